@@ -1,6 +1,9 @@
 package cmd
 
-import "io"
+import (
+	"io"
+	"os"
+)
 
 type CommandRunFunc func(cmd *Command, args []string) error
 
@@ -12,4 +15,13 @@ type Command struct {
 	Stdin  io.Reader
 	Name   string
 	Run    CommandRunFunc
+	// Spec declares the usage of builtins
+	Spec *Spec
+
+	// OnStart is called by commands that run as a separate process
+	// once that process has been started
+	OnStart func(*os.Process)
+	// OnExit is called by commands that run as a separate process
+	// once that process has exited
+	OnExit func(*os.ProcessState)
 }
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// getopts parses POSIX style short options for builtins. Options that
+// take an argument are followed by a colon in spec, e.g. "a:rs", and may
+// be combined as in `-rp prompt` or `-pprompt`. Parsing stops at the
+// first operand or after "--". args should not include the command name.
+func getopts(args []string, spec string) (map[byte]string, []string, error) {
+	opts := map[byte]string{}
+
+	i := 0
+	for ; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			i++
+			break
+		}
+		if len(arg) < 2 || arg[0] != '-' {
+			break
+		}
+
+		for j := 1; j < len(arg); j++ {
+			c := arg[j]
+			idx := strings.IndexByte(spec, c)
+			if idx < 0 || c == ':' {
+				return nil, nil, fmt.Errorf("-%c: invalid option", c)
+			}
+
+			takesArg := idx+1 < len(spec) && spec[idx+1] == ':'
+			if !takesArg {
+				opts[c] = ""
+				continue
+			}
+
+			if j+1 < len(arg) {
+				opts[c] = arg[j+1:]
+			} else {
+				if i+1 >= len(args) {
+					return nil, nil, fmt.Errorf("-%c: option requires an argument", c)
+				}
+				i++
+				opts[c] = args[i]
+			}
+			break
+		}
+	}
+
+	return opts, args[i:], nil
+}
@@ -0,0 +1,246 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// OpenFileFS opens files for reading and writing and creates the
+// directories they go in.
+type OpenFileFS interface {
+	OpenFile(string, int) (io.ReadWriteCloser, error)
+	MkdirAll(string, fs.FileMode) error
+}
+
+// IndexPath starts indexing the executables in pathEnv in the
+// background. Lookups only wait for the index when the name they are
+// after has not been indexed yet.
+func (r *Registry) IndexPath(pathEnv string, fsys fs.FS, fullPath func(string) (string, error)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pathEnv = pathEnv
+	r.fsys = fsys
+	r.fullPath = fullPath
+	r.startIndexing()
+}
+
+// UseIndexCache keeps the executables found in each PATH directory in
+// filename, so that directories that did not change since the last
+// session do not have to be read again. It should be called before
+// IndexPath.
+func (r *Registry) UseIndexCache(fsys OpenFileFS, filename string) {
+	c := &indexCache{
+		fsys:     fsys,
+		filename: filename,
+		Dirs:     map[string]cachedDir{},
+	}
+	c.load()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cache = c
+}
+
+// WaitIndexed blocks until every PATH directory has been indexed.
+func (r *Registry) WaitIndexed() {
+	r.mu.RLock()
+	indexed := r.indexed
+	r.mu.RUnlock()
+	<-indexed
+}
+
+// waitFor blocks until name has been indexed or indexing finished.
+func (r *Registry) waitFor(name string) {
+	for {
+		r.mu.RLock()
+		_, isHashed := r.hashed[name]
+		_, isIndexed := r.path[name]
+		indexed, updated := r.indexed, r.updated
+		r.mu.RUnlock()
+
+		if isHashed || isIndexed {
+			return
+		}
+
+		select {
+		case <-indexed:
+			return
+		case <-updated:
+		}
+	}
+}
+
+// startIndexing replaces the index with one that is built in the
+// background. r.mu must be held.
+func (r *Registry) startIndexing() {
+	r.generation++
+	r.path = map[string][]string{}
+	r.names = newTrie()
+	for name := range r.builtins {
+		r.names.insert(name)
+	}
+	r.notifyUpdated()
+
+	r.indexed = make(chan struct{})
+	if r.fsys == nil {
+		close(r.indexed)
+		return
+	}
+
+	dirs := make([]string, 0)
+	for _, dir := range filepath.SplitList(r.pathEnv) {
+		if len(dir) > 0 {
+			dirs = append(dirs, dir)
+		}
+	}
+	go r.index(r.generation, dirs, r.fsys, r.fullPath, r.indexed)
+}
+
+func (r *Registry) notifyUpdated() {
+	close(r.updated)
+	r.updated = make(chan struct{})
+}
+
+func (r *Registry) index(generation int, dirs []string, fsys fs.FS, fullPath func(string) (string, error), indexed chan struct{}) {
+	defer close(indexed)
+
+	results := make([]chan map[string]string, len(dirs))
+	for i, dir := range dirs {
+		results[i] = make(chan map[string]string, 1)
+		go func() {
+			results[i] <- r.indexDir(dir, fsys, fullPath)
+		}()
+	}
+
+	// directories are read concurrently but added in PATH order so
+	// that the index holds the first match for a name even before it
+	// is complete
+	for _, result := range results {
+		cmdPaths := <-result
+
+		r.mu.Lock()
+		if r.generation != generation {
+			r.mu.Unlock()
+			return
+		}
+		for name, execPath := range cmdPaths {
+			r.addPathExec(name, execPath)
+		}
+		r.notifyUpdated()
+		r.mu.Unlock()
+	}
+
+	r.mu.RLock()
+	cache := r.cache
+	r.mu.RUnlock()
+	cache.save()
+}
+
+func (r *Registry) indexDir(dir string, fsys fs.FS, fullPath func(string) (string, error)) map[string]string {
+	dir, err := fullPath(dir)
+	if err != nil {
+		return nil
+	}
+
+	fi, err := fs.Stat(fsys, dir)
+	if err != nil || !fi.IsDir() {
+		return nil
+	}
+
+	r.mu.RLock()
+	cache := r.cache
+	r.mu.RUnlock()
+
+	if cmdPaths, ok := cache.lookup(dir, fi.ModTime()); ok {
+		return cmdPaths
+	}
+
+	cmdPaths, _ := commandsInPath(dir, fsys)
+	cache.store(dir, fi.ModTime(), cmdPaths)
+	return cmdPaths
+}
+
+// indexCache is the on-disk cache of the executables in each PATH
+// directory. Entries are valid as long as the modification time of the
+// directory is unchanged. A nil cache caches nothing.
+type indexCache struct {
+	mu       sync.Mutex
+	fsys     OpenFileFS
+	filename string
+	dirty    bool
+
+	Dirs map[string]cachedDir `json:"dirs"`
+}
+
+type cachedDir struct {
+	ModTime  time.Time         `json:"mod_time"`
+	Commands map[string]string `json:"commands"`
+}
+
+func (c *indexCache) lookup(dir string, modTime time.Time) (map[string]string, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	d, ok := c.Dirs[dir]
+	if !ok || !d.ModTime.Equal(modTime) {
+		return nil, false
+	}
+	return d.Commands, true
+}
+
+func (c *indexCache) store(dir string, modTime time.Time, cmdPaths map[string]string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Dirs[dir] = cachedDir{ModTime: modTime, Commands: cmdPaths}
+	c.dirty = true
+}
+
+// load reads the cache from disk. A missing or corrupt cache is
+// treated as empty.
+func (c *indexCache) load() {
+	f, err := c.fsys.OpenFile(c.filename, os.O_RDONLY)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	if err := json.NewDecoder(f).Decode(c); err != nil || c.Dirs == nil {
+		c.Dirs = map[string]cachedDir{}
+	}
+}
+
+func (c *indexCache) save() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.dirty {
+		return
+	}
+
+	// a cache that cannot be written only costs a slower start next
+	// time, so errors are not reported
+	if err := c.fsys.MkdirAll(filepath.Dir(c.filename), 0o700); err != nil {
+		return
+	}
+	f, err := c.fsys.OpenFile(c.filename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	if err := json.NewEncoder(f).Encode(c); err == nil {
+		c.dirty = false
+	}
+}
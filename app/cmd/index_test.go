@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"os"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memFiles keeps the files written through it in memory.
+type memFiles struct {
+	files map[string]*bytes.Buffer
+	dirs  []string
+}
+
+type memFile struct {
+	*bytes.Buffer
+}
+
+func (memFile) Close() error { return nil }
+
+func (m *memFiles) OpenFile(name string, flags int) (io.ReadWriteCloser, error) {
+	if flags&os.O_CREATE != 0 {
+		m.files[name] = &bytes.Buffer{}
+	}
+	b, ok := m.files[name]
+	if !ok {
+		return nil, fs.ErrNotExist
+	}
+	return memFile{b}, nil
+}
+
+func (m *memFiles) MkdirAll(name string, perm fs.FileMode) error {
+	m.dirs = append(m.dirs, name)
+	return nil
+}
+
+func indexWithCache(t *testing.T, fsys fstest.MapFS, cache *memFiles) *Registry {
+	t.Helper()
+	r := NewResitry(nil)
+	r.UseIndexCache(cache, "cache/index.json")
+	r.IndexPath("bin", fsys, func(dir string) (string, error) { return dir, nil })
+	r.WaitIndexed()
+	return r
+}
+
+func cachedCommands(t *testing.T, cache *memFiles) map[string]string {
+	t.Helper()
+	var c indexCache
+	require.NoError(t, json.Unmarshal(cache.files["cache/index.json"].Bytes(), &c))
+	return c.Dirs["bin"].Commands
+}
+
+func TestIndexCache(t *testing.T) {
+	modTime := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	fsys := fstest.MapFS{
+		"bin":    {Mode: fs.ModeDir | 0o755, ModTime: modTime},
+		"bin/ls": {Mode: 0o755},
+	}
+
+	cache := &memFiles{files: map[string]*bytes.Buffer{}}
+	r := indexWithCache(t, fsys, cache)
+	_, ok := r.Hash("ls")
+	assert.True(t, ok)
+	// the cache directory is created before writing to it
+	assert.Equal(t, []string{"cache"}, cache.dirs)
+	assert.Equal(t, map[string]string{"ls": "bin/ls"}, cachedCommands(t, cache))
+
+	// listings of directories that did not change come from the cache
+	delete(fsys, "bin/ls")
+	fsys["bin/cat"] = &fstest.MapFile{Mode: 0o755}
+	r = indexWithCache(t, fsys, cache)
+	assert.Equal(t, []Match{{Name: "ls", Kind: KindFile, Path: "bin/ls"}}, r.Match("", MatchOptions{}))
+}
+
+func TestIndexCacheStale(t *testing.T) {
+	modTime := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	fsys := fstest.MapFS{
+		"bin":     {Mode: fs.ModeDir | 0o755, ModTime: modTime},
+		"bin/cat": {Mode: 0o755},
+	}
+
+	stale, err := json.Marshal(indexCache{Dirs: map[string]cachedDir{
+		"bin": {ModTime: modTime.Add(-time.Hour), Commands: map[string]string{"ls": "bin/ls"}},
+	}})
+	require.NoError(t, err)
+	cache := &memFiles{files: map[string]*bytes.Buffer{"cache/index.json": bytes.NewBuffer(stale)}}
+
+	// a directory changed since it was cached is read again
+	r := indexWithCache(t, fsys, cache)
+	assert.Equal(t, []Match{{Name: "cat", Kind: KindFile, Path: "bin/cat"}}, r.Match("", MatchOptions{}))
+	assert.Equal(t, map[string]string{"cat": "bin/cat"}, cachedCommands(t, cache))
+}
+
+func TestIndexCacheCorrupt(t *testing.T) {
+	fsys := fstest.MapFS{
+		"bin":    {Mode: fs.ModeDir | 0o755},
+		"bin/ls": {Mode: 0o755},
+	}
+	cache := &memFiles{files: map[string]*bytes.Buffer{
+		"cache/index.json": bytes.NewBufferString(`{"dirs": {"bin": [`),
+	}}
+
+	// a corrupt cache is ignored and written again
+	r := indexWithCache(t, fsys, cache)
+	assert.Len(t, r.LookupAll("ls"), 1)
+	assert.Equal(t, map[string]string{"ls": "bin/ls"}, cachedCommands(t, cache))
+}
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+)
+
+// MatchMode tells how a query is compared to command names
+type MatchMode int
+
+const (
+	// MatchPrefix matches names starting with the query
+	MatchPrefix MatchMode = iota
+	// MatchSubstring matches names containing the query
+	MatchSubstring
+)
+
+// MatchOptions configure Registry.Match. A Limit of 0 returns every
+// match.
+type MatchOptions struct {
+	Mode       MatchMode
+	IgnoreCase bool
+	Limit      int
+}
+
+// Match is a command name matching a query. Path is only set for files
+// and holds the executable that takes precedence.
+type Match struct {
+	Name string
+	Kind CommandKind
+	Path string
+}
+
+// SetRankFunc sets the function ranking matches of the same kind. Names
+// with a higher rank are returned first, ties are sorted by name. rank is
+// called with the registry locked and must not use it.
+func (r *Registry) SetRankFunc(rank func(name string) float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rank = rank
+}
+
+// Match returns the builtins and the executables indexed so far that
+// match query. Builtins come first, then the names ranked highest by the
+// rank function, then the rest in alphabetical order.
+func (r *Registry) Match(query string, opts MatchOptions) []Match {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	type ranked struct {
+		Match
+		rank float64
+	}
+
+	var matches []ranked
+	add := func(name string) {
+		m := Match{Name: name, Kind: KindBuiltin}
+		if _, ok := r.builtins.Lookup(name); !ok {
+			// names removed from the index stay in the trie
+			paths := r.path[name]
+			if len(paths) == 0 {
+				return
+			}
+			m.Kind, m.Path = KindFile, paths[0]
+		}
+
+		var rank float64
+		if r.rank != nil {
+			rank = r.rank(name)
+		}
+		matches = append(matches, ranked{Match: m, rank: rank})
+	}
+
+	switch opts.Mode {
+	case MatchSubstring:
+		lowerQuery := strings.ToLower(query)
+		r.names.each(func(name string) {
+			if strings.Contains(name, query) ||
+				opts.IgnoreCase && strings.Contains(strings.ToLower(name), lowerQuery) {
+				add(name)
+			}
+		})
+	default:
+		r.names.walk(query, func(name string) {
+			if opts.IgnoreCase || strings.HasPrefix(name, query) {
+				add(name)
+			}
+		})
+	}
+
+	slices.SortFunc(matches, func(a, b ranked) int {
+		return cmp.Or(
+			cmp.Compare(a.Kind, b.Kind),
+			cmp.Compare(b.rank, a.rank),
+			strings.Compare(a.Name, b.Name),
+		)
+	})
+
+	if opts.Limit > 0 && len(matches) > opts.Limit {
+		matches = matches[:opts.Limit]
+	}
+
+	result := make([]Match, len(matches))
+	for i, m := range matches {
+		result[i] = m.Match
+	}
+	return result
+}
+
+// MatchFirst returns the best ranked name starting with prefix.
+func (r *Registry) MatchFirst(prefix string) (Match, bool) {
+	matches := r.Match(prefix, MatchOptions{Limit: 1})
+	if len(matches) == 0 {
+		return Match{}, false
+	}
+	return matches[0], true
+}
@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"sort"
+)
+
+// HashEntry is an executable remembered by the registry and the number
+// of times it has been run.
+type HashEntry struct {
+	Name string
+	Path string
+	Hits int
+}
+
+// SetPathEnv rebuilds the index of executables when pathEnv differs from
+// the PATH the registry was built from. Remembered executables are
+// forgotten as they may no longer be the first match.
+func (r *Registry) SetPathEnv(pathEnv string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.fsys == nil || pathEnv == r.pathEnv {
+		return
+	}
+	r.pathEnv = pathEnv
+	r.rehash()
+}
+
+// Rehash forgets all remembered executables and indexes PATH again.
+func (r *Registry) Rehash() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rehash()
+}
+
+func (r *Registry) rehash() {
+	r.hashed = map[string]*HashEntry{}
+	if r.fsys != nil {
+		r.startIndexing()
+	}
+}
+
+// Hash looks up name in PATH and remembers where it was found.
+func (r *Registry) Hash(name string) (string, bool) {
+	r.waitFor(name)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.resolvePath(name)
+}
+
+// HashPath remembers execPath as the executable for name without
+// looking it up.
+func (r *Registry) HashPath(name string, execPath string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.hashed[name] = &HashEntry{Name: name, Path: execPath}
+}
+
+// Forget drops the remembered executable for name and reports whether
+// there was one.
+func (r *Registry) Forget(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.hashed[name]
+	delete(r.hashed, name)
+	return ok
+}
+
+// Hashed returns the remembered executables sorted by name.
+func (r *Registry) Hashed() []HashEntry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	entries := make([]HashEntry, 0, len(r.hashed))
+	for _, e := range r.hashed {
+		entries = append(entries, *e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name < entries[j].Name
+	})
+	return entries
+}
+
+// resolvePath returns the executable name runs, preferring the one
+// remembered for it. Executables that disappeared are dropped along the
+// way. r.mu must be held.
+func (r *Registry) resolvePath(name string) (string, bool) {
+	if e, ok := r.hashed[name]; ok {
+		if r.isExecutable(e.Path) {
+			return e.Path, true
+		}
+		delete(r.hashed, name)
+	}
+
+	candidates := r.pathCandidates(name)
+	if len(candidates) == 0 {
+		return "", false
+	}
+	r.hashed[name] = &HashEntry{Name: name, Path: candidates[0]}
+	return candidates[0], true
+}
+
+// pathCandidates returns the executables in PATH for name, searching
+// PATH directly for names missing from the index. r.mu must be held.
+func (r *Registry) pathCandidates(name string) []string {
+	candidates, ok := r.path[name]
+	if ok {
+		candidates = slices.DeleteFunc(slices.Clone(candidates), func(execPath string) bool {
+			return !r.isExecutable(execPath)
+		})
+		if len(candidates) > 0 {
+			r.path[name] = candidates
+			return candidates
+		}
+		delete(r.path, name)
+	}
+
+	candidates = r.searchPath(name)
+	if len(candidates) > 0 {
+		r.path[name] = candidates
+		r.names.insert(name)
+	}
+	return candidates
+}
+
+func (r *Registry) searchPath(name string) []string {
+	if r.fsys == nil || len(name) == 0 || filepath.Base(name) != name {
+		return nil
+	}
+
+	found := make([]string, 0)
+	for _, dir := range filepath.SplitList(r.pathEnv) {
+		if len(dir) == 0 {
+			continue
+		}
+		dir, err := r.fullPath(dir)
+		if err != nil {
+			continue
+		}
+
+		execPath := filepath.Join(dir, name)
+		if r.isExecutable(execPath) && !slices.Contains(found, execPath) {
+			found = append(found, execPath)
+		}
+	}
+	return found
+}
+
+// isExecutable checks that execPath still exists. Registries without a
+// file system trust their entries.
+func (r *Registry) isExecutable(execPath string) bool {
+	if r.fsys == nil {
+		return true
+	}
+	fi, err := fs.Stat(r.fsys, execPath)
+	return err == nil && !fi.IsDir() && hasExecPerms(fi.Mode().Perm())
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRegistry(fsys fstest.MapFS, pathEnv string) *Registry {
+	r := NewResitry(func(name, execPath string) CommandFunc {
+		return func() *Command { return &Command{Name: name} }
+	})
+	r.IndexPath(pathEnv, fsys, func(dir string) (string, error) { return dir, nil })
+	r.WaitIndexed()
+	return r
+}
+
+func TestHash(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a/ls":   {Mode: 0o755},
+		"b/ls":   {Mode: 0o755},
+		"b/cat":  {Mode: 0o755},
+		"b/data": {Mode: 0o644},
+	}
+	r := newTestRegistry(fsys, "a:b")
+
+	execPath, ok := r.Hash("ls")
+	assert.True(t, ok)
+	assert.Equal(t, "a/ls", execPath)
+
+	_, ok = r.Hash("data")
+	assert.False(t, ok)
+
+	// executables that disappeared are replaced by the next in PATH
+	delete(fsys, "a/ls")
+	execPath, ok = r.Hash("ls")
+	assert.True(t, ok)
+	assert.Equal(t, "b/ls", execPath)
+
+	assert.Equal(t, []HashEntry{{Name: "ls", Path: "b/ls"}}, r.Hashed())
+	assert.True(t, r.Forget("ls"))
+	assert.False(t, r.Forget("ls"))
+}
+
+func TestConcurrentLookups(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a/ls":  {Mode: 0o755},
+		"a/cat": {Mode: 0o755},
+	}
+	r := newTestRegistry(fsys, "a")
+
+	// commands in a pipeline are looked up from goroutines of their own
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Go(func() {
+			for _, name := range []string{"ls", "cat", "missing"} {
+				r.LookupCommand(name)
+				r.LookupAll(name)
+				r.Hash(name)
+				r.Hashed()
+				r.Forget(name)
+			}
+		})
+	}
+	wg.Wait()
+}
+
+func TestLookupAll(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a/ls":   {Mode: 0o755},
+		"b/ls":   {Mode: 0o755},
+		"b/echo": {Mode: 0o755},
+		"b/data": {Mode: 0o644},
+	}
+	r := newTestRegistry(fsys, "a:b")
+	r.AddBuiltinCommand("echo", nil)
+
+	assert.Equal(t, []Candidate{
+		{Name: "echo", Kind: KindBuiltin},
+		{Name: "echo", Kind: KindFile, Path: "b/echo"},
+	}, r.LookupAll("echo"))
+	assert.Equal(t, []Candidate{
+		{Name: "ls", Kind: KindFile, Path: "a/ls"},
+		{Name: "ls", Kind: KindFile, Path: "b/ls"},
+	}, r.LookupAll("ls"))
+	assert.Empty(t, r.LookupAll("data"))
+	assert.Empty(t, r.LookupAll("missing"))
+}
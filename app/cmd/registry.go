@@ -3,64 +3,161 @@ package cmd
 import (
 	"io/fs"
 	"path/filepath"
-	"regexp"
 	"runtime"
 	"slices"
 	"strings"
+	"sync"
 
 	"github.com/codecrafters-io/shell-starter-go/assert"
 )
 
+// Registry resolves command names to builtins and executables in PATH.
+// It is safe for concurrent use.
 type Registry struct {
-	builtins     commandMap
-	path         map[string]string
+	mu sync.RWMutex
+
+	builtins commandMap
+	// path holds every executable found for a name in PATH order
+	path map[string][]string
+	// hashed holds the executables that were looked up, as listed by
+	// the `hash` builtin
+	hashed map[string]*HashEntry
+	// names indexes the builtin and PATH names for completion
+	names        *trie
+	rank         func(name string) float64
 	buildCmdFunc func(exec string, path string) CommandFunc
+
+	// pathEnv is the PATH the registry was built from. When fsys is
+	// set missing names are searched for on demand and cached paths
+	// are checked before they are used.
+	pathEnv  string
+	fsys     fs.FS
+	fullPath func(string) (string, error)
+
+	// indexed is closed once the index covers every PATH directory
+	// and updated every time a directory was added to it
+	indexed    chan struct{}
+	updated    chan struct{}
+	generation int
+	cache      *indexCache
+}
+
+// CommandKind tells what a command name resolves to
+type CommandKind int
+
+const (
+	KindBuiltin CommandKind = iota
+	KindFile
+)
+
+func (k CommandKind) String() string {
+	switch k {
+	case KindBuiltin:
+		return "builtin"
+	case KindFile:
+		return "file"
+	default:
+		return "unknown"
+	}
+}
+
+// Candidate is one of the commands a name resolves to. Path is only set
+// for files.
+type Candidate struct {
+	Name string
+	Kind CommandKind
+	Path string
 }
 
 func NewResitry(
 	buildCmdFunc func(exec string, path string) CommandFunc,
 ) *Registry {
+	indexed := make(chan struct{})
+	close(indexed)
 	return &Registry{
 		builtins:     commandMap{},
-		path:         map[string]string{},
+		path:         map[string][]string{},
+		hashed:       map[string]*HashEntry{},
+		names:        newTrie(),
 		buildCmdFunc: buildCmdFunc,
+		indexed:      indexed,
+		updated:      make(chan struct{}),
 	}
 }
 
 func (r *Registry) AddBuiltinCommand(name string, cmd CommandFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	assert.NotNil(r.builtins)
 	r.builtins[name] = cmd
+	r.names.insert(name)
 }
 
+// AddPathExec adds execPath as a candidate for name. Candidates added
+// first take precedence.
 func (r *Registry) AddPathExec(name string, execPath string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.addPathExec(name, execPath)
+}
+
+func (r *Registry) addPathExec(name string, execPath string) {
 	assert.NotNil(r.path)
-	r.path[name] = execPath
+	if slices.Contains(r.path[name], execPath) {
+		return
+	}
+	if len(r.path[name]) == 0 {
+		r.names.insert(name)
+	}
+	r.path[name] = append(r.path[name], execPath)
 }
 
 func (r *Registry) LookupBuiltinCommand(name string) (*Command, bool) {
+	r.mu.RLock()
 	cf, ok := r.builtins.Lookup(name)
+	r.mu.RUnlock()
 	if !ok {
 		return nil, false
 	}
-	return cf(), true
+	return withHelp(cf()), true
+}
+
+// Builtins returns the names of the builtins in alphabetical order.
+func (r *Registry) Builtins() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.builtins))
+	for name := range r.builtins {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
 }
 
 func (r *Registry) LookupPathCommand(name string) (string, *Command, bool) {
-	execPath, ok := r.path[name]
+	r.waitFor(name)
+
+	r.mu.Lock()
+	execPath, ok := r.resolvePath(name)
+	if ok {
+		r.hashed[name].Hits++
+	}
+	r.mu.Unlock()
 	if !ok {
 		return "", nil, false
 	}
+
 	cmd := r.buildCmdFunc(name, execPath)()
 	return execPath, cmd, true
 }
 
 func (r *Registry) LookupCommand(name string) (*Command, bool) {
-	cd, ok := r.builtins.Lookup(name)
+	cmd, ok := r.LookupBuiltinCommand(name)
 	if ok {
-		return cd(), true
+		return cmd, true
 	}
 
-	_, cmd, ok := r.LookupPathCommand(name)
+	_, cmd, ok = r.LookupPathCommand(name)
 	if ok {
 		return cmd, true
 	}
@@ -68,43 +165,23 @@ func (r *Registry) LookupCommand(name string) (*Command, bool) {
 	return nil, false
 }
 
-func (r *Registry) MatchAll(reg *regexp.Regexp) []string {
-	matches := map[string]struct{}{}
-
-	for k := range r.builtins {
-		if reg.MatchString(k) {
-			matches[k] = struct{}{}
-		}
-	}
-
-	for k := range r.path {
-		if reg.MatchString(k) {
-			matches[k] = struct{}{}
-		}
-	}
+// LookupAll returns every command name resolves to in order of
+// precedence, the builtin first and then each executable in PATH order.
+// It waits for PATH to be fully indexed.
+func (r *Registry) LookupAll(name string) []Candidate {
+	r.WaitIndexed()
 
-	keys := make([]string, 0, len(matches))
-	for k := range matches {
-		keys = append(keys, k)
-	}
-	slices.Reverse(keys)
-	return keys
-}
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
-func (r *Registry) MatchFirst(prefix string) (string, bool) {
-	for k := range r.builtins {
-		if strings.HasPrefix(k, prefix) {
-			return k, true
-		}
+	candidates := make([]Candidate, 0, 1)
+	if _, ok := r.builtins.Lookup(name); ok {
+		candidates = append(candidates, Candidate{Name: name, Kind: KindBuiltin})
 	}
-
-	for k := range r.path {
-		if strings.HasPrefix(k, prefix) {
-			return k, true
-		}
+	for _, execPath := range r.pathCandidates(name) {
+		candidates = append(candidates, Candidate{Name: name, Kind: KindFile, Path: execPath})
 	}
-
-	return "", false
+	return candidates
 }
 
 type commandMap map[string]CommandFunc
@@ -114,41 +191,13 @@ func (m commandMap) Lookup(name string) (CommandFunc, bool) {
 	return c, ok
 }
 
-func LoadFromPathEnv(
-	pathEnv string,
-	fsys fs.FS,
-	fullPath func(string) (string, error),
-	buildCmdFunc func(exec string, path string) CommandFunc,
-) (*Registry, error) {
-	registry := NewResitry(buildCmdFunc)
-	if strings.TrimSpace(pathEnv) == "" {
-		return registry, nil
-	}
-
-	paths := filepath.SplitList(pathEnv)
-	for _, p := range paths {
-		if len(p) == 0 {
-			continue
-		}
-
-		cmdPaths, _ := commandsInPath(registry, p, fsys, fullPath)
-		for k, v := range cmdPaths {
-			registry.AddPathExec(k, v)
-		}
-	}
-
-	return registry, nil
-}
-
-func commandsInPath(registry *Registry, dir string, fsys fs.FS, fullPath func(string) (string, error)) (map[string]string, error) {
+func commandsInPath(dir string, fsys fs.FS) (map[string]string, error) {
 	assert.Assert(len(dir) > 0, "expected non-empty path")
 	assert.Assertf(len(dir) < 4026, "unexpectedly large path: %s", dir)
 	assert.NotNil(fsys, "fsys")
-	assert.NotNil(fullPath, "fullPath func")
 
 	cmdPaths := map[string]string{}
 
-	dir, _ = fullPath(dir)
 	err := fs.WalkDir(fsys, dir,
 		func(path string, entry fs.DirEntry, err error) error {
 			if err != nil {
@@ -163,8 +212,8 @@ func commandsInPath(registry *Registry, dir string, fsys fs.FS, fullPath func(st
 				return fs.SkipDir
 			}
 
-			fi, _ := entry.Info()
-			if !hasExecPerms(fi.Mode().Perm()) {
+			fi, err := entry.Info()
+			if err != nil || !hasExecPerms(fi.Mode().Perm()) {
 				return nil
 			}
 
@@ -173,9 +222,6 @@ func commandsInPath(registry *Registry, dir string, fsys fs.FS, fullPath func(st
 			if _, ok := cmdPaths[fname]; ok {
 				return nil
 			}
-			if _, ok := registry.path[fname]; ok {
-				return nil
-			}
 
 			cmdPaths[fname] = path
 			return nil
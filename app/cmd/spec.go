@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ArgKind tells what the operands of a command are, so they can be
+// completed
+type ArgKind int
+
+const (
+	ArgAny ArgKind = iota
+	ArgCommand
+	ArgDirectory
+)
+
+// Flag is a short option of a command. Arg names the argument of flags
+// that take one.
+type Flag struct {
+	Name  byte
+	Arg   string
+	Usage string
+}
+
+// Spec declares how a command is used. It drives argument parsing, the
+// --help output, the help builtin and completion.
+type Spec struct {
+	// Synopsis is the usage line, starting with the command name
+	Synopsis string
+	// Short is a one line description
+	Short string
+	// Long is the help text shown below Short
+	Long  string
+	Flags []Flag
+	Args  ArgKind
+	// RawArgs is set for commands that see every argument as is, even
+	// --help
+	RawArgs bool
+}
+
+// Parse parses the flags at the start of args, which should not include
+// the command name. The values of flags without an argument are empty.
+// Errors include the usage line.
+func (s *Spec) Parse(args []string) (map[byte]string, []string, error) {
+	optstring := strings.Builder{}
+	for _, f := range s.Flags {
+		optstring.WriteByte(f.Name)
+		if len(f.Arg) > 0 {
+			optstring.WriteByte(':')
+		}
+	}
+
+	flags, rest, err := getopts(args, optstring.String())
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w\n%s", err, s.Usage())
+	}
+	return flags, rest, nil
+}
+
+// Usage returns the usage line in the format used by error messages.
+func (s *Spec) Usage() string {
+	name, _, _ := strings.Cut(s.Synopsis, " ")
+	return fmt.Sprintf("%s: usage: %s", name, s.Synopsis)
+}
+
+// LookupFlag returns the flag called name.
+func (s *Spec) LookupFlag(name byte) (Flag, bool) {
+	for _, f := range s.Flags {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return Flag{}, false
+}
+
+// WriteHelp writes the help of the command called name to w.
+func (s *Spec) WriteHelp(w io.Writer, name string) error {
+	b := strings.Builder{}
+	fmt.Fprintf(&b, "%s: %s\n", name, s.Synopsis)
+	if len(s.Short) > 0 {
+		fmt.Fprintf(&b, "    %s\n", s.Short)
+	}
+	if len(s.Long) > 0 {
+		b.WriteString("\n")
+		for _, line := range strings.Split(strings.TrimSpace(s.Long), "\n") {
+			if len(line) > 0 {
+				b.WriteString("    ")
+			}
+			b.WriteString(line + "\n")
+		}
+	}
+	if len(s.Flags) > 0 {
+		b.WriteString("\n    Options:\n")
+		for _, f := range s.Flags {
+			flag := "-" + string(f.Name)
+			if len(f.Arg) > 0 {
+				flag += " " + f.Arg
+			}
+			fmt.Fprintf(&b, "      %-12s%s\n", flag, f.Usage)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// withHelp makes c print its help when run with --help as its only
+// argument.
+func withHelp(c *Command) *Command {
+	if c.Spec == nil || c.Spec.RawArgs {
+		return c
+	}
+	run := c.Run
+	c.Run = func(c *Command, args []string) error {
+		if len(args) == 2 && args[1] == "--help" {
+			return c.Spec.WriteHelp(c.Stdout, c.Name)
+		}
+		return run(c, args)
+	}
+	return c
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"strings"
+)
+
+// trie is a prefix index of command names. Names are indexed by their
+// lower case form so the same index serves case-insensitive lookups.
+type trie struct {
+	children map[rune]*trie
+	// names holds the names whose lower case form ends at this node
+	names []string
+}
+
+func newTrie() *trie {
+	return &trie{}
+}
+
+func (t *trie) insert(name string) {
+	node := t
+	for _, r := range strings.ToLower(name) {
+		if node.children == nil {
+			node.children = map[rune]*trie{}
+		}
+		child, ok := node.children[r]
+		if !ok {
+			child = &trie{}
+			node.children[r] = child
+		}
+		node = child
+	}
+	for _, n := range node.names {
+		if n == name {
+			return
+		}
+	}
+	node.names = append(node.names, name)
+}
+
+// walk calls fn for every name whose lower case form starts with the
+// lower case form of prefix.
+func (t *trie) walk(prefix string, fn func(name string)) {
+	node := t
+	for _, r := range strings.ToLower(prefix) {
+		child, ok := node.children[r]
+		if !ok {
+			return
+		}
+		node = child
+	}
+	node.each(fn)
+}
+
+func (t *trie) each(fn func(name string)) {
+	for _, name := range t.names {
+		fn(name)
+	}
+	for _, child := range t.children {
+		child.each(fn)
+	}
+}
@@ -7,3 +7,15 @@ type goenv struct{}
 func (_ goenv) Get(key string) string {
 	return os.Getenv(key)
 }
+
+func (_ goenv) Lookup(key string) (string, bool) {
+	return os.LookupEnv(key)
+}
+
+func (_ goenv) Set(key, value string) error {
+	return os.Setenv(key, value)
+}
+
+func (_ goenv) Unset(key string) error {
+	return os.Unsetenv(key)
+}
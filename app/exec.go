@@ -3,8 +3,10 @@ package main
 import (
 	"errors"
 	"os/exec"
+	"syscall"
 
 	"github.com/codecrafters-io/shell-starter-go/app/cmd"
+	"github.com/codecrafters-io/shell-starter-go/app/shell/interpreter"
 	"github.com/codecrafters-io/shell-starter-go/assert"
 )
 
@@ -21,11 +23,26 @@ func goexec(c *cmd.Command, path string, args []string) error {
 		Stderr: c.Stderr,
 	}
 
-	err := cmd.Run()
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	if c.OnStart != nil {
+		c.OnStart(cmd.Process)
+	}
+
+	err := cmd.Wait()
+	if c.OnExit != nil && cmd.ProcessState != nil {
+		c.OnExit(cmd.ProcessState)
+	}
+
 	var exitErr *exec.ExitError
 	if !errors.As(err, &exitErr) {
 		return err
 	}
 
-	return nil
+	code := exitErr.ExitCode()
+	if ws, ok := exitErr.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		code = 128 + int(ws.Signal())
+	}
+	return interpreter.ExitStatus(code)
 }
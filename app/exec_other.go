@@ -0,0 +1,16 @@
+//go:build !unix
+
+package main
+
+import (
+	"errors"
+
+	"github.com/codecrafters-io/shell-starter-go/app/cmd"
+)
+
+// canReplace tells whether goreplace can replace the shell process
+const canReplace = false
+
+func goreplace(c *cmd.Command, path string, args []string) error {
+	return errors.New("replacing the shell process is not supported on this platform")
+}
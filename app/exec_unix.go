@@ -0,0 +1,31 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"syscall"
+
+	"github.com/codecrafters-io/shell-starter-go/app/cmd"
+	"golang.org/x/sys/unix"
+)
+
+// canReplace tells whether goreplace can replace the shell process
+const canReplace = true
+
+// goreplace replaces the current process with the executable at path.
+// Streams redirected to files are moved onto the standard file
+// descriptors first since those are what the new process inherits.
+func goreplace(c *cmd.Command, path string, args []string) error {
+	streams := []any{c.Stdin, c.Stdout, c.Stderr}
+	for fd, stream := range streams {
+		f, ok := stream.(interface{ Fd() uintptr })
+		if !ok || int(f.Fd()) == fd {
+			continue
+		}
+		if err := unix.Dup2(int(f.Fd()), fd); err != nil {
+			return err
+		}
+	}
+	return syscall.Exec(path, args, os.Environ())
+}
@@ -12,10 +12,20 @@ func (_ gofs) Open(name string) (fs.File, error) {
 	return os.Open(name)
 }
 
+func (_ gofs) Stat(name string) (fs.FileInfo, error) {
+	return os.Stat(name)
+}
+
 func (_ gofs) ReadDir(name string) ([]fs.DirEntry, error) {
 	return os.ReadDir(name)
 }
 
+func (_ gofs) MkdirAll(name string, perm fs.FileMode) error {
+	return os.MkdirAll(name, perm)
+}
+
+// OpenFile creates files readable and writable by everyone, leaving it
+// to the umask to take permissions away.
 func (_ gofs) OpenFile(name string, flags int) (io.ReadWriteCloser, error) {
-	return os.OpenFile(name, flags, 0644)
+	return os.OpenFile(name, flags, 0666)
 }
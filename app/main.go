@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"syscall"
 
+	"github.com/codecrafters-io/shell-starter-go/app/cmd"
 	"github.com/codecrafters-io/shell-starter-go/app/plugin"
 	"github.com/codecrafters-io/shell-starter-go/app/shell"
 	"github.com/codecrafters-io/shell-starter-go/app/shell/history"
@@ -37,6 +38,18 @@ func run() {
 		ExecFunc:       goexec,
 		FullPathFunc:   filepath.Abs,
 		WorkingDir:     workingDir,
+		TerminalSizeFunc: func() (int, int, error) {
+			return term.GetSize(fd)
+		},
+	}
+	if canReplace {
+		s.ExecReplaceFunc = func(c *cmd.Command, path string, args []string) error {
+			_ = term.Restore(fd, oldState)
+			err := goreplace(c, path, args)
+			// only reached when the exec failed
+			_, _ = term.MakeRaw(fd)
+			return err
+		}
 	}
 
 	s.WithPlugins(
@@ -48,6 +61,8 @@ func run() {
 	if os.Getenv("ENV") != "CODECRAFTERS" {
 		s.WithPlugins(
 			plugin.NewCompletionHints(),
+			plugin.NewFuzzyPicker(),
+			plugin.NewPasteGuard(),
 			plugin.NewLuaPluginLoader(".plugins"),
 		)
 	}
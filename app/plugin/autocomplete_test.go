@@ -1,9 +1,18 @@
 package plugin
 
 import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/codecrafters-io/shell-starter-go/app/cmd"
+	"github.com/codecrafters-io/shell-starter-go/app/shell"
+	"github.com/codecrafters-io/shell-starter-go/app/shell/history"
+	"github.com/codecrafters-io/shell-starter-go/app/shell/shelltest"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestLongestCommonPrefix(t *testing.T) {
@@ -18,3 +27,105 @@ func TestLongestCommonPrefix(t *testing.T) {
 	out := largestCommonPrefix(input)
 	assert.Equal(t, "1234", out)
 }
+
+func TestAutocompleteMatch(t *testing.T) {
+	r := cmd.NewResitry(nil)
+	r.AddBuiltinCommand("echo", nil)
+	r.AddPathExec("ed", "/bin/ed")
+	r.AddPathExec("egrep", "/bin/egrep")
+	r.AddPathExec("env", "/bin/env")
+	r.AddPathExec("Emacs", "/bin/Emacs")
+	r.SetRankFunc(func(name string) float64 {
+		if name == "env" {
+			return 1
+		}
+		return 0
+	})
+	a := &Autocomplete{registry: r}
+
+	matches, exact := a.match("e")
+	assert.True(t, exact)
+	assert.Equal(t, []string{"echo", "env", "ed", "egrep"}, matches)
+
+	matches, exact = a.match("em")
+	assert.False(t, exact)
+	assert.Equal(t, []string{"Emacs"}, matches)
+
+	matches, exact = a.match("rep")
+	assert.False(t, exact)
+	assert.Equal(t, []string{"egrep"}, matches)
+}
+
+func TestAutocompleteMatchArg(t *testing.T) {
+	r := cmd.NewResitry(nil)
+	r.AddBuiltinCommand("type", func() *cmd.Command {
+		return &cmd.Command{
+			Name: "type",
+			Spec: &cmd.Spec{
+				Synopsis: "type [-ap] name",
+				Flags:    []cmd.Flag{{Name: 'a'}, {Name: 'p'}},
+				Args:     cmd.ArgCommand,
+			},
+		}
+	})
+	r.AddPathExec("tar", "/bin/tar")
+	a := &Autocomplete{registry: r}
+
+	matches, exact := a.matchArg("type", "-")
+	assert.True(t, exact)
+	assert.Equal(t, []string{"-a", "-p"}, matches)
+
+	matches, _ = a.matchArg("type", "ta")
+	assert.Equal(t, []string{"tar"}, matches)
+
+	matches, _ = a.matchArg("tar", "-")
+	assert.Empty(t, matches)
+}
+
+func TestAutocompleteMatchDirectory(t *testing.T) {
+	home, workingDir := t.TempDir(), t.TempDir()
+	for _, dir := range []string{
+		filepath.Join(home, "Documents"),
+		filepath.Join(home, "Downloads"),
+		filepath.Join(home, "Music"),
+		filepath.Join(home, ".config"),
+		filepath.Join(workingDir, "src"),
+	} {
+		require.NoError(t, os.Mkdir(dir, 0o755))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(home, "Dockerfile"), nil, 0o644))
+
+	a := NewAutoComplete()
+	s := &shell.Shell{
+		Stdout:         io.Discard,
+		Stderr:         io.Discard,
+		Stdin:          strings.NewReader(""),
+		Env:            shelltest.MapEnv{"HOME": home},
+		FS:             shelltest.OSFS{},
+		FullPathFunc:   filepath.Abs,
+		WorkingDir:     workingDir,
+		HistoryContext: history.NewHistoryContext(history.NewInMemoryHistory()),
+	}
+	s.WithPlugins(a)
+	require.NoError(t, s.Run())
+
+	tt := []struct {
+		input   string
+		matches []string
+	}{
+		{"~/Do", []string{"~/Documents/", "~/Downloads/"}},
+		{"~/M", []string{"~/Music/"}},
+		{"~/", []string{"~/Documents/", "~/Downloads/", "~/Music/"}},
+		{"~/.", []string{"~/.config/"}},
+		{"~/Music/", []string{}},
+		{"~/missing/", []string{}},
+		{"s", []string{"src/"}},
+		{home + "/Mu", []string{home + "/Music/"}},
+	}
+
+	for _, test := range tt {
+		matches, exact := a.matchDirectory(test.input)
+		assert.True(t, exact, test.input)
+		assert.Equal(t, test.matches, matches, test.input)
+	}
+}
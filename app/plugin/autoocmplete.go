@@ -1,8 +1,7 @@
 package plugin
 
 import (
-	"fmt"
-	"regexp"
+	"path/filepath"
 	"slices"
 	"strings"
 
@@ -16,6 +15,7 @@ var _ shell.ShellPlugin = (*Autocomplete)(nil)
 type Autocomplete struct {
 	tr       *terminal.Terminal
 	registry *cmd.Registry
+	shell    *shell.Shell
 
 	bellRung bool
 }
@@ -31,24 +31,51 @@ func (a *Autocomplete) Name() string {
 func (a *Autocomplete) Register(s *shell.Shell) {
 	a.registry = s.CommandRegistry
 	a.tr = s.Terminal()
+	a.shell = s
 	s.KeyHandlers().Use(terminal.ItemKeyTab, a.handleItemKeyTab)
 }
 
 func (a *Autocomplete) handleItemKeyTab(next shell.KeyHandler) shell.KeyHandler {
 	return func(i terminal.Item) error {
-		if line, ok := a.complete(a.tr.Line()); ok {
-			a.tr.ReplaceWith(line)
+		// only the text before the cursor is completed
+		line := []rune(a.tr.Line())
+		before, after := string(line[:a.tr.Cursor()]), string(line[a.tr.Cursor():])
+		if completed, ok := a.complete(before); ok {
+			a.tr.ReplaceWith(completed + after)
+			a.tr.SetCursor(len([]rune(completed)))
 		}
 		return next(i)
 	}
 }
 
-func (a *Autocomplete) complete(input string) (string, bool) {
-	escaped := regexp.QuoteMeta(input)
-	reg, _ := regexp.Compile(fmt.Sprintf("^(%s)+.*", escaped))
-	matches := a.registry.MatchAll(reg)
+func (a *Autocomplete) complete(line string) (string, bool) {
+	head, word := "", line
+	if i := strings.LastIndexByte(line, ' '); i >= 0 {
+		head, word = line[:i+1], line[i+1:]
+	}
+
+	var matches []string
+	exact := true
+	if fields := strings.Fields(head); len(fields) > 0 {
+		matches, exact = a.matchArg(fields[0], word)
+	} else {
+		matches, exact = a.match(word)
+	}
+
+	completion, ok := a.completeWord(word, matches, exact)
+	if !ok {
+		return "", false
+	}
+	return head + completion, true
+}
+
+func (a *Autocomplete) completeWord(input string, matches []string, exact bool) (string, bool) {
 	if len(matches) == 1 {
 		a.bellRung = false
+		// directories are left open for their subdirectories
+		if strings.HasSuffix(matches[0], "/") {
+			return matches[0], true
+		}
 		return matches[0] + " ", true
 	}
 
@@ -57,19 +84,101 @@ func (a *Autocomplete) complete(input string) (string, bool) {
 		return "", false
 	}
 
-	prefix := largestCommonPrefix(matches)
-	if prefix != input {
-		return prefix, true
-	} else {
-		if a.ringTheBell() {
-			return "", false
+	if exact {
+		prefix := largestCommonPrefix(slices.Clone(matches))
+		if prefix != input {
+			return prefix, true
 		}
 	}
+	if a.ringTheBell() {
+		return "", false
+	}
 
 	a.printPossibleCompletions(matches)
 	return "", false
 }
 
+// match returns the names completing input in ranked order. Names
+// starting with input are preferred, then names starting with it in any
+// case and last names containing it. exact reports whether the names
+// start with input.
+func (a *Autocomplete) match(input string) ([]string, bool) {
+	modes := []cmd.MatchOptions{
+		{Mode: cmd.MatchPrefix},
+		{Mode: cmd.MatchPrefix, IgnoreCase: true},
+		{Mode: cmd.MatchSubstring, IgnoreCase: true},
+	}
+	for i, opts := range modes {
+		matches := a.registry.Match(input, opts)
+		if len(matches) == 0 {
+			continue
+		}
+		names := make([]string, len(matches))
+		for j, m := range matches {
+			names[j] = m.Name
+		}
+		return names, i == 0
+	}
+	return nil, false
+}
+
+// matchArg completes the arguments of the builtin name from its spec,
+// offering its flags and, for builtins taking commands, command names.
+func (a *Autocomplete) matchArg(name string, input string) ([]string, bool) {
+	c, ok := a.registry.LookupBuiltinCommand(name)
+	if !ok || c.Spec == nil {
+		return nil, true
+	}
+
+	if strings.HasPrefix(input, "-") {
+		flags := make([]string, 0, len(c.Spec.Flags))
+		for _, f := range c.Spec.Flags {
+			if flag := "-" + string(f.Name); strings.HasPrefix(flag, input) {
+				flags = append(flags, flag)
+			}
+		}
+		return flags, true
+	}
+
+	switch c.Spec.Args {
+	case cmd.ArgCommand:
+		return a.match(input)
+	case cmd.ArgDirectory:
+		return a.matchDirectory(input)
+	}
+	return nil, true
+}
+
+// matchDirectory completes the subdirectories of the directory input
+// is in, or else the visited directories matching a bare name.
+func (a *Autocomplete) matchDirectory(input string) ([]string, bool) {
+	if a.shell == nil {
+		return nil, true
+	}
+
+	parent, prefix := filepath.Split(input)
+	dir := a.shell.ExpandTilde(parent)
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(a.shell.WorkingDir, dir)
+	}
+
+	dirs := make([]string, 0)
+	entries, _ := a.shell.FS.ReadDir(dir)
+	for _, entry := range entries {
+		name := entry.Name()
+		hidden := strings.HasPrefix(name, ".") && !strings.HasPrefix(prefix, ".")
+		if entry.IsDir() && !hidden && strings.HasPrefix(name, prefix) {
+			dirs = append(dirs, parent+name+"/")
+		}
+	}
+	// visited directories would not start with the path typed so far
+	if len(dirs) > 0 || len(input) == 0 || len(parent) > 0 {
+		return dirs, true
+	}
+
+	return a.shell.FrecentDirs(input), false
+}
+
 func (a *Autocomplete) ringTheBell() bool {
 	if a.bellRung {
 		return false
@@ -81,8 +190,9 @@ func (a *Autocomplete) ringTheBell() bool {
 }
 
 func (a *Autocomplete) printPossibleCompletions(completions []string) {
+	a.tr.MoveBelow()
 	a.tr.Writer().StagePushForegroundColor(terminal.Cyan).
-		Stagef("\n%s\n", strings.Join(completions, "  ")).
+		Stagef("%s\n", strings.Join(completions, "  ")).
 		StagePopForegroundColor()
 	a.tr.Ready()
 }
@@ -91,15 +201,11 @@ func (a *Autocomplete) printPossibleCompletions(completions []string) {
 // returns the first match it finds. It does not
 // ring the bell or check any possible completions.
 func (a *Autocomplete) MatchFirst(input string) (string, bool) {
-	escaped := regexp.QuoteMeta(input)
-	reg, _ := regexp.Compile(fmt.Sprintf("^(%s)+.*", escaped))
-	matches := a.registry.MatchAll(reg)
-	if len(matches) > 0 {
+	match, ok := a.registry.MatchFirst(input)
+	if ok {
 		a.bellRung = false
-		return matches[0], true
 	}
-
-	return "", false
+	return match.Name, ok
 }
 
 func largestCommonPrefix(s []string) string {
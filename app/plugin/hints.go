@@ -30,7 +30,8 @@ func (a *CompletionHints) Register(s *shell.Shell) {
 
 func (a *CompletionHints) onCharacterRead(_ rune) {
 	line := a.tr.Line()
-	if line == "" {
+	// the hint is drawn after the cursor, so only when it ends the line
+	if line == "" || a.tr.Cursor() != len([]rune(line)) {
 		return
 	}
 
@@ -39,13 +40,5 @@ func (a *CompletionHints) onCharacterRead(_ rune) {
 		return
 	}
 
-	suggestions := match[len(line):]
-
-	tw := a.tr.Writer()
-	_, _ = tw.Stage(terminal.ClearLine).
-		StagePushForegroundColor(terminal.Grey).
-		StageString(suggestions).
-		StageMove(-len(suggestions)).
-		StagePopForegroundColor().
-		Commit()
+	a.tr.ShowHint(match.Name[len(line):])
 }
@@ -27,6 +27,7 @@ func (h *NavHistory) Register(s *shell.Shell) {
 	s.AddHook(shell.HookPreRead, h.onPreRead)
 	s.KeyHandlers().Use(terminal.ItemKeyUp, h.handleItemUp)
 	s.KeyHandlers().Use(terminal.ItemKeyDown, h.handleItemDown)
+	s.KeyHandlers().Use(terminal.ItemSearch, h.handleSearch)
 
 	h.shellHistory = s.HistoryContext
 	h.tr = s.Terminal()
@@ -50,6 +51,15 @@ func (h *NavHistory) handleItemDown(next shell.KeyHandler) shell.KeyHandler {
 	}
 }
 
+func (h *NavHistory) handleSearch(next shell.KeyHandler) shell.KeyHandler {
+	return func(i terminal.Item) error {
+		if search, ok := h.tr.Search(); ok {
+			h.tr.ShowSearchMatch(h.historyCtx.Search(search.Query, search.Backward, search.Next))
+		}
+		return next(i)
+	}
+}
+
 func (h *NavHistory) onPreRead() {
 	h.historyCtx = history.NewHistoryContext(h.shellHistory)
 }
@@ -4,8 +4,12 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"sync"
+	"sync/atomic"
 
+	"github.com/codecrafters-io/shell-starter-go/app/cmd"
 	"github.com/codecrafters-io/shell-starter-go/app/shell"
+	"github.com/codecrafters-io/shell-starter-go/app/shell/interpreter"
 	"github.com/codecrafters-io/shell-starter-go/app/shell/terminal"
 	lua "github.com/yuin/gopher-lua"
 )
@@ -16,6 +20,14 @@ type LuaPlugin struct {
 	name     string
 	filename string
 	lstate   *lua.LState
+	// mu is held while Lua runs, as gopher-lua is not safe for
+	// concurrent use and commands in a pipeline, e.g. missing ones,
+	// run on goroutines of their own. Functions called from Lua let
+	// go of it around what may call back into Lua.
+	mu sync.Mutex
+	// locked records that mu is held for Lua, so those functions know
+	// whether there is a lock to let go of.
+	locked atomic.Bool
 
 	s  *shell.Shell
 	tw *terminal.TermWriter
@@ -43,11 +55,15 @@ func (l *LuaPlugin) Register(s *shell.Shell) {
 	l.lstate.PreloadModule("shell", l.shellLoader)
 	l.lstate.PreloadModule("shellplugin", l.shellPluginLoader)
 
+	l.lock()
 	if err := l.lstate.DoFile(l.filename); err != nil {
 		fmt.Println(err)
 	}
+	l.unlock()
 
 	s.AddHook(shell.HookPreExit, func() {
+		l.lock()
+		defer l.unlock()
 		l.lstate.Close()
 	})
 }
@@ -56,6 +72,10 @@ func (l *LuaPlugin) shellPluginLoader(lstate *lua.LState) int {
 	exports := map[string]lua.LGFunction{
 		"SetPromptStringFunc": l.SetPromptStringFunc,
 		"AddHook":             l.AddHook,
+		"BindKey":             l.BindKey,
+		"AddPasteHandler":     l.AddPasteHandler,
+
+		"AddCommandNotFoundHandler": l.AddCommandNotFoundHandler,
 	}
 
 	mod := lstate.SetFuncs(lstate.NewTable(), exports)
@@ -69,9 +89,92 @@ func (l *LuaPlugin) AddHook(lstate *lua.LState) int {
 	lfunc := lstate.ToFunction(2)
 
 	l.s.AddHook(shell.Hook(lhook), func() {
+		l.lock()
+		defer l.unlock()
+		if _, err := luaCall[*lua.LNilType](lstate, lfunc); err != nil {
+			l.s.Error(err.Error())
+		}
+	})
+
+	return 0
+}
+
+// BindKey runs a Lua function when the key it names, e.g. "ctrl+g" or
+// "alt+p", is pressed.
+func (l *LuaPlugin) BindKey(lstate *lua.LState) int {
+	lkey := lstate.ToString(1)
+	lfunc := lstate.ToFunction(2)
+
+	key, err := terminal.ParseKeyEvent(lkey)
+	if err != nil {
+		lstate.RaiseError("BindKey: %s", err)
+		return 0
+	}
+	l.s.BindKey(key, func(terminal.Item) error {
+		l.lock()
+		defer l.unlock()
 		if _, err := luaCall[*lua.LNilType](lstate, lfunc); err != nil {
 			l.s.Error(err.Error())
 		}
+		return nil
+	})
+	return 0
+}
+
+// AddPasteHandler registers a Lua function called with pasted text. It
+// returns the text to insert, or nil or false to drop the paste.
+func (l *LuaPlugin) AddPasteHandler(lstate *lua.LState) int {
+	lfunc := lstate.ToFunction(1)
+
+	l.s.HandlePaste(func(text string) (string, bool) {
+		l.lock()
+		defer l.unlock()
+		ret, err := luaCall[lua.LValue](lstate, lfunc, lua.LString(text))
+		if err != nil {
+			l.s.Error(err.Error())
+			return text, true
+		}
+		if !lua.LVAsBool(ret) {
+			return "", false
+		}
+		return lua.LVAsString(ret), true
+	})
+	return 0
+}
+
+// AddCommandNotFoundHandler registers a Lua function called with the name
+// and the arguments of a missing command. It returns whether it handled
+// the command and optionally its exit status.
+func (l *LuaPlugin) AddCommandNotFoundHandler(lstate *lua.LState) int {
+	lfunc := lstate.ToFunction(1)
+
+	l.s.HandleCommandNotFound(func(c *cmd.Command, args []string) (bool, error) {
+		l.lock()
+		defer l.unlock()
+		largs := lstate.NewTable()
+		for _, arg := range args[1:] {
+			largs.Append(lua.LString(arg))
+		}
+
+		err := lstate.CallByParam(lua.P{
+			Fn:      lfunc,
+			NRet:    2,
+			Protect: true,
+		}, lua.LString(args[0]), largs)
+		if err != nil {
+			l.s.Error(err.Error())
+			return false, nil
+		}
+		handled, status := lstate.Get(-2), lstate.Get(-1)
+		lstate.Pop(2)
+
+		if !lua.LVAsBool(handled) {
+			return false, nil
+		}
+		if n, ok := status.(lua.LNumber); ok && n != 0 {
+			return true, interpreter.ExitStatus(int(n))
+		}
+		return true, nil
 	})
 
 	return 0
@@ -81,6 +184,8 @@ func (l *LuaPlugin) SetPromptStringFunc(lstate *lua.LState) int {
 	lfunc := lstate.ToFunction(1)
 
 	l.s.Terminal().PromptStringFunc = func() string {
+		l.lock()
+		defer l.unlock()
 		val, err := luaCall[lua.LString](lstate, lfunc)
 		if err != nil {
 			l.s.Error(err.Error())
@@ -96,6 +201,9 @@ func (l *LuaPlugin) shellLoader(lstate *lua.LState) int {
 		"StagePushForegroundColor": l.StagePushForegroundColor,
 		"StagePopForegroundColor":  l.StagePopForegroundColor,
 		"StageString":              l.StageString,
+		"ViMode":                   l.ViMode,
+		"Pick":                     l.Pick,
+		"Insert":                   l.Insert,
 	}
 
 	mod := lstate.SetFuncs(lstate.NewTable(), exports)
@@ -122,6 +230,93 @@ func (l *LuaPlugin) StageString(lstate *lua.LState) int {
 	return 0
 }
 
+// ViMode returns the mode of the vi line editor for prompts to show, or
+// an empty string in emacs mode.
+func (l *LuaPlugin) ViMode(lstate *lua.LState) int {
+	mode, ok := l.s.Terminal().ViMode()
+	if !ok {
+		lstate.Push(lua.LString(""))
+		return 1
+	}
+	lstate.Push(lua.LString(mode.String()))
+	return 1
+}
+
+// Pick lets the user choose among a list of candidates. The optional
+// second argument is a table with the fields prompt, query, multi and
+// preview, a function returning the preview of a candidate. It returns
+// the list of chosen candidates.
+func (l *LuaPlugin) Pick(lstate *lua.LState) int {
+	lcandidates := lstate.CheckTable(1)
+	lopts := lstate.OptTable(2, lstate.NewTable())
+
+	candidates := make([]string, 0, lcandidates.Len())
+	lcandidates.ForEach(func(_, v lua.LValue) {
+		candidates = append(candidates, lua.LVAsString(v))
+	})
+	opts := terminal.PickOptions{
+		Prompt: lua.LVAsString(lopts.RawGetString("prompt")),
+		Query:  lua.LVAsString(lopts.RawGetString("query")),
+		Multi:  lua.LVAsBool(lopts.RawGetString("multi")),
+	}
+	if lpreview, ok := lopts.RawGetString("preview").(*lua.LFunction); ok {
+		opts.Preview = func(c string) string {
+			l.lock()
+			defer l.unlock()
+			val, err := luaCall[lua.LString](lstate, lpreview, lua.LString(c))
+			if err != nil {
+				return err.Error()
+			}
+			return val.String()
+		}
+	}
+
+	// the preview and the prompt are drawn while picking
+	var picked []string
+	l.unlocked(func() {
+		picked, _ = l.s.Terminal().Pick(candidates, opts)
+	})
+	lpicked := lstate.NewTable()
+	for _, p := range picked {
+		lpicked.Append(lua.LString(p))
+	}
+	lstate.Push(lpicked)
+	return 1
+}
+
+// Insert adds text to the line at the cursor.
+func (l *LuaPlugin) Insert(lstate *lua.LState) int {
+	text := lstate.ToString(1)
+	// the prompt is drawn again with the line
+	l.unlocked(func() {
+		l.s.Terminal().Insert(text)
+	})
+	return 0
+}
+
+func (l *LuaPlugin) lock() {
+	l.mu.Lock()
+	l.locked.Store(true)
+}
+
+func (l *LuaPlugin) unlock() {
+	l.locked.Store(false)
+	l.mu.Unlock()
+}
+
+// unlocked runs f with mu released if Lua holds it. The Lua state only
+// runs on the goroutine that locked it, so a function called from Lua
+// finds mu locked by its own caller.
+func (l *LuaPlugin) unlocked(f func()) {
+	if !l.locked.Load() {
+		f()
+		return
+	}
+	l.unlock()
+	defer l.lock()
+	f()
+}
+
 func luaCall[R lua.LValue](lstate *lua.LState, lfunc *lua.LFunction, args ...lua.LValue) (R, error) {
 	numRet := 0
 	var aux R
@@ -129,6 +324,9 @@ func luaCall[R lua.LValue](lstate *lua.LState, lfunc *lua.LFunction, args ...lua
 	case lua.LString, lua.LNumber, lua.LBool, lua.LFunction,
 		lua.LChannel, lua.LTable, lua.LState, lua.LUserData:
 		numRet = 1
+	case nil:
+		// R is lua.LValue itself, which accepts any return value
+		numRet = 1
 	case *lua.LNilType:
 	default:
 		return aux, errors.New("invalid lua type for return")
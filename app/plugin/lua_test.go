@@ -0,0 +1,80 @@
+package plugin
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/codecrafters-io/shell-starter-go/app/shell"
+	"github.com/codecrafters-io/shell-starter-go/app/shell/history"
+	"github.com/codecrafters-io/shell-starter-go/app/shell/shelltest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	lua "github.com/yuin/gopher-lua"
+)
+
+func TestLuaCall(t *testing.T) {
+	lstate := lua.NewState()
+	defer lstate.Close()
+	require.NoError(t, lstate.DoString(`
+		function upper(s) return string.upper(s) end
+		function drop(s) return false end
+		function none(s) end
+	`))
+	fn := func(name string) *lua.LFunction {
+		return lstate.GetGlobal(name).(*lua.LFunction)
+	}
+
+	s, err := luaCall[lua.LString](lstate, fn("upper"), lua.LString("paste"))
+	assert.NoError(t, err)
+	assert.Equal(t, lua.LString("PASTE"), s)
+
+	v, err := luaCall[lua.LValue](lstate, fn("drop"), lua.LString("paste"))
+	assert.NoError(t, err)
+	assert.Equal(t, lua.LFalse, v)
+
+	v, err = luaCall[lua.LValue](lstate, fn("none"), lua.LString("paste"))
+	assert.NoError(t, err)
+	assert.Equal(t, lua.LNil, v)
+
+	_, err = luaCall[lua.LString](lstate, fn("drop"), lua.LString("paste"))
+	assert.Error(t, err)
+
+	// results are popped off the stack
+	assert.Equal(t, 0, lstate.GetTop())
+}
+
+func TestLuaInsertLocking(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "insert.lua")
+	require.NoError(t, os.WriteFile(filename, []byte(`
+		local shell = require("shell")
+		local plugin = require("shellplugin")
+		shell.Insert("loaded ")
+		plugin.AddHook("Initialized", function() shell.Insert("hook ") end)
+	`), 0o644))
+
+	l := NewLuaPlugin("insert", filename)
+	s := &shell.Shell{
+		Stdout:         io.Discard,
+		Stderr:         io.Discard,
+		Stdin:          strings.NewReader(""),
+		Env:            shelltest.MapEnv{"HOME": t.TempDir()},
+		FS:             shelltest.OSFS{},
+		FullPathFunc:   filepath.Abs,
+		WorkingDir:     t.TempDir(),
+		HistoryContext: history.NewHistoryContext(history.NewInMemoryHistory()),
+	}
+	s.WithPlugins(l)
+	require.NoError(t, s.Run())
+	assert.Equal(t, "loaded hook ", s.Terminal().Line())
+
+	// called without the lock, there is nothing to let go of
+	lstate := lua.NewState()
+	defer lstate.Close()
+	lstate.Push(lua.LString("direct"))
+	l.Insert(lstate)
+	assert.Equal(t, "loaded hook direct", s.Terminal().Line())
+	assert.True(t, l.mu.TryLock())
+}
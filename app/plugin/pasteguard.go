@@ -0,0 +1,59 @@
+package plugin
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/codecrafters-io/shell-starter-go/app/shell"
+	"github.com/codecrafters-io/shell-starter-go/app/shell/terminal"
+)
+
+// pasteConfirmLines is the number of lines above which a paste has to
+// be confirmed
+const pasteConfirmLines = 10
+
+var _ shell.ShellPlugin = (*PasteGuard)(nil)
+
+// PasteGuard removes control characters from pasted text, which could
+// otherwise act on the terminal, and asks before inserting long pastes.
+type PasteGuard struct {
+	tr *terminal.Terminal
+}
+
+func NewPasteGuard() *PasteGuard {
+	return &PasteGuard{}
+}
+
+func (*PasteGuard) Name() string {
+	return "Paste Guard"
+}
+
+func (p *PasteGuard) Register(s *shell.Shell) {
+	p.tr = s.Terminal()
+	s.HandlePaste(p.handlePaste)
+}
+
+func (p *PasteGuard) handlePaste(text string) (string, bool) {
+	text = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) && r != '\n' && r != '\t' {
+			return -1
+		}
+		return r
+	}, text)
+
+	lines := strings.Count(strings.TrimSuffix(text, "\n"), "\n") + 1
+	if lines <= pasteConfirmLines {
+		return text, true
+	}
+
+	_, _ = p.tr.Writer().Stage([]byte("\n")).Commit()
+	answer, err := p.tr.ReadLine(terminal.ReadLineOptions{
+		Prompt: fmt.Sprintf("paste %d lines? [y/N] ", lines),
+		NChars: 1,
+	})
+	if err != nil {
+		return "", false
+	}
+	return text, strings.EqualFold(answer, "y")
+}
@@ -0,0 +1,147 @@
+package plugin
+
+import (
+	"io"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/codecrafters-io/shell-starter-go/app/shell"
+	"github.com/codecrafters-io/shell-starter-go/app/shell/interpreter"
+	"github.com/codecrafters-io/shell-starter-go/app/shell/terminal"
+)
+
+const (
+	// maxPickPaths bounds the files and directories offered by Ctrl-T
+	// and Alt-C
+	maxPickPaths = 10000
+	// previewBytes bounds what is read of a file to preview it
+	previewBytes = 4096
+)
+
+var _ shell.ShellPlugin = (*FuzzyPicker)(nil)
+
+// FuzzyPicker binds Alt-R to pick a command from history, Ctrl-T to
+// pick files to insert and Alt-C to pick a directory to change to.
+// Ctrl-R is left to the incremental history search and vi's redo.
+type FuzzyPicker struct {
+	s  *shell.Shell
+	tr *terminal.Terminal
+}
+
+func NewFuzzyPicker() *FuzzyPicker {
+	return &FuzzyPicker{}
+}
+
+func (*FuzzyPicker) Name() string {
+	return "Fuzzy Picker"
+}
+
+func (p *FuzzyPicker) Register(s *shell.Shell) {
+	p.s = s
+	p.tr = s.Terminal()
+
+	s.BindKey(terminal.KeyEvent{Key: terminal.KeyRune, Mod: terminal.ModAlt, Rune: 'r'}, p.pickHistory)
+	s.BindKey(terminal.KeyEvent{Key: terminal.KeyRune, Mod: terminal.ModCtrl, Rune: 't'}, p.pickFiles)
+	s.BindKey(terminal.KeyEvent{Key: terminal.KeyRune, Mod: terminal.ModAlt, Rune: 'c'}, p.pickDir)
+}
+
+func (p *FuzzyPicker) pickHistory(_ terminal.Item) error {
+	h := p.s.HistoryContext
+	seen := map[string]bool{}
+	entries := make([]string, 0, h.Len())
+	for i := range h.Len() {
+		if entry := h.At(i); !seen[entry] {
+			seen[entry] = true
+			entries = append(entries, entry)
+		}
+	}
+
+	// a pick that fails ends like a cancelled one, the read loop then
+	// sees the same error
+	picked, _ := p.tr.Pick(entries, terminal.PickOptions{Query: p.tr.Line()})
+	if len(picked) > 0 {
+		p.tr.ReplaceWith(picked[0])
+	}
+	return nil
+}
+
+func (p *FuzzyPicker) pickFiles(_ terminal.Item) error {
+	picked, _ := p.tr.Pick(p.walk(false), terminal.PickOptions{
+		Multi:   true,
+		Preview: p.preview,
+	})
+	if len(picked) > 0 {
+		for i, path := range picked {
+			picked[i] = interpreter.Quote(path)
+		}
+		p.tr.Insert(strings.Join(picked, " ") + " ")
+	}
+	return nil
+}
+
+func (p *FuzzyPicker) pickDir(_ terminal.Item) error {
+	picked, _ := p.tr.Pick(p.walk(true), terminal.PickOptions{Preview: p.preview})
+	if len(picked) > 0 {
+		if err := p.s.Chdir(picked[0]); err != nil {
+			p.s.Error("cd: " + picked[0] + ": " + err.Error() + "\n")
+		}
+		// the prompt may show the working directory
+		p.tr.Refresh()
+	}
+	return nil
+}
+
+// walk lists the paths below the working directory, relative to it,
+// leaving out hidden ones.
+func (p *FuzzyPicker) walk(dirsOnly bool) []string {
+	root := p.s.WorkingDir
+	paths := make([]string, 0)
+	_ = fs.WalkDir(p.s.FS, root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || path == root {
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if len(paths) >= maxPickPaths {
+			return fs.SkipAll
+		}
+		if d.IsDir() || !dirsOnly {
+			rel, _ := filepath.Rel(root, path)
+			paths = append(paths, rel)
+		}
+		return nil
+	})
+	return paths
+}
+
+// preview shows the start of a file or the entries of a directory.
+func (p *FuzzyPicker) preview(path string) string {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(p.s.WorkingDir, path)
+	}
+
+	if entries, err := p.s.FS.ReadDir(path); err == nil {
+		names := make([]string, 0, len(entries))
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		return strings.Join(names, "\n")
+	}
+
+	f, err := p.s.FS.Open(path)
+	if err != nil {
+		return err.Error()
+	}
+	defer f.Close()
+	b := make([]byte, previewBytes)
+	n, _ := io.ReadFull(f, b)
+	if strings.ContainsRune(string(b[:n]), 0) {
+		return "(binary file)"
+	}
+	return string(b[:n])
+}
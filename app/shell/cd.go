@@ -3,55 +3,195 @@ package shell
 import (
 	"errors"
 	"fmt"
-	"os"
+	"io/fs"
+	"path/filepath"
 	"runtime"
 	"strings"
 
 	"github.com/codecrafters-io/shell-starter-go/app/cmd"
+	"github.com/codecrafters-io/shell-starter-go/app/shell/interpreter"
+)
+
+// Names of the variables kept up to date by cd
+const (
+	varPWD    = "PWD"
+	varOldPWD = "OLDPWD"
 )
 
 func NewCDCommandFunc(s *Shell) cmd.CommandFunc {
 	return func() *cmd.Command {
 		return &cmd.Command{
-			Name: "cmd",
+			Name: "cd",
+			Spec: &cmd.Spec{
+				Synopsis: "cd [dir]",
+				Short:    "Change the shell working directory.",
+				Long: "Change the current directory to DIR, $HOME by default. A DIR of - changes\n" +
+					"back to $OLDPWD. Relative DIRs are searched for in the colon separated\n" +
+					"directories of $CDPATH.",
+				Args: cmd.ArgDirectory,
+			},
 			Run: func(cmd *cmd.Command, args []string) error {
-				if len(args) < 2 {
-					return nil
+				if len(args) > 2 {
+					_, _ = fmt.Fprintln(cmd.Stderr, "cd: too many arguments")
+					return interpreter.ExitStatus(1)
 				}
 
-				target := args[1]
-				if strings.Contains(target, "~") {
-					home := s.Env.Get("HOME")
-					if runtime.GOOS == "windows" {
-						home = s.Env.Get("USERPROFILE")
+				target := ""
+				if len(args) == 2 {
+					target = args[1]
+				}
+
+				printDir := false
+				switch target {
+				case "":
+					home, ok := s.homeDir()
+					if !ok {
+						_, _ = fmt.Fprintln(cmd.Stderr, "cd: HOME not set")
+						return interpreter.ExitStatus(1)
 					}
-					target = strings.Replace(target, "~", home, 1)
-				} else {
-					if !os.IsPathSeparator(target[0]) {
-						target = s.WorkingDir + string(os.PathSeparator) + target
+					target = home
+				case "-":
+					oldPWD, ok := s.interp.LookupVar(varOldPWD)
+					if !ok {
+						_, _ = fmt.Fprintln(cmd.Stderr, "cd: OLDPWD not set")
+						return interpreter.ExitStatus(1)
 					}
+					target, printDir = oldPWD, true
 				}
 
-				target, err := s.FullPathFunc(target)
-				if err != nil {
-					return fmt.Errorf("failed to get full path of %q: %w\n", target, err)
+				dir, inCDPath := s.lookupCDPath(target)
+				if err := s.chdir(dir); err != nil {
+					_, _ = fmt.Fprintf(cmd.Stderr, "cd: %s: %s\n", target, err)
+					return interpreter.ExitStatus(1)
 				}
 
-				f, err := s.FS.OpenFile(target, os.O_RDONLY)
-				if err != nil {
-					if errors.Is(err, os.ErrNotExist) {
-						_, err := fmt.Fprintf(cmd.Stdout, "cd: %s: No such file or directory\n", args[1])
-						return err
-					}
-
-					return fmt.Errorf("failed to check target location: %s\n", err)
+				if printDir || inCDPath {
+					_, _ = fmt.Fprintln(cmd.Stdout, s.WorkingDir)
 				}
-				_ = f.Close()
-
-				s.WorkingDir = target
-
 				return nil
 			},
 		}
 	}
 }
+
+// Chdir changes the working directory like cd does.
+func (s *Shell) Chdir(dir string) error {
+	return s.chdir(dir)
+}
+
+// chdir changes the working directory to dir, which may be relative to
+// the current one, and updates PWD and OLDPWD.
+func (s *Shell) chdir(dir string) error {
+	target, err := s.absPath(dir)
+	if err != nil {
+		return err
+	}
+
+	fi, err := fs.Stat(s.FS, target)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return errors.New("No such file or directory")
+	case err != nil:
+		return err
+	case !fi.IsDir():
+		return errors.New("Not a directory")
+	}
+
+	s.interp.SetVar(varOldPWD, s.WorkingDir)
+	s.WorkingDir = target
+	s.interp.SetVar(varPWD, target)
+	s.recordDir(target)
+	s.runHooks(HookPostChdir)
+	return nil
+}
+
+func (s *Shell) absPath(dir string) (string, error) {
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(s.WorkingDir, dir)
+	}
+	return s.FullPathFunc(dir)
+}
+
+func (s *Shell) isDir(dir string) bool {
+	target, err := s.absPath(dir)
+	if err != nil {
+		return false
+	}
+	fi, err := fs.Stat(s.FS, target)
+	return err == nil && fi.IsDir()
+}
+
+// lookupCDPath finds dir in the directories listed in CDPATH. It reports
+// whether dir was found through a CDPATH entry, in which case cd prints
+// the new working directory.
+func (s *Shell) lookupCDPath(dir string) (string, bool) {
+	if filepath.IsAbs(dir) || dir == "." || dir == ".." ||
+		strings.HasPrefix(dir, "./") || strings.HasPrefix(dir, "../") {
+		return dir, false
+	}
+
+	cdPath := s.interp.Var("CDPATH")
+	if len(cdPath) == 0 {
+		return dir, false
+	}
+
+	for _, base := range filepath.SplitList(cdPath) {
+		// an empty entry stands for the current directory
+		if len(base) == 0 {
+			if s.isDir(dir) {
+				return dir, false
+			}
+			continue
+		}
+		if candidate := filepath.Join(base, dir); s.isDir(candidate) {
+			return candidate, true
+		}
+	}
+	return dir, false
+}
+
+func (s *Shell) homeDir() (string, bool) {
+	if runtime.GOOS == "windows" {
+		if home := s.Env.Get("USERPROFILE"); len(home) > 0 {
+			return home, true
+		}
+	}
+	home, ok := s.interp.LookupVar("HOME")
+	return home, ok && len(home) > 0
+}
+
+// ExpandTilde expands the tilde prefix of path, e.g. ~/src or ~-, the way
+// it is expanded in unquoted words. Other paths are returned unchanged.
+func (s *Shell) ExpandTilde(path string) string {
+	rest, ok := strings.CutPrefix(path, "~")
+	if !ok {
+		return path
+	}
+	prefix, rest, _ := strings.Cut(rest, "/")
+	dir, ok := s.expandTilde(prefix)
+	if !ok {
+		return path
+	}
+	return filepath.Join(dir, rest)
+}
+
+// expandTilde expands ~ to the home directory, ~+ and ~- to PWD and
+// OLDPWD and ~N, ~+N and ~-N to entries of the directory stack.
+func (s *Shell) expandTilde(prefix string) (string, bool) {
+	switch prefix {
+	case "":
+		return s.homeDir()
+	case "+":
+		return s.WorkingDir, true
+	case "-":
+		return s.interp.LookupVar(varOldPWD)
+	}
+
+	if n, fromEnd, ok := parseStackIndex(prefix); ok {
+		return s.dirStackEntry(n, fromEnd)
+	}
+	if n, fromEnd, ok := parseStackIndex("+" + prefix); ok {
+		return s.dirStackEntry(n, fromEnd)
+	}
+	return "", false
+}
@@ -7,7 +7,11 @@ import (
 func NewClearCommandFunc() cmd.CommandFunc {
 	return func() *cmd.Command {
 		return &cmd.Command{
-			Name: "type",
+			Name: "clear",
+			Spec: &cmd.Spec{
+				Synopsis: "clear",
+				Short:    "Clear the terminal screen.",
+			},
 			Run: func(cmd *cmd.Command, args []string) error {
 				_, _ = cmd.Stdout.Write([]byte{0x1B, '[', '2', 'J'})
 				return nil
@@ -0,0 +1,118 @@
+package shell
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/codecrafters-io/shell-starter-go/app/cmd"
+	"github.com/codecrafters-io/shell-starter-go/app/shell/interpreter"
+	"github.com/codecrafters-io/shell-starter-go/assert"
+)
+
+// NewCommandCommandFunc returns the `command` builtin, which runs or
+// describes a builtin or executable while skipping aliases and functions.
+func NewCommandCommandFunc(r *cmd.Registry) cmd.CommandFunc {
+	assert.NotNil(r, "registry")
+	return func() *cmd.Command {
+		return &cmd.Command{
+			Name: "command",
+			Spec: &cmd.Spec{
+				Synopsis: "command [-vV] command [arg ...]",
+				Short:    "Execute a simple command or display information about commands.",
+				Long:     "Runs COMMAND with ARGS, looking up builtins and executables in PATH only.",
+				Flags: []cmd.Flag{
+					{Name: 'v', Usage: "print the path or name of COMMAND"},
+					{Name: 'V', Usage: "print a description of COMMAND like type"},
+				},
+				Args: cmd.ArgCommand,
+			},
+			Run: func(cmd *cmd.Command, args []string) error {
+				assert.Assert(len(args) > 0)
+
+				flags, rest, err := cmd.Spec.Parse(args[1:])
+				if err != nil {
+					return fmt.Errorf("command: %w", err)
+				}
+				if len(rest) == 0 {
+					return nil
+				}
+
+				_, short := flags['v']
+				_, verbose := flags['V']
+				if !short && !verbose {
+					c, found := r.LookupCommand(rest[0])
+					if !found {
+						return fmt.Errorf("%s: %w", rest[0], interpreter.ErrCommandNotFound)
+					}
+					return runSubcommand(cmd, c, rest)
+				}
+
+				var status error
+				for _, name := range rest {
+					candidates := r.LookupAll(name)
+					if len(candidates) == 0 {
+						if verbose {
+							_, _ = fmt.Fprintf(cmd.Stderr, "command: %s: not found\n", name)
+						}
+						status = interpreter.ExitStatus(1)
+						continue
+					}
+
+					if verbose {
+						describeCandidate(cmd.Stdout, candidates[0])
+					} else {
+						printCommandName(cmd.Stdout, candidates[0])
+					}
+				}
+				return status
+			},
+		}
+	}
+}
+
+// printCommandName prints c the way `command -v` does, which is the path
+// for executables and the plain name for everything else.
+func printCommandName(w io.Writer, c cmd.Candidate) {
+	if c.Kind == cmd.KindFile {
+		_, _ = fmt.Fprintln(w, c.Path)
+		return
+	}
+	_, _ = fmt.Fprintln(w, c.Name)
+}
+
+func NewBuiltinCommandFunc(r *cmd.Registry) cmd.CommandFunc {
+	assert.NotNil(r, "registry")
+	return func() *cmd.Command {
+		return &cmd.Command{
+			Name: "builtin",
+			Spec: &cmd.Spec{
+				Synopsis: "builtin [shell-builtin [arg ...]]",
+				Short:    "Execute shell builtins.",
+				Long:     "Runs SHELL-BUILTIN with ARGS without looking it up in PATH.",
+				Args:     cmd.ArgCommand,
+			},
+			Run: func(cmd *cmd.Command, args []string) error {
+				assert.Assert(len(args) > 0)
+
+				if len(args) < 2 {
+					return nil
+				}
+
+				c, found := r.LookupBuiltinCommand(args[1])
+				if !found {
+					return fmt.Errorf("builtin: %s: not a shell builtin", args[1])
+				}
+				return runSubcommand(cmd, c, args[1:])
+			},
+		}
+	}
+}
+
+// runSubcommand runs c on behalf of parent, sharing its streams.
+func runSubcommand(parent *cmd.Command, c *cmd.Command, args []string) error {
+	c.Stdin = parent.Stdin
+	c.Stdout = parent.Stdout
+	c.Stderr = parent.Stderr
+	c.OnExit = parent.OnExit
+	return c.Run(c, args)
+}
@@ -0,0 +1,309 @@
+package shell
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-io/shell-starter-go/app/cmd"
+	"github.com/codecrafters-io/shell-starter-go/app/shell/interpreter"
+	"github.com/codecrafters-io/shell-starter-go/assert"
+)
+
+// dirsOptions control how the directory stack is printed
+type dirsOptions struct {
+	long    bool
+	perLine bool
+	verbose bool
+}
+
+func NewPushdCommandFunc(s *Shell) cmd.CommandFunc {
+	assert.NotNil(s, "shell")
+	return func() *cmd.Command {
+		return &cmd.Command{
+			Name: "pushd",
+			Spec: &cmd.Spec{
+				Synopsis: "pushd [-n] [+N | -N | dir]",
+				Short:    "Add directories to the directory stack.",
+				Long: "Changes to DIR and pushes the previous directory on the stack. +N and -N\n" +
+					"rotate the stack so that the Nth entry from the left or right is on top.\n" +
+					"Without arguments the top two directories are exchanged.",
+				Flags: []cmd.Flag{
+					{Name: 'n', Usage: "add DIR to the stack without changing to it"},
+				},
+				Args: cmd.ArgDirectory,
+			},
+			Run: func(cmd *cmd.Command, args []string) error {
+				assert.Assert(len(args) > 0)
+
+				index, rest := splitStackIndex(args[1:])
+				flags, rest, err := cmd.Spec.Parse(rest)
+				if err != nil {
+					return fmt.Errorf("pushd: %w", err)
+				}
+				_, noChdir := flags['n']
+
+				entries := s.dirStackEntries()
+				switch {
+				case len(rest) > 1 || len(rest) > 0 && len(index) > 0:
+					_, _ = fmt.Fprintln(cmd.Stderr, "pushd: too many arguments")
+					return interpreter.ExitStatus(1)
+				case len(index) > 0:
+					n, fromEnd, _ := parseStackIndex(index)
+					i, ok := stackPosition(len(entries), n, fromEnd)
+					if !ok {
+						_, _ = fmt.Fprintf(cmd.Stderr, "pushd: %s: directory stack index out of range\n", index)
+						return interpreter.ExitStatus(1)
+					}
+					entries = slices.Concat(entries[i:], entries[:i])
+				case len(rest) == 1:
+					dir, _ := s.lookupCDPath(rest[0])
+					if abs, err := s.absPath(dir); err == nil {
+						dir = abs
+					}
+					if noChdir {
+						s.dirStack = slices.Insert(s.dirStack, 0, dir)
+						return s.printDirStack(cmd.Stdout, dirsOptions{})
+					}
+					entries = append([]string{dir}, entries...)
+				default:
+					if len(entries) < 2 {
+						_, _ = fmt.Fprintln(cmd.Stderr, "pushd: no other directory")
+						return interpreter.ExitStatus(1)
+					}
+					entries[0], entries[1] = entries[1], entries[0]
+				}
+
+				if err := s.chdir(entries[0]); err != nil {
+					_, _ = fmt.Fprintf(cmd.Stderr, "pushd: %s: %s\n", entries[0], err)
+					return interpreter.ExitStatus(1)
+				}
+				s.dirStack = entries[1:]
+				return s.printDirStack(cmd.Stdout, dirsOptions{})
+			},
+		}
+	}
+}
+
+func NewPopdCommandFunc(s *Shell) cmd.CommandFunc {
+	assert.NotNil(s, "shell")
+	return func() *cmd.Command {
+		return &cmd.Command{
+			Name: "popd",
+			Spec: &cmd.Spec{
+				Synopsis: "popd [-n] [+N | -N]",
+				Short:    "Remove directories from the directory stack.",
+				Long: "Removes the top directory from the stack and changes to the new top\n" +
+					"directory. +N and -N remove the Nth entry from the left or right instead.",
+				Flags: []cmd.Flag{
+					{Name: 'n', Usage: "only manipulate the stack without changing directories"},
+				},
+			},
+			Run: func(cmd *cmd.Command, args []string) error {
+				assert.Assert(len(args) > 0)
+
+				index, rest := splitStackIndex(args[1:])
+				flags, rest, err := cmd.Spec.Parse(rest)
+				if err != nil {
+					return fmt.Errorf("popd: %w", err)
+				}
+				if len(rest) > 0 {
+					_, _ = fmt.Fprintf(cmd.Stderr, "popd: %s: invalid argument\n", rest[0])
+					return interpreter.ExitStatus(1)
+				}
+				_, noChdir := flags['n']
+
+				entries := s.dirStackEntries()
+				if len(entries) < 2 {
+					_, _ = fmt.Fprintln(cmd.Stderr, "popd: directory stack empty")
+					return interpreter.ExitStatus(1)
+				}
+
+				i := 0
+				if len(index) > 0 {
+					n, fromEnd, _ := parseStackIndex(index)
+					pos, ok := stackPosition(len(entries), n, fromEnd)
+					if !ok {
+						_, _ = fmt.Fprintf(cmd.Stderr, "popd: %s: directory stack index out of range\n", index)
+						return interpreter.ExitStatus(1)
+					}
+					i = pos
+				}
+				if i == 0 && noChdir {
+					// keep the current directory and drop the one below it
+					i = 1
+				}
+
+				entries = slices.Delete(entries, i, i+1)
+				if i == 0 {
+					if err := s.chdir(entries[0]); err != nil {
+						_, _ = fmt.Fprintf(cmd.Stderr, "popd: %s: %s\n", entries[0], err)
+						return interpreter.ExitStatus(1)
+					}
+				}
+				s.dirStack = entries[1:]
+				return s.printDirStack(cmd.Stdout, dirsOptions{})
+			},
+		}
+	}
+}
+
+func NewDirsCommandFunc(s *Shell) cmd.CommandFunc {
+	assert.NotNil(s, "shell")
+	return func() *cmd.Command {
+		return &cmd.Command{
+			Name: "dirs",
+			Spec: &cmd.Spec{
+				Synopsis: "dirs [-clpv] [+N] [-N]",
+				Short:    "Display directory stack.",
+				Long: "Lists the remembered directories, the current one first. +N and -N show\n" +
+					"the Nth entry from the left or right.",
+				Flags: []cmd.Flag{
+					{Name: 'c', Usage: "clear the directory stack"},
+					{Name: 'l', Usage: "do not abbreviate the home directory with ~"},
+					{Name: 'p', Usage: "print one entry per line"},
+					{Name: 'v', Usage: "print one entry per line prefixed with its position"},
+				},
+			},
+			Run: func(cmd *cmd.Command, args []string) error {
+				assert.Assert(len(args) > 0)
+
+				index, rest := splitStackIndex(args[1:])
+				flags, rest, err := cmd.Spec.Parse(rest)
+				if err != nil {
+					return fmt.Errorf("dirs: %w", err)
+				}
+				if len(rest) > 0 {
+					_, _ = fmt.Fprintf(cmd.Stderr, "dirs: %s: invalid argument\n", rest[0])
+					return interpreter.ExitStatus(1)
+				}
+
+				if _, ok := flags['c']; ok {
+					s.dirStack = nil
+					return nil
+				}
+
+				_, long := flags['l']
+				_, perLine := flags['p']
+				_, verbose := flags['v']
+				opts := dirsOptions{long: long, perLine: perLine, verbose: verbose}
+
+				if len(index) > 0 {
+					n, fromEnd, _ := parseStackIndex(index)
+					dir, ok := s.dirStackEntry(n, fromEnd)
+					if !ok {
+						_, _ = fmt.Fprintf(cmd.Stderr, "dirs: %s: directory stack index out of range\n", index)
+						return interpreter.ExitStatus(1)
+					}
+					if !long {
+						dir = s.abbreviateHome(dir)
+					}
+					_, err := fmt.Fprintln(cmd.Stdout, dir)
+					return err
+				}
+				return s.printDirStack(cmd.Stdout, opts)
+			},
+		}
+	}
+}
+
+// dirStackEntries returns the directory stack with the working directory
+// on top, as listed by `dirs`.
+func (s *Shell) dirStackEntries() []string {
+	return append([]string{s.WorkingDir}, s.dirStack...)
+}
+
+// dirStackEntry returns the nth entry of the stack counting from the top,
+// or from the bottom if fromEnd is set.
+func (s *Shell) dirStackEntry(n int, fromEnd bool) (string, bool) {
+	entries := s.dirStackEntries()
+	i, ok := stackPosition(len(entries), n, fromEnd)
+	if !ok {
+		return "", false
+	}
+	return entries[i], true
+}
+
+func (s *Shell) printDirStack(w io.Writer, opts dirsOptions) error {
+	entries := s.dirStackEntries()
+	if !opts.long {
+		for i, dir := range entries {
+			entries[i] = s.abbreviateHome(dir)
+		}
+	}
+
+	b := strings.Builder{}
+	switch {
+	case opts.verbose:
+		for i, dir := range entries {
+			fmt.Fprintf(&b, "%2d  %s\n", i, dir)
+		}
+	case opts.perLine:
+		for _, dir := range entries {
+			b.WriteString(dir + "\n")
+		}
+	default:
+		b.WriteString(strings.Join(entries, " ") + "\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// abbreviateHome replaces the home directory at the start of dir with ~.
+func (s *Shell) abbreviateHome(dir string) string {
+	home, ok := s.homeDir()
+	if !ok {
+		return dir
+	}
+	if dir == home {
+		return "~"
+	}
+	if rest, ok := strings.CutPrefix(dir, strings.TrimSuffix(home, "/")+"/"); ok {
+		return "~/" + rest
+	}
+	return dir
+}
+
+// splitStackIndex takes the +N or -N argument out of args, so that -N is
+// not taken for a flag.
+func splitStackIndex(args []string) (string, []string) {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if _, _, ok := parseStackIndex(arg); ok {
+			return arg, slices.Delete(slices.Clone(args), i, i+1)
+		}
+	}
+	return "", args
+}
+
+// parseStackIndex parses +N, counting from the top of the stack, and -N,
+// counting from the bottom.
+func parseStackIndex(arg string) (int, bool, bool) {
+	if len(arg) < 2 || (arg[0] != '+' && arg[0] != '-') {
+		return 0, false, false
+	}
+	for _, c := range arg[1:] {
+		if c < '0' || c > '9' {
+			return 0, false, false
+		}
+	}
+	n, err := strconv.Atoi(arg[1:])
+	if err != nil {
+		return 0, false, false
+	}
+	return n, arg[0] == '-', true
+}
+
+func stackPosition(size, n int, fromEnd bool) (int, bool) {
+	if n >= size {
+		return 0, false
+	}
+	if fromEnd {
+		return size - 1 - n, true
+	}
+	return n, true
+}
@@ -0,0 +1,204 @@
+package shell
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/codecrafters-io/shell-starter-go/app/shell/shelltest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runDirCommands runs each line in a shell started in a temporary HOME
+// holding the directories a, b, c and lib/proj, and returns what the
+// lines wrote to stdout and stderr with HOME written as $HOME.
+func runDirCommands(t *testing.T, lines []string, env shelltest.MapEnv) (string, string) {
+	t.Helper()
+	home := t.TempDir()
+	for _, dir := range []string{"a", "b", "c", "lib/proj"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(home, dir), 0o755))
+	}
+
+	if env == nil {
+		env = shelltest.MapEnv{}
+	}
+	for k, v := range env {
+		env[k] = strings.ReplaceAll(v, "$HOME", home)
+	}
+	env["HOME"] = home
+
+	stdout, stderr := filepath.Join(home, "stdout"), filepath.Join(home, "stderr")
+	input := strings.Builder{}
+	for _, line := range lines {
+		input.WriteString(line + " >> " + stdout + " 2>> " + stderr + "\n")
+	}
+	runShell(t, input.String(), env)
+
+	read := func(name string) string {
+		b, err := os.ReadFile(name)
+		if os.IsNotExist(err) {
+			return ""
+		}
+		require.NoError(t, err)
+		return strings.ReplaceAll(string(b), home, "$HOME")
+	}
+	return read(stdout), read(stderr)
+}
+
+func TestDirCommands(t *testing.T) {
+	tt := []struct {
+		name   string
+		lines  []string
+		env    shelltest.MapEnv
+		stdout string
+		stderr string
+	}{
+		{
+			name:   "cd in CDPATH",
+			lines:  []string{"cd proj", "pwd"},
+			env:    shelltest.MapEnv{"CDPATH": "/nowhere:$HOME/lib"},
+			stdout: "$HOME/lib/proj\n$HOME/lib/proj\n",
+		},
+		{
+			name:   "cd not in CDPATH",
+			lines:  []string{"cd a", "pwd"},
+			env:    shelltest.MapEnv{"CDPATH": "$HOME/lib"},
+			stdout: "$HOME/a\n",
+		},
+		{
+			name:   "cd -",
+			lines:  []string{"cd a", "cd -", "echo $OLDPWD"},
+			stdout: "$HOME\n$HOME/a\n",
+		},
+		{
+			name:   "cd - without OLDPWD",
+			lines:  []string{"cd -", "pwd"},
+			stdout: "$HOME\n",
+			stderr: "cd: OLDPWD not set\n",
+		},
+		{
+			name:   "cd missing",
+			lines:  []string{"cd missing", "pwd"},
+			stdout: "$HOME\n",
+			stderr: "cd: missing: No such file or directory\n",
+		},
+		{
+			name:   "cd too many arguments",
+			lines:  []string{"cd a b"},
+			stderr: "cd: too many arguments\n",
+		},
+		{
+			name:  "pushd rotates",
+			lines: []string{"pushd a", "pushd ../b", "pushd ../c", "pushd +1", "pushd -0", "pwd"},
+			stdout: "~/a ~\n" +
+				"~/b ~/a ~\n" +
+				"~/c ~/b ~/a ~\n" +
+				"~/b ~/a ~ ~/c\n" +
+				"~/c ~/b ~/a ~\n" +
+				"$HOME/c\n",
+		},
+		{
+			name:   "pushd exchanges",
+			lines:  []string{"pushd a", "pushd", "pwd"},
+			stdout: "~/a ~\n~ ~/a\n$HOME\n",
+		},
+		{
+			name:   "pushd -n",
+			lines:  []string{"pushd -n a", "pwd"},
+			stdout: "~ ~/a\n$HOME\n",
+		},
+		{
+			name:   "pushd out of range",
+			lines:  []string{"pushd -n a", "pushd +2"},
+			stdout: "~ ~/a\n",
+			stderr: "pushd: +2: directory stack index out of range\n",
+		},
+		{
+			name:   "pushd without other directory",
+			lines:  []string{"pushd"},
+			stderr: "pushd: no other directory\n",
+		},
+		{
+			name:   "pushd missing",
+			lines:  []string{"pushd missing", "dirs"},
+			stdout: "~\n",
+			stderr: "pushd: $HOME/missing: No such file or directory\n",
+		},
+		{
+			name:   "popd",
+			lines:  []string{"pushd a", "pushd ../b", "popd", "pwd"},
+			stdout: "~/a ~\n~/b ~/a ~\n~/a ~\n$HOME/a\n",
+		},
+		{
+			name:   "popd +N",
+			lines:  []string{"pushd a", "pushd ../b", "popd +1", "popd -0", "pwd"},
+			stdout: "~/a ~\n~/b ~/a ~\n~/b ~\n~/b\n$HOME/b\n",
+		},
+		{
+			name:   "popd -n",
+			lines:  []string{"pushd a", "pushd ../b", "popd -n", "pwd"},
+			stdout: "~/a ~\n~/b ~/a ~\n~/b ~\n$HOME/b\n",
+		},
+		{
+			name:   "popd empty",
+			lines:  []string{"popd"},
+			stderr: "popd: directory stack empty\n",
+		},
+		{
+			name:   "popd invalid argument",
+			lines:  []string{"pushd -n a", "popd a"},
+			stdout: "~ ~/a\n",
+			stderr: "popd: a: invalid argument\n",
+		},
+		{
+			name:   "dirs -v",
+			lines:  []string{"pushd -n a", "dirs -v"},
+			stdout: "~ ~/a\n 0  ~\n 1  ~/a\n",
+		},
+		{
+			name:   "dirs -p",
+			lines:  []string{"pushd -n a", "dirs -p"},
+			stdout: "~ ~/a\n~\n~/a\n",
+		},
+		{
+			name:   "dirs -l",
+			lines:  []string{"pushd -n a", "dirs -l"},
+			stdout: "~ ~/a\n$HOME $HOME/a\n",
+		},
+		{
+			name:   "dirs -c",
+			lines:  []string{"pushd -n a", "dirs -c", "dirs"},
+			stdout: "~ ~/a\n~\n",
+		},
+		{
+			name:   "dirs +N and -N",
+			lines:  []string{"pushd -n a", "dirs +1", "dirs -1", "dirs +2"},
+			stdout: "~ ~/a\n~/a\n~\n",
+			stderr: "dirs: +2: directory stack index out of range\n",
+		},
+		{
+			name:   "autocd",
+			lines:  []string{"shopt -s autocd", "a", "pwd"},
+			stdout: "$HOME/a\n",
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			stdout, stderr := runDirCommands(t, test.lines, test.env)
+			assert.Equal(t, test.stdout, stdout)
+			assert.Equal(t, test.stderr, stderr)
+		})
+	}
+}
+
+func TestAutoCDOff(t *testing.T) {
+	home := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(home, "a"), 0o755))
+
+	out := runShell(t, "a\npwd\n", shelltest.MapEnv{"HOME": home})
+	assert.Contains(t, out, "a: command not found")
+	assert.Contains(t, out, "\n"+home+"\n")
+}
@@ -1,7 +1,7 @@
 package shell
 
 import (
-	"fmt"
+	"io"
 	"strings"
 
 	"github.com/codecrafters-io/shell-starter-go/app/cmd"
@@ -12,11 +12,58 @@ func NewEchoCommandFunc() cmd.CommandFunc {
 	return func() *cmd.Command {
 		return &cmd.Command{
 			Name: "echo",
+			Spec: &cmd.Spec{
+				Synopsis: "echo [-neE] [arg ...]",
+				Short:    "Write arguments to the standard output.",
+				Flags: []cmd.Flag{
+					{Name: 'n', Usage: "do not append a newline"},
+					{Name: 'e', Usage: "interpret backslash escapes"},
+					{Name: 'E', Usage: "do not interpret backslash escapes"},
+				},
+				RawArgs: true,
+			},
 			Run: func(cmd *cmd.Command, args []string) error {
 				assert.Assert(len(args) > 0)
-				fmt.Fprintf(cmd.Stdout, "%s\n", strings.Join(args[1:], " "))
-				return nil
+
+				newline, escapes := true, false
+				args = args[1:]
+				for len(args) > 0 && isEchoFlags(args[0]) {
+					for _, c := range args[0][1:] {
+						switch c {
+						case 'n':
+							newline = false
+						case 'e':
+							escapes = true
+						case 'E':
+							escapes = false
+						}
+					}
+					args = args[1:]
+				}
+
+				out := strings.Join(args, " ")
+				if escapes {
+					var stop bool
+					if out, stop = expandEscapes(out, true); stop {
+						newline = false
+					}
+				}
+				if newline {
+					out += "\n"
+				}
+
+				_, err := io.WriteString(cmd.Stdout, out)
+				return err
 			},
 		}
 	}
 }
+
+// isEchoFlags reports whether arg only consists of options understood by
+// echo. Anything else, like `-x` or `-`, is printed as an argument.
+func isEchoFlags(arg string) bool {
+	if len(arg) < 2 || arg[0] != '-' {
+		return false
+	}
+	return strings.Trim(arg[1:], "neE") == ""
+}
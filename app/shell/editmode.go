@@ -0,0 +1,38 @@
+package shell
+
+import (
+	"github.com/codecrafters-io/shell-starter-go/app/shell/interpreter"
+	"github.com/codecrafters-io/shell-starter-go/app/shell/terminal"
+)
+
+// Names of the set options choosing the key bindings of the line editor
+const (
+	OptVi    = "vi"
+	OptEmacs = "emacs"
+)
+
+// registerEditModeOptions adds the vi and emacs options. Exactly one of
+// them is on, so turning one off turns the other on.
+func (s *Shell) registerEditModeOptions() {
+	opts := s.interp.Options()
+	opts.Register(&interpreter.Option{
+		Name: OptEmacs,
+		Kind: interpreter.SetOption,
+		On:   true,
+		OnChange: func(on bool) {
+			_ = opts.Set(OptVi, !on)
+		},
+	})
+	opts.Register(&interpreter.Option{
+		Name: OptVi,
+		Kind: interpreter.SetOption,
+		OnChange: func(on bool) {
+			if on {
+				s.tr.SetEditMode(terminal.EditModeVi)
+			} else {
+				s.tr.SetEditMode(terminal.EditModeEmacs)
+			}
+			_ = opts.Set(OptEmacs, !on)
+		},
+	})
+}
@@ -0,0 +1,268 @@
+package shell
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/codecrafters-io/shell-starter-go/app/cmd"
+	"github.com/codecrafters-io/shell-starter-go/app/shell/envrc"
+	"github.com/codecrafters-io/shell-starter-go/app/shell/interpreter"
+	"github.com/codecrafters-io/shell-starter-go/assert"
+)
+
+const envTrustFileName = "shell-go-allowed"
+
+// envFileNames are the files looked for in the working directory and its
+// parents, in order of preference.
+var envFileNames = []string{".envrc", ".shellenv"}
+
+// loadedEnvFile is the env file currently applied. saved holds the values
+// its variables had before, nil for the ones that were not set.
+type loadedEnvFile struct {
+	path  string
+	hash  string
+	saved map[string]*string
+}
+
+func NewAllowCommandFunc(s *Shell) cmd.CommandFunc {
+	assert.NotNil(s, "shell")
+	return func() *cmd.Command {
+		return &cmd.Command{
+			Name: "allow",
+			Spec: &cmd.Spec{
+				Synopsis: "allow [file]",
+				Short:    "Allow an env file to be loaded.",
+				Long: "Approves the current content of FILE, by default the .envrc or .shellenv\n" +
+					"of the working directory or its closest parent. The file is blocked\n" +
+					"again once its content changes.",
+			},
+			Run: func(cmd *cmd.Command, args []string) error {
+				assert.Assert(len(args) > 0)
+
+				path, content, err := s.envFileArg(cmd.Spec, args[1:])
+				if err != nil {
+					_, _ = fmt.Fprintf(cmd.Stderr, "allow: %s\n", err)
+					return interpreter.ExitStatus(1)
+				}
+				s.envTrust.Allow(path, content)
+				s.updateEnvFile()
+				if err := s.saveEnvTrust(); err != nil {
+					_, _ = fmt.Fprintf(cmd.Stderr, "allow: cannot save the decision: %s\n", err)
+					return interpreter.ExitStatus(1)
+				}
+				return nil
+			},
+		}
+	}
+}
+
+func NewDenyCommandFunc(s *Shell) cmd.CommandFunc {
+	assert.NotNil(s, "shell")
+	return func() *cmd.Command {
+		return &cmd.Command{
+			Name: "deny",
+			Spec: &cmd.Spec{
+				Synopsis: "deny [file]",
+				Short:    "Revoke the approval of an env file.",
+				Long: "Blocks FILE, by default the .envrc or .shellenv of the working directory\n" +
+					"or its closest parent, and reverts its variables if it was loaded.",
+			},
+			Run: func(cmd *cmd.Command, args []string) error {
+				assert.Assert(len(args) > 0)
+
+				path, _, err := s.envFileArg(cmd.Spec, args[1:])
+				if err != nil {
+					_, _ = fmt.Fprintf(cmd.Stderr, "deny: %s\n", err)
+					return interpreter.ExitStatus(1)
+				}
+				s.envTrust.Deny(path)
+				s.updateEnvFile()
+				if err := s.saveEnvTrust(); err != nil {
+					_, _ = fmt.Fprintf(cmd.Stderr, "deny: cannot save the decision: %s\n", err)
+					return interpreter.ExitStatus(1)
+				}
+				return nil
+			},
+		}
+	}
+}
+
+// envFileArg returns the env file named by the arguments of allow and
+// deny along with its content.
+func (s *Shell) envFileArg(spec *cmd.Spec, args []string) (string, []byte, error) {
+	_, rest, err := spec.Parse(args)
+	if err != nil {
+		return "", nil, err
+	}
+
+	switch len(rest) {
+	case 0:
+		path, content, ok := s.findEnvFile(s.WorkingDir)
+		if !ok {
+			return "", nil, fmt.Errorf("no %s found", strings.Join(envFileNames, " or "))
+		}
+		return path, content, nil
+	case 1:
+		path, err := s.absPath(rest[0])
+		if err != nil {
+			return "", nil, fmt.Errorf("%s: %w", rest[0], err)
+		}
+		content, err := s.readFile(path)
+		if err != nil {
+			return "", nil, fmt.Errorf("%s: %w", rest[0], err)
+		}
+		return path, content, nil
+	default:
+		return "", nil, fmt.Errorf("too many arguments")
+	}
+}
+
+// updateEnvFile applies the env file of the working directory, reverting
+// the one applied before if it is a different file or was edited. Files
+// that were not allowed are reported once and left alone.
+func (s *Shell) updateEnvFile() {
+	path, content, found := s.findEnvFile(s.WorkingDir)
+	hash := ""
+	if found {
+		hash = envrc.Hash(content)
+	}
+	allowed := found && s.envTrust.Allowed(path, content)
+	if allowed && s.envFile != nil && s.envFile.path == path && s.envFile.hash == hash {
+		return
+	}
+
+	if s.envFile != nil {
+		s.unloadEnvFile()
+	}
+	if !found {
+		return
+	}
+
+	// problems are only reported once per version of a file
+	if !allowed {
+		if reported := "blocked " + hash + " " + path; s.envReported != reported {
+			s.envReported = reported
+			_, _ = fmt.Fprintf(s.Stderr, "envrc: %s is blocked. Run `allow` to approve its content\n",
+				s.abbreviateHome(path))
+		}
+		return
+	}
+	reported := "invalid " + hash + " " + path
+	if s.envReported == reported {
+		return
+	}
+	if err := s.loadEnvFile(path, hash, content); err != nil {
+		s.envReported = reported
+		_, _ = fmt.Fprintf(s.Stderr, "envrc: %s: %s\n", s.abbreviateHome(path), err)
+	}
+}
+
+func (s *Shell) loadEnvFile(path, hash string, content []byte) error {
+	vars, err := envrc.Parse(bytes.NewReader(content), s.interp.Var)
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(s.Stderr, "envrc: loading %s\n", s.abbreviateHome(path))
+
+	loaded := &loadedEnvFile{path: path, hash: hash, saved: map[string]*string{}}
+	for _, v := range vars {
+		if _, ok := loaded.saved[v.Name]; !ok {
+			if old, ok := s.Env.Lookup(v.Name); ok {
+				loaded.saved[v.Name] = &old
+			} else {
+				loaded.saved[v.Name] = nil
+			}
+		}
+
+		if v.Unset {
+			_ = s.Env.Unset(v.Name)
+		} else {
+			_ = s.Env.Set(v.Name, v.Value)
+		}
+	}
+	s.envFile = loaded
+
+	s.printEnvChanges(loaded.saved)
+	return nil
+}
+
+func (s *Shell) unloadEnvFile() {
+	_, _ = fmt.Fprintln(s.Stderr, "envrc: unloading")
+	for name, old := range s.envFile.saved {
+		if old == nil {
+			_ = s.Env.Unset(name)
+		} else {
+			_ = s.Env.Set(name, *old)
+		}
+	}
+	s.envFile = nil
+}
+
+// printEnvChanges lists the variables changed by an env file, prefixed
+// with + when added, ~ when modified and - when removed.
+func (s *Shell) printEnvChanges(saved map[string]*string) {
+	changes := make([]string, 0, len(saved))
+	for name, old := range saved {
+		val, ok := s.Env.Lookup(name)
+		switch {
+		case old == nil && ok:
+			changes = append(changes, "+"+name)
+		case old != nil && !ok:
+			changes = append(changes, "-"+name)
+		case old != nil && *old != val:
+			changes = append(changes, "~"+name)
+		}
+	}
+	if len(changes) == 0 {
+		return
+	}
+	slices.SortFunc(changes, func(a, b string) int {
+		return strings.Compare(a[1:], b[1:])
+	})
+	_, _ = fmt.Fprintf(s.Stderr, "envrc: export %s\n", strings.Join(changes, " "))
+}
+
+// findEnvFile looks for an env file in dir and then in its parents.
+func (s *Shell) findEnvFile(dir string) (string, []byte, bool) {
+	for {
+		for _, name := range envFileNames {
+			path := filepath.Join(dir, name)
+			if content, err := s.readFile(path); err == nil {
+				return path, content, true
+			}
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil, false
+		}
+		dir = parent
+	}
+}
+
+func (s *Shell) readFile(path string) ([]byte, error) {
+	file, err := s.FS.OpenFile(path, os.O_RDONLY)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(file)
+}
+
+func (s *Shell) loadEnvTrust() {
+	s.envTrust = envrc.NewTrust()
+	if filename := s.dataFile(envTrustFileName); len(filename) > 0 {
+		_ = envrc.ReadTrustFromFile(s.envTrust, s.FS, filename)
+	}
+}
+
+func (s *Shell) saveEnvTrust() error {
+	return s.writeDataFile(envTrustFileName, func(filename string) error {
+		return envrc.WriteTrustToFile(s.envTrust, s.FS, filename)
+	})
+}
@@ -0,0 +1,154 @@
+package envrc
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Var is an assignment read from an env file. Unset is set for `unset
+// NAME` lines.
+type Var struct {
+	Name  string
+	Value string
+	Unset bool
+}
+
+// Parse reads the assignments of an env file. Lines are either comments,
+// `[export] NAME=value` or `unset NAME ...`. Values may be single or
+// double quoted and outside of single quotes $NAME and ${NAME} are
+// expanded with lookup, which sees the assignments made before.
+func Parse(r io.Reader, lookup func(string) string) ([]Var, error) {
+	vars := make([]Var, 0)
+	assigned := map[string]string{}
+	expand := func(name string) string {
+		if val, ok := assigned[name]; ok {
+			return val
+		}
+		return lookup(name)
+	}
+
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+
+		if names, ok := strings.CutPrefix(line, "unset "); ok {
+			for _, name := range strings.Fields(names) {
+				if !isValidName(name) {
+					return nil, fmt.Errorf("line %d: `%s': not a valid identifier", lineNo, name)
+				}
+				vars = append(vars, Var{Name: name, Unset: true})
+				delete(assigned, name)
+			}
+			continue
+		}
+
+		line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+		name, value, ok := strings.Cut(line, "=")
+		if !ok || !isValidName(name) {
+			return nil, fmt.Errorf("line %d: expected NAME=value", lineNo)
+		}
+
+		value, err := parseValue(value, expand)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		vars = append(vars, Var{Name: name, Value: value})
+		assigned[name] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading file: %w", err)
+	}
+	return vars, nil
+}
+
+func parseValue(s string, expand func(string) string) (string, error) {
+	b := strings.Builder{}
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\'':
+			end := strings.IndexByte(s[i+1:], '\'')
+			if end < 0 {
+				return "", fmt.Errorf("unterminated single quote")
+			}
+			b.WriteString(s[i+1 : i+1+end])
+			i += end + 1
+		case '"':
+			i++
+			for ; i < len(s) && s[i] != '"'; i++ {
+				switch {
+				case s[i] == '\\' && i+1 < len(s) && strings.IndexByte(`"\$`, s[i+1]) >= 0:
+					i++
+					b.WriteByte(s[i])
+				case s[i] == '$':
+					val, n := expandParam(s[i:], expand)
+					b.WriteString(val)
+					i += n - 1
+				default:
+					b.WriteByte(s[i])
+				}
+			}
+			if i >= len(s) {
+				return "", fmt.Errorf("unterminated double quote")
+			}
+		case '#':
+			// a comment ends the value unless it is part of a word
+			if i > 0 && (s[i-1] == ' ' || s[i-1] == '\t') {
+				return strings.TrimSpace(b.String()), nil
+			}
+			b.WriteByte(c)
+		case '\\':
+			if i+1 < len(s) {
+				i++
+				b.WriteByte(s[i])
+			}
+		case '$':
+			val, n := expandParam(s[i:], expand)
+			b.WriteString(val)
+			i += n - 1
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return strings.TrimSpace(b.String()), nil
+}
+
+// expandParam expands the $NAME or ${NAME} at the start of s and returns
+// the number of bytes it took. A $ not followed by a name is kept.
+func expandParam(s string, expand func(string) string) (string, int) {
+	if strings.HasPrefix(s, "${") {
+		end := strings.IndexByte(s, '}')
+		if end < 0 || !isValidName(s[2:end]) {
+			return "$", 1
+		}
+		return expand(s[2:end]), end + 1
+	}
+
+	n := 1
+	for n < len(s) && isValidName(s[1:n+1]) {
+		n++
+	}
+	if n == 1 {
+		return "$", 1
+	}
+	return expand(s[1:n]), n
+}
+
+func isValidName(name string) bool {
+	if len(name) == 0 {
+		return false
+	}
+	for i, c := range name {
+		switch {
+		case c == '_', c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,48 @@
+package envrc
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	input := `# project settings
+export GOFLAGS=-mod=mod
+NAME='single $HOME'
+GREETING="hello \"$NAME\" \$HOME"
+BIN=${HOME}/bin:$PATH # extends PATH
+EMPTY=
+unset GOPATH OLD
+`
+	env := map[string]string{"HOME": "/home/mino", "PATH": "/bin"}
+	vars, err := Parse(strings.NewReader(input), func(name string) string {
+		return env[name]
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []Var{
+		{Name: "GOFLAGS", Value: "-mod=mod"},
+		{Name: "NAME", Value: "single $HOME"},
+		{Name: "GREETING", Value: `hello "single $HOME" $HOME`},
+		{Name: "BIN", Value: "/home/mino/bin:/bin"},
+		{Name: "EMPTY", Value: ""},
+		{Name: "GOPATH", Unset: true},
+		{Name: "OLD", Unset: true},
+	}, vars)
+}
+
+func TestParseErrors(t *testing.T) {
+	for input, msg := range map[string]string{
+		"echo hi":      "line 1: expected NAME=value",
+		"\n1A=b":       "line 2: expected NAME=value",
+		`A="open`:      "line 1: unterminated double quote",
+		"A='open":      "line 1: unterminated single quote",
+		"unset A-B":    "line 1: `A-B': not a valid identifier",
+		"export =oops": "line 1: expected NAME=value",
+	} {
+		_, err := Parse(strings.NewReader(input), func(string) string { return "" })
+		assert.EqualError(t, err, msg, input)
+	}
+}
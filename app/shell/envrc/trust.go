@@ -0,0 +1,91 @@
+package envrc
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+)
+
+type openFileFS interface {
+	OpenFile(string, int) (io.ReadWriteCloser, error)
+}
+
+// Trust keeps the env files that were allowed along with the hash of
+// their content, so that a file is blocked again once it is edited.
+type Trust struct {
+	allowed map[string]string
+}
+
+func NewTrust() *Trust {
+	return &Trust{allowed: map[string]string{}}
+}
+
+func Hash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+func (t *Trust) Allow(path string, content []byte) {
+	t.allowed[path] = Hash(content)
+}
+
+func (t *Trust) Deny(path string) {
+	delete(t.allowed, path)
+}
+
+// Allowed reports whether path was allowed with this content.
+func (t *Trust) Allowed(path string, content []byte) bool {
+	hash, ok := t.allowed[path]
+	return ok && hash == Hash(content)
+}
+
+// ReadTrustFromFile adds the allowed files listed in filename to t, one
+// per line as the hash followed by the path.
+func ReadTrustFromFile(t *Trust, fsys openFileFS, filename string) error {
+	file, err := fsys.OpenFile(filename, os.O_RDONLY)
+	if err != nil {
+		return fmt.Errorf("open file: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		hash, path, ok := strings.Cut(scanner.Text(), " ")
+		if !ok || len(path) == 0 {
+			continue
+		}
+		t.allowed[path] = hash
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("reading file: %w", err)
+	}
+	return nil
+}
+
+func WriteTrustToFile(t *Trust, fsys openFileFS, filename string) error {
+	file, err := fsys.OpenFile(filename, os.O_WRONLY|os.O_TRUNC|os.O_CREATE)
+	if err != nil {
+		return fmt.Errorf("open file: %w", err)
+	}
+	defer file.Close()
+
+	paths := make([]string, 0, len(t.allowed))
+	for path := range t.allowed {
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+
+	w := bufio.NewWriter(file)
+	for _, path := range paths {
+		_, _ = fmt.Fprintf(w, "%s %s\n", t.allowed[path], path)
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("file write: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package envrc
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/codecrafters-io/shell-starter-go/app/shell/fsys/fsystest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrust(t *testing.T) {
+	trust := NewTrust()
+	assert.False(t, trust.Allowed("/p/.envrc", []byte("A=1")))
+
+	trust.Allow("/p/.envrc", []byte("A=1"))
+	assert.True(t, trust.Allowed("/p/.envrc", []byte("A=1")))
+	assert.False(t, trust.Allowed("/p/.envrc", []byte("A=2")))
+	assert.False(t, trust.Allowed("/q/.envrc", []byte("A=1")))
+
+	buf := bytes.NewBuffer(nil)
+	fsys := fsystest.Buffer(buf)
+	require.NoError(t, WriteTrustToFile(trust, fsys, ""))
+
+	read := NewTrust()
+	require.NoError(t, ReadTrustFromFile(read, fsys, ""))
+	assert.True(t, read.Allowed("/p/.envrc", []byte("A=1")))
+
+	read.Deny("/p/.envrc")
+	assert.False(t, read.Allowed("/p/.envrc", []byte("A=1")))
+}
@@ -0,0 +1,84 @@
+package shell
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/codecrafters-io/shell-starter-go/app/shell/shelltest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvFile(t *testing.T) {
+	home := t.TempDir()
+	envFile := filepath.Join(home, "project", ".envrc")
+	require.NoError(t, os.Mkdir(filepath.Dir(envFile), 0o755))
+	require.NoError(t, os.WriteFile(envFile, []byte("FOO=bar\n"), 0o644))
+
+	steps := []struct {
+		line string
+		out  string
+	}{
+		{"cd project", "envrc: ~/project/.envrc is blocked. Run `allow` to approve its content\n"},
+		{"echo [$FOO]", "[]\n"},
+		{"allow", "envrc: loading ~/project/.envrc\nenvrc: export +FOO\n"},
+		{"echo [$FOO]", "[bar]\n"},
+		{"cd ..", "envrc: unloading\n"},
+		{"echo [$FOO]", "[]\n"},
+		{"cd project", "envrc: loading ~/project/.envrc\nenvrc: export +FOO\n"},
+		{"echo FOO=baz > " + envFile, "envrc: unloading\n" +
+			"envrc: ~/project/.envrc is blocked. Run `allow` to approve its content\n"},
+		{"echo [$FOO]", "[]\n"},
+		{"allow", "envrc: loading ~/project/.envrc\nenvrc: export +FOO\n"},
+		{"echo [$FOO]", "[baz]\n"},
+		{"deny", "envrc: unloading\n"},
+		{"echo [$FOO]", "[]\n"},
+		// a blocked file is only reported once per version
+		{"cd ..", ""},
+		{"cd project", ""},
+		{"echo [$FOO]", "[]\n"},
+	}
+
+	input := strings.Builder{}
+	expected := strings.Builder{}
+	for _, step := range steps {
+		input.WriteString(step.line + "\n")
+		expected.WriteString("$ " + step.line + "\n" + step.out)
+	}
+	expected.WriteString("$ ")
+
+	out := runShell(t, input.String(), shelltest.MapEnv{"HOME": home})
+	assert.Equal(t, expected.String(), out)
+
+	// the decisions are kept for the next session
+	out = runShell(t, "cd project\nallow\ncd ..\ncd project\n", shelltest.MapEnv{"HOME": home})
+	assert.Equal(t, "$ cd project\n"+
+		"envrc: ~/project/.envrc is blocked. Run `allow` to approve its content\n"+
+		"$ allow\n"+
+		"envrc: loading ~/project/.envrc\nenvrc: export +FOO\n"+
+		"$ cd ..\n"+
+		"envrc: unloading\n"+
+		"$ cd project\n"+
+		"envrc: loading ~/project/.envrc\nenvrc: export +FOO\n"+
+		"$ ", out)
+
+	out = runShell(t, "cd project\necho [$FOO]\n", shelltest.MapEnv{"HOME": home})
+	assert.Equal(t, "$ cd project\n"+
+		"envrc: loading ~/project/.envrc\nenvrc: export +FOO\n"+
+		"$ echo [$FOO]\n[baz]\n$ ", out)
+}
+
+func TestEnvFileRestoresVariables(t *testing.T) {
+	home := t.TempDir()
+	envFile := filepath.Join(home, "project", ".envrc")
+	require.NoError(t, os.Mkdir(filepath.Dir(envFile), 0o755))
+	require.NoError(t, os.WriteFile(envFile, []byte("FOO=new\nunset BAR\n"), 0o644))
+
+	env := shelltest.MapEnv{"HOME": home, "FOO": "old", "BAR": "kept"}
+	out := runShell(t, "cd project\nallow\necho [$FOO] [$BAR]\ncd ..\necho [$FOO] [$BAR]\n", env)
+	assert.Contains(t, out, "envrc: export -BAR ~FOO\n")
+	assert.Contains(t, out, "$ echo [$FOO] [$BAR]\n[new] []\n")
+	assert.Contains(t, out, "$ echo [$FOO] [$BAR]\n[old] [kept]\n")
+}
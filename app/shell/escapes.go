@@ -0,0 +1,123 @@
+package shell
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// expandEscapes interprets backslash escape sequences in s. printf
+// formats take octal escapes as \NNN while `echo -e` and `%b` take them
+// as \0NNN, which is selected with echoStyle. The returned bool reports
+// whether a \c sequence asked for all further output to be suppressed.
+func expandEscapes(s string, echoStyle bool) (string, bool) {
+	if !strings.ContainsRune(s, '\\') {
+		return s, false
+	}
+
+	b := strings.Builder{}
+	b.Grow(len(s))
+	for i := 0; i < len(s); {
+		if s[i] != '\\' {
+			b.WriteByte(s[i])
+			i++
+			continue
+		}
+
+		expanded, n, stop := parseEscape(s[i:], echoStyle)
+		b.WriteString(expanded)
+		if stop {
+			return b.String(), true
+		}
+		i += n
+	}
+
+	return b.String(), false
+}
+
+// parseEscape expands the escape sequence at the start of s, which must
+// begin with a backslash, and returns the number of bytes consumed.
+// Unknown sequences are kept as is.
+func parseEscape(s string, echoStyle bool) (string, int, bool) {
+	if len(s) < 2 {
+		return s, len(s), false
+	}
+
+	switch c := s[1]; c {
+	case 'a':
+		return "\a", 2, false
+	case 'b':
+		return "\b", 2, false
+	case 'e', 'E':
+		return "\x1b", 2, false
+	case 'f':
+		return "\f", 2, false
+	case 'n':
+		return "\n", 2, false
+	case 'r':
+		return "\r", 2, false
+	case 't':
+		return "\t", 2, false
+	case 'v':
+		return "\v", 2, false
+	case '\\':
+		return `\`, 2, false
+	case '"', '\'':
+		if echoStyle {
+			return s[:2], 2, false
+		}
+		return s[1:2], 2, false
+	case 'c':
+		return "", 2, true
+	case 'x':
+		if n, val := parseNumericEscape(s[2:], 16, 2); n > 0 {
+			return string([]byte{byte(val)}), 2 + n, false
+		}
+	case 'u', 'U':
+		maxDigits := 4
+		if c == 'U' {
+			maxDigits = 8
+		}
+		if n, val := parseNumericEscape(s[2:], 16, maxDigits); n > 0 && utf8.ValidRune(rune(val)) {
+			return string(rune(val)), 2 + n, false
+		}
+	case '0', '1', '2', '3', '4', '5', '6', '7':
+		if !echoStyle {
+			n, val := parseNumericEscape(s[1:], 8, 3)
+			return string([]byte{byte(val)}), 1 + n, false
+		}
+		if c == '0' {
+			n, val := parseNumericEscape(s[2:], 8, 3)
+			return string([]byte{byte(val)}), 2 + n, false
+		}
+	}
+
+	return s[:2], 2, false
+}
+
+// parseNumericEscape parses up to maxDigits digits in the given base
+// from the start of s and returns the number of digits consumed along
+// with their value.
+func parseNumericEscape(s string, base, maxDigits int) (int, int64) {
+	n := 0
+	for n < len(s) && n < maxDigits && isDigitInBase(s[n], base) {
+		n++
+	}
+	if n == 0 {
+		return 0, 0
+	}
+	val, _ := strconv.ParseInt(s[:n], base, 64)
+	return n, val
+}
+
+func isDigitInBase(c byte, base int) bool {
+	switch {
+	case c >= '0' && c <= '7':
+		return true
+	case c >= '8' && c <= '9':
+		return base > 8
+	case c >= 'a' && c <= 'f', c >= 'A' && c <= 'F':
+		return base > 10
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package shell
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"github.com/codecrafters-io/shell-starter-go/app/cmd"
+	"github.com/codecrafters-io/shell-starter-go/app/shell/interpreter"
+	"github.com/codecrafters-io/shell-starter-go/assert"
+)
+
+func NewExecCommandFunc(s *Shell) cmd.CommandFunc {
+	assert.NotNil(s, "shell")
+	return func() *cmd.Command {
+		return &cmd.Command{
+			Name: "exec",
+			Spec: &cmd.Spec{
+				Synopsis: "exec [command [argument ...]] [redirection ...]",
+				Short:    "Replace the shell with the given command.",
+				Long:     "Without COMMAND the redirections apply to the shell itself.",
+				Args:     cmd.ArgCommand,
+			},
+			Run: func(cmd *cmd.Command, args []string) error {
+				assert.Assert(len(args) > 0)
+
+				// without a command the interpreter has already applied
+				// the redirections to itself
+				if len(args) == 1 {
+					return nil
+				}
+
+				if s.ExecReplaceFunc == nil {
+					return errors.New("exec: not supported")
+				}
+
+				name := args[1]
+				path := name
+				if !strings.ContainsRune(name, '/') {
+					p, _, found := s.CommandRegistry.LookupPathCommand(name)
+					if !found {
+						return fmt.Errorf("exec: %s: %w", name, interpreter.ErrCommandNotFound)
+					}
+					path = p
+				}
+
+				// the shell is torn down before replacing it, so make sure
+				// the exec is not going to fail for obvious reasons first
+				info, err := fs.Stat(s.FS, path)
+				if err != nil {
+					return fmt.Errorf("exec: %s: %w", name, err)
+				}
+				if info.IsDir() || info.Mode()&0o111 == 0 {
+					return fmt.Errorf("exec: %s: cannot execute", name)
+				}
+
+				s.tearDown()
+
+				// the plugins are gone, so the shell cannot go on after
+				// a failed exec
+				if err := s.ExecReplaceFunc(cmd, path, args[1:]); err != nil {
+					fmt.Fprintf(cmd.Stderr, "exec: %s: %s\n", name, err)
+				}
+				return ErrExit
+			},
+		}
+	}
+}
@@ -8,6 +8,10 @@ func NewExitCommandFunc() cmd.CommandFunc {
 	return func() *cmd.Command {
 		return &cmd.Command{
 			Name: "exit",
+			Spec: &cmd.Spec{
+				Synopsis: "exit",
+				Short:    "Exit the shell.",
+			},
 			Run: func(cmd *cmd.Command, args []string) error {
 				return ErrExit
 			},
@@ -0,0 +1,31 @@
+// Package fsystest implements file systems for testing code that opens
+// files through an OpenFile method.
+package fsystest
+
+import (
+	"bytes"
+	"io"
+)
+
+// OpenFileFunc is a file system that opens files by calling itself.
+type OpenFileFunc func(string, int) (io.ReadWriteCloser, error)
+
+func (f OpenFileFunc) OpenFile(filename string, flags int) (io.ReadWriteCloser, error) {
+	return f(filename, flags)
+}
+
+// Buffer returns a file system where every file reads from and writes to
+// buf, whatever its name and flags.
+func Buffer(buf *bytes.Buffer) OpenFileFunc {
+	return func(string, int) (io.ReadWriteCloser, error) {
+		return nopCloser{buf}, nil
+	}
+}
+
+type nopCloser struct {
+	io.ReadWriter
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
@@ -37,3 +37,17 @@ func (e *KeyHandlers) handle(item terminal.Item) error {
 
 	return handler(item)
 }
+
+// BindKey runs h when key is pressed, instead of what the line editor
+// does for it.
+func (s *Shell) BindKey(key terminal.KeyEvent, h KeyHandler) {
+	s.tr.Bind(key)
+	s.keyHandlers.Use(terminal.ItemKeyBound, func(next KeyHandler) KeyHandler {
+		return func(i terminal.Item) error {
+			if i.Key != key {
+				return next(i)
+			}
+			return h(i)
+		}
+	})
+}
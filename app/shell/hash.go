@@ -0,0 +1,129 @@
+package shell
+
+import (
+	"fmt"
+
+	"github.com/codecrafters-io/shell-starter-go/app/cmd"
+	"github.com/codecrafters-io/shell-starter-go/app/shell/interpreter"
+	"github.com/codecrafters-io/shell-starter-go/assert"
+)
+
+func NewHashCommandFunc(r *cmd.Registry) cmd.CommandFunc {
+	assert.NotNil(r, "registry")
+	return func() *cmd.Command {
+		return &cmd.Command{
+			Name: "hash",
+			Spec: &cmd.Spec{
+				Synopsis: "hash [-lr] [-p pathname] [-dt] [name ...]",
+				Short:    "Remember or display program locations.",
+				Flags: []cmd.Flag{
+					{Name: 'd', Usage: "forget the remembered location of each NAME"},
+					{Name: 'l', Usage: "display in a format that may be reused as input"},
+					{Name: 'p', Arg: "pathname", Usage: "use PATHNAME as the full path of NAME"},
+					{Name: 'r', Usage: "forget all remembered locations"},
+					{Name: 't', Usage: "print the remembered location of each NAME"},
+				},
+				Args: cmd.ArgCommand,
+			},
+			Run: func(cmd *cmd.Command, args []string) error {
+				assert.Assert(len(args) > 0)
+
+				flags, names, err := cmd.Spec.Parse(args[1:])
+				if err != nil {
+					return fmt.Errorf("hash: %w", err)
+				}
+
+				if _, ok := flags['r']; ok {
+					r.Rehash()
+				}
+
+				if execPath, ok := flags['p']; ok {
+					if len(names) == 0 {
+						return fmt.Errorf("hash: -p: name argument required")
+					}
+					for _, name := range names {
+						r.HashPath(name, execPath)
+					}
+					return nil
+				}
+
+				_, forget := flags['d']
+				_, printPaths := flags['t']
+				_, reusable := flags['l']
+
+				if len(names) == 0 {
+					if forget || printPaths {
+						return fmt.Errorf("hash: -%c: name argument required", firstFlag(flags, "dt"))
+					}
+					if _, ok := flags['r']; ok {
+						return nil
+					}
+					printHashed(cmd, r.Hashed(), reusable)
+					return nil
+				}
+
+				var status error
+				for _, name := range names {
+					switch {
+					case forget:
+						if !r.Forget(name) {
+							_, _ = fmt.Fprintf(cmd.Stderr, "hash: %s: not found\n", name)
+							status = interpreter.ExitStatus(1)
+						}
+					case printPaths:
+						execPath, ok := r.Hash(name)
+						if !ok {
+							_, _ = fmt.Fprintf(cmd.Stderr, "hash: %s: not found\n", name)
+							status = interpreter.ExitStatus(1)
+							continue
+						}
+						if len(names) > 1 {
+							_, _ = fmt.Fprintf(cmd.Stdout, "%s\t%s\n", name, execPath)
+						} else {
+							_, _ = fmt.Fprintln(cmd.Stdout, execPath)
+						}
+					default:
+						// builtins are never hashed
+						if _, ok := r.LookupBuiltinCommand(name); ok {
+							continue
+						}
+						if _, ok := r.Hash(name); !ok {
+							_, _ = fmt.Fprintf(cmd.Stderr, "hash: %s: not found\n", name)
+							status = interpreter.ExitStatus(1)
+						}
+					}
+				}
+				return status
+			},
+		}
+	}
+}
+
+func printHashed(c *cmd.Command, entries []cmd.HashEntry, reusable bool) {
+	if len(entries) == 0 {
+		_, _ = fmt.Fprintln(c.Stdout, "hash: hash table empty")
+		return
+	}
+
+	if reusable {
+		for _, e := range entries {
+			_, _ = fmt.Fprintf(c.Stdout, "builtin hash -p %s %s\n", e.Path, e.Name)
+		}
+		return
+	}
+
+	_, _ = fmt.Fprintln(c.Stdout, "hits\tcommand")
+	for _, e := range entries {
+		_, _ = fmt.Fprintf(c.Stdout, "%4d\t%s\n", e.Hits, e.Path)
+	}
+}
+
+// firstFlag returns the first of the flags in order that was given.
+func firstFlag(flags map[byte]string, order string) byte {
+	for i := 0; i < len(order); i++ {
+		if _, ok := flags[order[i]]; ok {
+			return order[i]
+		}
+	}
+	return 0
+}
@@ -0,0 +1,70 @@
+package shell
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/codecrafters-io/shell-starter-go/app/shell/interpreter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashCommand(t *testing.T) {
+	r := newLookupTestRegistry()
+	steps := []struct {
+		args   string
+		stdout string
+		stderr string
+		status int
+	}{
+		{args: "hash", stdout: "hash: hash table empty\n"},
+		// builtins are not remembered
+		{args: "hash ls echo"},
+		{args: "hash missing", stderr: "hash: missing: not found\n", status: 1},
+		{args: "run ls"},
+		{args: "run ls"},
+		{args: "hash", stdout: "hits\tcommand\n   2\tbin/ls\n"},
+		{args: "hash -l", stdout: "builtin hash -p bin/ls ls\n"},
+		{args: "hash -t ls", stdout: "bin/ls\n"},
+		{args: "hash -p usr/bin/ls ls list"},
+		{args: "hash -t ls missing", stdout: "ls\tusr/bin/ls\n", stderr: "hash: missing: not found\n", status: 1},
+		{args: "hash", stdout: "hits\tcommand\n   0\tusr/bin/ls\n   0\tusr/bin/ls\n"},
+		{args: "hash -d list"},
+		{args: "hash -d list", stderr: "hash: list: not found\n", status: 1},
+		{args: "hash -r"},
+		{args: "hash", stdout: "hash: hash table empty\n"},
+		// names are looked up again once forgotten
+		{args: "hash -t ls", stdout: "bin/ls\n"},
+	}
+
+	for _, step := range steps {
+		// run steps stand for running the executable through the shell
+		if name, ok := strings.CutPrefix(step.args, "run "); ok {
+			_, _, found := r.LookupPathCommand(name)
+			require.True(t, found, step.args)
+			continue
+		}
+
+		stdout, stderr, err := runBuiltin(t, r, step.args)
+		assert.Equal(t, step.stdout, stdout, step.args)
+		assert.Equal(t, step.stderr, stderr, step.args)
+		assert.Equal(t, step.status, interpreter.StatusOf(err), step.args)
+	}
+}
+
+func TestHashCommandErrors(t *testing.T) {
+	tt := []struct {
+		args string
+		err  string
+	}{
+		{"hash -p bin/ls", "hash: -p: name argument required"},
+		{"hash -d", "hash: -d: name argument required"},
+		{"hash -t", "hash: -t: name argument required"},
+	}
+
+	r := newLookupTestRegistry()
+	for _, test := range tt {
+		_, _, err := runBuiltin(t, r, test.args)
+		assert.EqualError(t, err, test.err, test.args)
+	}
+}
@@ -0,0 +1,102 @@
+package shell
+
+import (
+	"fmt"
+	"io"
+	"path"
+
+	"github.com/codecrafters-io/shell-starter-go/app/cmd"
+	"github.com/codecrafters-io/shell-starter-go/app/shell/interpreter"
+	"github.com/codecrafters-io/shell-starter-go/assert"
+)
+
+func NewHelpCommandFunc(r *cmd.Registry) cmd.CommandFunc {
+	assert.NotNil(r, "registry")
+	return func() *cmd.Command {
+		return &cmd.Command{
+			Name: "help",
+			Spec: &cmd.Spec{
+				Synopsis: "help [-ds] [pattern ...]",
+				Short:    "Display information about builtin commands.",
+				Long:     "Shows the help of the builtins matching PATTERN, or lists every builtin.",
+				Flags: []cmd.Flag{
+					{Name: 'd', Usage: "output a short description of each topic"},
+					{Name: 's', Usage: "output only the usage synopsis of each topic"},
+				},
+				Args: cmd.ArgCommand,
+			},
+			Run: func(cmd *cmd.Command, args []string) error {
+				assert.Assert(len(args) > 0)
+
+				flags, patterns, err := cmd.Spec.Parse(args[1:])
+				if err != nil {
+					return fmt.Errorf("help: %w", err)
+				}
+
+				if len(patterns) == 0 {
+					return listBuiltins(cmd.Stdout, r)
+				}
+
+				_, short := flags['d']
+				_, synopsis := flags['s']
+
+				var status error
+				for _, pattern := range patterns {
+					found := false
+					for _, name := range r.Builtins() {
+						if ok, _ := path.Match(pattern, name); !ok {
+							continue
+						}
+						found = true
+						c, _ := r.LookupBuiltinCommand(name)
+						if err := writeBuiltinHelp(cmd.Stdout, name, c.Spec, short, synopsis); err != nil {
+							return err
+						}
+					}
+					if !found {
+						_, _ = fmt.Fprintf(cmd.Stderr, "help: no help topics match `%s'\n", pattern)
+						status = interpreter.ExitStatus(1)
+					}
+				}
+				return status
+			},
+		}
+	}
+}
+
+func listBuiltins(w io.Writer, r *cmd.Registry) error {
+	names := r.Builtins()
+	width := 0
+	for _, name := range names {
+		width = max(width, len(name))
+	}
+
+	_, _ = fmt.Fprintln(w, "These shell commands are defined internally. Type `help' to see this list.")
+	_, _ = fmt.Fprintln(w, "Type `help name' to find out more about the command `name'.")
+	_, _ = fmt.Fprintln(w)
+	for _, name := range names {
+		short := ""
+		if c, ok := r.LookupBuiltinCommand(name); ok && c.Spec != nil {
+			short = c.Spec.Short
+		}
+		if _, err := fmt.Fprintf(w, " %-*s  %s\n", width, name, short); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeBuiltinHelp(w io.Writer, name string, spec *cmd.Spec, short, synopsis bool) error {
+	var err error
+	switch {
+	case spec == nil:
+		_, err = fmt.Fprintf(w, "%s: no help available\n", name)
+	case synopsis:
+		_, err = fmt.Fprintf(w, "%s: %s\n", name, spec.Synopsis)
+	case short:
+		_, err = fmt.Fprintf(w, "%s - %s\n", name, spec.Short)
+	default:
+		err = spec.WriteHelp(w, name)
+	}
+	return err
+}
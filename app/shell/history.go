@@ -1,7 +1,6 @@
 package shell
 
 import (
-	"flag"
 	"fmt"
 	"io"
 	"slices"
@@ -27,16 +26,33 @@ func NewHistoryCommandFunc(hctx *history.HistoryContext, fsys OpenFileFS) cmd.Co
 	return func() *cmd.Command {
 		return &cmd.Command{
 			Name: "history",
+			Spec: &cmd.Spec{
+				Synopsis: "history [-r filename] [-w filename] [-a filename] [n]",
+				Short:    "Display or manipulate the history list.",
+				Long:     "Lists the last N entries of the history, or all of them.",
+				Flags: []cmd.Flag{
+					{Name: 'r', Arg: "filename", Usage: "read the history file and append it to the history"},
+					{Name: 'w', Arg: "filename", Usage: "write the history to the history file"},
+					{Name: 'a', Arg: "filename", Usage: "append the new entries to the history file"},
+				},
+			},
 			Run: func(cmd *cmd.Command, args []string) error {
-				opts := &historyOptions{}
-				flagset := flag.NewFlagSet("history", flag.ExitOnError)
-				flagset.StringVar(&opts.readFilename, "r", "", "Path to file from which to read history entries")
-				flagset.StringVar(&opts.writeFilename, "w", "", "Path to file to which to write history entries")
-				flagset.StringVar(&opts.appendFilename, "a", "", "Path to file to which to append history entries")
-				if err := flagset.Parse(args[1:]); err != nil {
-					return fmt.Errorf("parsing args: %w", err)
+				flags, rest, err := cmd.Spec.Parse(args[1:])
+				if err != nil {
+					return fmt.Errorf("history: %w", err)
+				}
+				if len(rest) > 1 {
+					return fmt.Errorf("history: too many arguments")
+				}
+
+				opts := &historyOptions{
+					readFilename:   flags['r'],
+					writeFilename:  flags['w'],
+					appendFilename: flags['a'],
+				}
+				if len(rest) > 0 {
+					opts.n = rest[0]
 				}
-				opts.n = flagset.Arg(0)
 				return runHistory(cmd.Stdout, opts, hctx, fsys)
 			},
 		}
@@ -1,6 +1,8 @@
 package history
 
 import (
+	"strings"
+
 	"golang.org/x/term"
 )
 
@@ -54,6 +56,30 @@ func (h *HistoryContext) Forward() (string, bool) {
 	return item, ok
 }
 
+// Search moves to the nearest entry containing query, looking at older
+// entries when backward is set, and returns it. The current entry is
+// looked at first unless skip is set.
+func (h *HistoryContext) Search(query string, backward, skip bool) (string, bool) {
+	h.grow()
+
+	step := 1
+	if !backward {
+		step = -1
+	}
+	start := h.pos
+	if start < 0 || skip {
+		start += step
+	}
+
+	for i := start; i >= 0 && i < h.Len(); i += step {
+		if item := h.At(i); strings.Contains(item, query) {
+			h.pos = i
+			return item, true
+		}
+	}
+	return "", false
+}
+
 func (h *HistoryContext) backIdx() int {
 	return h.pos + 1
 }
@@ -79,3 +79,36 @@ func TestHistoryContext(t *testing.T) {
 	item, ok = hctx.Forward()
 	assert.False(t, ok)
 }
+
+func TestHistoryContextSearch(t *testing.T) {
+	h := history.NewInMemoryHistory()
+	h.Add("echo one")
+	h.Add("ls")
+	h.Add("echo two")
+	hctx := history.NewHistoryContext(h)
+
+	item, ok := hctx.Search("echo", true, false)
+	assert.Equal(t, "echo two", item)
+	assert.True(t, ok)
+
+	// the current entry still matches a longer query
+	item, ok = hctx.Search("echo t", true, false)
+	assert.Equal(t, "echo two", item)
+	assert.True(t, ok)
+
+	item, ok = hctx.Search("echo", true, true)
+	assert.Equal(t, "echo one", item)
+	assert.True(t, ok)
+
+	_, ok = hctx.Search("echo", true, true)
+	assert.False(t, ok)
+
+	item, ok = hctx.Search("echo", false, true)
+	assert.Equal(t, "echo two", item)
+	assert.True(t, ok)
+
+	// browsing goes on from the match
+	item, ok = hctx.Back()
+	assert.Equal(t, "ls", item)
+	assert.True(t, ok)
+}
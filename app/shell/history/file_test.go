@@ -2,9 +2,9 @@ package history
 
 import (
 	"bytes"
-	"io"
 	"testing"
 
+	"github.com/codecrafters-io/shell-starter-go/app/shell/fsys/fsystest"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -17,18 +17,14 @@ func TestAppendHistory(t *testing.T) {
 		hctx.Add("2")
 		hctx.Add("3")
 
-		err := AppendHistoryToFile(hctx, OpenFileFSFunc(func(s string, i int) (io.ReadWriteCloser, error) {
-			return &noOpCloser{buf}, nil
-		}), "")
+		err := AppendHistoryToFile(hctx, fsystest.Buffer(buf), "")
 		assert.NoError(t, err)
 
 		hctx.Add("4")
 		hctx.Add("5")
 		hctx.Add("6")
 
-		err = AppendHistoryToFile(hctx, OpenFileFSFunc(func(s string, i int) (io.ReadWriteCloser, error) {
-			return &noOpCloser{buf}, nil
-		}), "")
+		err = AppendHistoryToFile(hctx, fsystest.Buffer(buf), "")
 		assert.NoError(t, err)
 
 		expected := "1\n2\n3\n4\n5\n6\n"
@@ -41,18 +37,14 @@ func TestAppendHistory(t *testing.T) {
 		buf := bytes.NewBuffer([]byte(p))
 		hctx := NewHistoryContext(NewInMemoryHistory())
 
-		err := AppendHistoryToFile(hctx, OpenFileFSFunc(func(s string, i int) (io.ReadWriteCloser, error) {
-			return &noOpCloser{buf}, nil
-		}), "")
+		err := AppendHistoryToFile(hctx, fsystest.Buffer(buf), "")
 		assert.NoError(t, err)
 
 		hctx.Add("4")
 		hctx.Add("5")
 		hctx.Add("6")
 
-		err = AppendHistoryToFile(hctx, OpenFileFSFunc(func(s string, i int) (io.ReadWriteCloser, error) {
-			return &noOpCloser{buf}, nil
-		}), "")
+		err = AppendHistoryToFile(hctx, fsystest.Buffer(buf), "")
 		assert.NoError(t, err)
 
 		expected := "1\n2\n3\n4\n5\n6\n"
@@ -60,17 +52,3 @@ func TestAppendHistory(t *testing.T) {
 		assert.Equal(t, expected, actual)
 	})
 }
-
-type OpenFileFSFunc func(string, int) (io.ReadWriteCloser, error)
-
-func (f OpenFileFSFunc) OpenFile(filename string, flags int) (io.ReadWriteCloser, error) {
-	return f(filename, flags)
-}
-
-type noOpCloser struct {
-	io.ReadWriter
-}
-
-func (n *noOpCloser) Close() error {
-	return nil
-}
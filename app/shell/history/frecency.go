@@ -0,0 +1,31 @@
+package history
+
+import (
+	"math"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+const (
+	// frecencyHalfLife is the number of entries after which a command
+	// counts half as much
+	frecencyHalfLife = 50
+	// frecencyWindow bounds how many entries are scored
+	frecencyWindow = 1000
+)
+
+// CommandFrecency scores the commands that start the entries of h by how
+// often and how recently they were used. Every use adds a weight that
+// halves each frecencyHalfLife entries.
+func CommandFrecency(h term.History) map[string]float64 {
+	scores := map[string]float64{}
+	for age := range min(h.Len(), frecencyWindow) {
+		fields := strings.Fields(h.At(age))
+		if len(fields) == 0 {
+			continue
+		}
+		scores[fields[0]] += math.Exp2(-float64(age) / frecencyHalfLife)
+	}
+	return scores
+}
@@ -0,0 +1,27 @@
+package history_test
+
+import (
+	"testing"
+
+	"github.com/codecrafters-io/shell-starter-go/app/shell/history"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommandFrecency(t *testing.T) {
+	h := history.NewInMemoryHistory()
+	h.Add("ls -la")
+	h.Add("ls")
+	h.Add("  ")
+	h.Add("git status")
+
+	scores := history.CommandFrecency(h)
+	assert.Len(t, scores, 2)
+	assert.Equal(t, 1.0, scores["git"])
+	assert.Greater(t, scores["ls"], scores["git"])
+
+	h.Add("git log")
+	h.Add("git diff")
+
+	scores = history.CommandFrecency(h)
+	assert.Greater(t, scores["git"], scores["ls"])
+}
@@ -12,6 +12,8 @@ const (
 	HookInitialized  Hook = "Initialized"
 	HookPreEvaluate  Hook = "PreEvaluate"
 	HookPostEvaluate Hook = "PostEvaluate"
+	// HookPostChdir runs after the working directory changed
+	HookPostChdir Hook = "PostChdir"
 )
 
 type HookFunc func()
@@ -41,6 +41,14 @@ type (
 		Stmt         Statement
 	}
 
+	// TimeStmt reports how long Stmt took to run. Stmt is nil for a
+	// bare `time`.
+	TimeStmt struct {
+		TimePos int
+		Posix   bool
+		Stmt    Statement
+	}
+
 	ArgsList struct {
 		Args []Expression
 	}
@@ -55,6 +63,13 @@ type (
 		Filename  Expression
 	}
 
+	// DupStmt points a stream at the same place as the file
+	// descriptor TargetFd, like `2>&1`.
+	DupStmt struct {
+		DupPos   int
+		TargetFd int
+	}
+
 	VariableExpr struct {
 		ValuePos int
 		Literal  string
@@ -92,12 +107,14 @@ func (x *ArgsList) Pos() int {
 }
 func (x *RedirectStmt) Pos() int         { return x.RedirectPos }
 func (x *AppendStmt) Pos() int           { return x.AppendPos }
+func (x *DupStmt) Pos() int              { return x.DupPos }
 func (x *VariableExpr) Pos() int         { return x.ValuePos }
 func (x *MultiTextExpr) Pos() int        { return x.Expressions[0].Pos() }
 func (x *RawTextExpr) Pos() int          { return x.ValuePos }
 func (x *SingleQuotedTextExpr) Pos() int { return x.ValuePos }
 func (x *DoubleQuotedTextExpr) Pos() int { return x.StartQuote }
 func (x *BackgroundStmt) Pos() int       { return x.Stmt.Pos() }
+func (x *TimeStmt) Pos() int             { return x.TimePos }
 
 func (x *Root) End() int     { return x.Cmds[0].End() }
 func (x *PipeStmt) End() int { return x.Cmds[0].End() }
@@ -117,18 +134,27 @@ func (x *ArgsList) End() int {
 }
 func (x *RedirectStmt) End() int         { return x.Filename.End() }
 func (x *AppendStmt) End() int           { return x.Filename.End() }
+func (x *DupStmt) End() int              { return x.DupPos }
 func (x *VariableExpr) End() int         { return x.ValuePos + utf8.RuneCountInString(x.Literal) }
 func (x *MultiTextExpr) End() int        { return x.Expressions[len(x.Expressions)-1].End() }
 func (x *RawTextExpr) End() int          { return x.ValuePos }
 func (x *SingleQuotedTextExpr) End() int { return x.ValuePos }
 func (x *DoubleQuotedTextExpr) End() int { return x.StartQuote }
 func (x *BackgroundStmt) End() int       { return x.AmpersandPos }
+func (x *TimeStmt) End() int {
+	if x.Stmt == nil {
+		return x.TimePos + len(timeKeyword)
+	}
+	return x.Stmt.End()
+}
 
 func (*PipeStmt) stmtNode()       {}
 func (*CommandStmt) stmtNode()    {}
 func (*AppendStmt) stmtNode()     {}
 func (*RedirectStmt) stmtNode()   {}
+func (*DupStmt) stmtNode()        {}
 func (*BackgroundStmt) stmtNode() {}
+func (*TimeStmt) stmtNode()       {}
 
 func (*MultiTextExpr) exprNode()        {}
 func (*VariableExpr) exprNode()         {}
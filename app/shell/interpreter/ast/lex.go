@@ -12,8 +12,9 @@ import (
 const (
 	eof = -1
 
-	spaceChars        = " \t\r\n"
+	spaceChars        = " \t\r"
 	quotedEscapeChars = `"\$`
+	specialParamChars = "?$!#@*-0123456789"
 )
 
 type stateFunc func(*lexer) stateFunc
@@ -171,28 +172,33 @@ func (l *lexer) variable() {
 		return
 	}
 
-	hasParen := l.accept("{")
+	if l.accept(specialParamChars) {
+		l.emit(tokenVariable)
+		return
+	}
+
+	if l.accept("{") {
+		for {
+			switch l.next() {
+			case '}':
+				l.emit(tokenVariable)
+				return
+			case eof:
+				l.errorf("unclosed variable paren")
+				return
+			}
+		}
+	}
 
 	for {
 		switch r := l.next(); {
 		case isAlphaNumeric(r):
 			// continue
 		case r == '}':
-			if !hasParen {
-				l.errorf("unexpected closing paren")
-				return
-			}
-
-			l.emit(tokenVariable)
+			l.errorf("unexpected closing paren")
 			return
 		default:
 			l.backup()
-
-			if hasParen {
-				l.errorf("unclosed variable paren")
-				return
-			}
-
 			l.emit(tokenVariable)
 			return
 		}
@@ -200,7 +206,7 @@ func (l *lexer) variable() {
 }
 
 func isSpace(r rune) bool {
-	return r == ' ' || r == '\n' || r == '\t' || r == '\r'
+	return r == ' ' || r == '\t' || r == '\r'
 }
 
 func isAlphaNumeric(r rune) bool {
@@ -247,6 +253,11 @@ func lexText(l *lexer) stateFunc {
 			l.next()
 			l.emit(tokenSemicolon)
 			return lexText
+		case r == '\n':
+			l.emitText()
+			l.next()
+			l.emit(tokenNewline)
+			return lexText
 		case r == '$':
 			l.emitText()
 			l.variable()
@@ -294,9 +305,15 @@ func lexSingleQuotes(l *lexer) stateFunc {
 func lexRedirectOrAppend(l *lexer) stateFunc {
 	_ = l.accept("12")
 	assert.Assert(l.accept(">"))
-	if l.accept(">") {
+	switch {
+	case l.accept(">"):
 		l.emit(tokenAppend)
-	} else {
+	case l.accept("&"):
+		if !l.accept("12") {
+			return l.errorf("expected file descriptor after \">&\" at char %d", l.pos)
+		}
+		l.emit(tokenDuplicate)
+	default:
 		l.emit(tokenRedirect)
 	}
 	return lexText
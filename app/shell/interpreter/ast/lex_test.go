@@ -135,6 +135,18 @@ func TestNextToken(t *testing.T) {
 				{tokenEOF, "", -1},
 			},
 		},
+		{
+			input: "one \n\"two\nthree\"",
+			output: []token{
+				{tokenText, "one", -1},
+				{tokenSpace, " ", -1},
+				{tokenNewline, "\n", -1},
+				{tokenDoubleQuote, "\"", -1},
+				{tokenText, "two\nthree", -1},
+				{tokenDoubleQuote, "\"", -1},
+				{tokenEOF, "", -1},
+			},
+		},
 		{
 			input: `one\\two`,
 			output: []token{
@@ -244,6 +256,21 @@ func TestNextToken(t *testing.T) {
 				{tokenEOF, "", -1},
 			},
 		},
+		{
+			input: "echo meep > log 2>&1",
+			output: []token{
+				{tokenText, "echo", -1},
+				{tokenSpace, " ", -1},
+				{tokenText, "meep", -1},
+				{tokenSpace, " ", -1},
+				{tokenRedirect, ">", -1},
+				{tokenSpace, " ", -1},
+				{tokenText, "log", -1},
+				{tokenSpace, " ", -1},
+				{tokenDuplicate, "2>&1", -1},
+				{tokenEOF, "", -1},
+			},
+		},
 		{
 			input: "echo meep | echo",
 			output: []token{
@@ -271,6 +298,17 @@ func TestNextToken(t *testing.T) {
 				{tokenDoubleQuote, "\"", -1},
 			},
 		},
+		{
+			input: `echo $? ${arr[1]}`,
+			output: []token{
+				{tokenText, "echo", -1},
+				{tokenSpace, " ", -1},
+				{tokenVariable, "$?", -1},
+				{tokenSpace, " ", -1},
+				{tokenVariable, "${arr[1]}", -1},
+				{tokenEOF, "", -1},
+			},
+		},
 	}
 
 	for _, test := range tt {
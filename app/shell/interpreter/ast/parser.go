@@ -7,6 +7,8 @@ import (
 	"github.com/codecrafters-io/shell-starter-go/assert"
 )
 
+const timeKeyword = "time"
+
 type Parser struct {
 	l         *lexer
 	prevToken token
@@ -85,24 +87,39 @@ func (p *Parser) parseStatements() []Statement {
 	stmts := make([]Statement, 0)
 
 	for !p.isCurToken(tokenEOF) {
+		// blank lines separate nothing
+		if p.isCurToken(tokenSpace) || p.isCurToken(tokenNewline) {
+			p.nextToken()
+			continue
+		}
+
+		var timed *TimeStmt
+		if p.isTimeKeyword() {
+			timed = p.parseTime()
+			if p.isCurToken(tokenEOF) || p.isCurToken(tokenSemicolon) || p.isCurToken(tokenNewline) {
+				stmts = append(stmts, timed)
+				p.nextToken()
+				continue
+			}
+		}
+
 		cmd := p.parseCommand()
 		if p.err != nil {
 			break
 		}
 
-		switch p.curToken.typ {
-		case tokenPipeline:
-			pipe := p.parsePipline(cmd)
-			if p.isCurToken(tokenAmpersand) {
-				stmts = append(stmts, p.parseBackground(pipe))
-			} else {
-				stmts = append(stmts, pipe)
-			}
-		case tokenAmpersand:
-			stmts = append(stmts, p.parseBackground(cmd))
-		default:
-			stmts = append(stmts, cmd)
+		var stmt Statement = cmd
+		if p.isCurToken(tokenPipeline) {
+			stmt = p.parsePipline(cmd)
+		}
+		if timed != nil {
+			timed.Stmt = stmt
+			stmt = timed
+		}
+		if p.isCurToken(tokenAmpersand) {
+			stmt = p.parseBackground(stmt)
 		}
+		stmts = append(stmts, stmt)
 
 		p.nextToken()
 	}
@@ -110,6 +127,42 @@ func (p *Parser) parseStatements() []Statement {
 	return stmts
 }
 
+// isTimeKeyword reports whether the current token is an unquoted `time`
+// at the start of a statement.
+func (p *Parser) isTimeKeyword() bool {
+	for p.isCurToken(tokenSpace) {
+		p.nextToken()
+	}
+
+	if !p.isCurToken(tokenText) || p.curToken.literal != timeKeyword {
+		return false
+	}
+	switch p.peekToken.typ {
+	case tokenSpace, tokenEOF, tokenSemicolon, tokenNewline:
+		return true
+	}
+	return false
+}
+
+func (p *Parser) parseTime() *TimeStmt {
+	assert.Assert(p.isCurToken(tokenText))
+
+	t := &TimeStmt{TimePos: p.curToken.pos - len(timeKeyword)}
+	p.nextToken()
+	for p.isCurToken(tokenSpace) {
+		p.nextToken()
+	}
+
+	if p.isCurToken(tokenText) && p.curToken.literal == "-p" && !p.isPeekToken(tokenText) {
+		t.Posix = true
+		p.nextToken()
+		for p.isCurToken(tokenSpace) {
+			p.nextToken()
+		}
+	}
+	return t
+}
+
 func (p *Parser) parseBackground(stmt Statement) *BackgroundStmt {
 	assert.Assert(p.isCurToken(tokenAmpersand))
 
@@ -130,6 +183,10 @@ func (p *Parser) parsePipline(first *CommandStmt) *PipeStmt {
 
 	for p.isCurToken(tokenPipeline) {
 		p.nextToken()
+		// a pipeline may continue on the next line
+		for p.isCurToken(tokenSpace) || p.isCurToken(tokenNewline) {
+			p.nextToken()
+		}
 
 		cmd := p.parseCommand()
 		if p.err != nil {
@@ -171,7 +228,7 @@ func (p *Parser) parseCommand() *CommandStmt {
 	cmd.Args = p.parseArgsList()
 
 Loop:
-	for {
+	for p.err == nil {
 		switch p.curToken.typ {
 		case tokenRedirect:
 			isStdout := !strings.HasPrefix(p.curToken.literal, "2")
@@ -179,7 +236,7 @@ Loop:
 			if isStdout {
 				cmd.StdOut = append(cmd.StdOut, r)
 			} else {
-				cmd.StdErr = append(cmd.StdOut, r)
+				cmd.StdErr = append(cmd.StdErr, r)
 			}
 		case tokenAppend:
 			isStdout := !strings.HasPrefix(p.curToken.literal, "2")
@@ -187,8 +244,19 @@ Loop:
 			if isStdout {
 				cmd.StdOut = append(cmd.StdOut, a)
 			} else {
-				cmd.StdErr = append(cmd.StdOut, a)
+				cmd.StdErr = append(cmd.StdErr, a)
+			}
+		case tokenDuplicate:
+			isStdout := !strings.HasPrefix(p.curToken.literal, "2")
+			d := p.parseDup()
+			if isStdout {
+				cmd.StdOut = append(cmd.StdOut, d)
+			} else {
+				cmd.StdErr = append(cmd.StdErr, d)
 			}
+		case tokenSpace:
+			// more redirections may follow
+			p.nextToken()
 		default:
 			break Loop
 		}
@@ -381,6 +449,7 @@ Loop:
 
 func (p *Parser) parseRedirect() *RedirectStmt {
 	assert.Assert(p.isCurToken(tokenRedirect))
+	pos := p.curToken.pos
 
 	if !p.isPrevToken(tokenSpace) {
 		p.errorf("expected space before redirect token")
@@ -404,11 +473,12 @@ func (p *Parser) parseRedirect() *RedirectStmt {
 		p.errorf("expected filename after redirect token but got %s", p.peekToken.typ)
 	}
 
-	return &RedirectStmt{Filename: filename}
+	return &RedirectStmt{RedirectPos: pos, Filename: filename}
 }
 
 func (p *Parser) parseAppend() *AppendStmt {
 	assert.Assert(p.isCurToken(tokenAppend))
+	pos := p.curToken.pos
 
 	if !p.isPrevToken(tokenSpace) {
 		p.errorf("expected space before append token")
@@ -432,5 +502,22 @@ func (p *Parser) parseAppend() *AppendStmt {
 		p.errorf("expected filename after append token but got %s", p.peekToken.typ)
 	}
 
-	return &AppendStmt{Filename: filename}
+	return &AppendStmt{AppendPos: pos, Filename: filename}
+}
+
+func (p *Parser) parseDup() *DupStmt {
+	assert.Assert(p.isCurToken(tokenDuplicate))
+
+	if !p.isPrevToken(tokenSpace) {
+		p.errorf("expected space before duplicate token")
+		return nil
+	}
+
+	literal := p.curToken.literal
+	d := &DupStmt{
+		DupPos:   p.curToken.pos,
+		TargetFd: int(literal[len(literal)-1] - '0'),
+	}
+	p.nextToken()
+	return d
 }
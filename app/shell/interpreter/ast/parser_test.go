@@ -68,3 +68,50 @@ func TestCommandEnd(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Len(t, prog.Cmds, 1)
 }
+
+func TestRedirects(t *testing.T) {
+	input := `echo mino > out.txt 2>> err.txt >&2 | more`
+	prog, err := Parse(input)
+	require.NoError(t, err)
+	require.Len(t, prog.Cmds, 1)
+	require.IsType(t, &PipeStmt{}, prog.Cmds[0])
+
+	cmd := prog.Cmds[0].(*PipeStmt).Cmds[0]
+	require.Len(t, cmd.StdOut, 2)
+	require.Len(t, cmd.StdErr, 1)
+	assert.IsType(t, &RedirectStmt{}, cmd.StdOut[0])
+	assert.Equal(t, &DupStmt{DupPos: cmd.StdOut[1].Pos(), TargetFd: 2}, cmd.StdOut[1])
+	assert.IsType(t, &AppendStmt{}, cmd.StdErr[0])
+}
+
+func TestTime(t *testing.T) {
+	input := `time -p echo 1 | more & time; timer`
+	prog, err := Parse(input)
+	require.NoError(t, err)
+	require.Len(t, prog.Cmds, 3)
+
+	require.IsType(t, &BackgroundStmt{}, prog.Cmds[0])
+	timed := prog.Cmds[0].(*BackgroundStmt).Stmt
+	if assert.IsType(t, &TimeStmt{}, timed) {
+		assert.True(t, timed.(*TimeStmt).Posix)
+		assert.IsType(t, &PipeStmt{}, timed.(*TimeStmt).Stmt)
+	}
+
+	assert.Equal(t, &TimeStmt{TimePos: 24}, prog.Cmds[1])
+	assert.IsType(t, &CommandStmt{}, prog.Cmds[2])
+}
+
+func TestNewlines(t *testing.T) {
+	input := "\necho 1\n\necho 2 &\necho 3 |\n  more;\n\"echo\n4\"\n"
+	prog, err := Parse(input)
+	require.NoError(t, err)
+	require.Len(t, prog.Cmds, 4)
+
+	assert.IsType(t, &CommandStmt{}, prog.Cmds[0])
+	assert.IsType(t, &BackgroundStmt{}, prog.Cmds[1])
+	assert.IsType(t, &PipeStmt{}, prog.Cmds[2])
+	// a quoted newline stays in the word
+	if assert.IsType(t, &CommandStmt{}, prog.Cmds[3]) {
+		assert.Empty(t, prog.Cmds[3].(*CommandStmt).Args.Args)
+	}
+}
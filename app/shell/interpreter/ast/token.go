@@ -21,6 +21,8 @@ const (
 	tokenAmpersand
 	tokenVariable
 	tokenSemicolon
+	tokenDuplicate
+	tokenNewline
 )
 
 type token struct {
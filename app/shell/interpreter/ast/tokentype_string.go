@@ -21,11 +21,13 @@ func _() {
 	_ = x[tokenAmpersand-10]
 	_ = x[tokenVariable-11]
 	_ = x[tokenSemicolon-12]
+	_ = x[tokenDuplicate-13]
+	_ = x[tokenNewline-14]
 }
 
-const _tokenType_name = "ErrorEOFSpaceTextSingleQuoteDoubleQuoteEscapedRedirectAppendPipelineAmpersandVariableSemicolon"
+const _tokenType_name = "ErrorEOFSpaceTextSingleQuoteDoubleQuoteEscapedRedirectAppendPipelineAmpersandVariableSemicolonDuplicateNewline"
 
-var _tokenType_index = [...]uint8{0, 5, 8, 13, 17, 28, 39, 46, 54, 60, 68, 77, 85, 94}
+var _tokenType_index = [...]uint8{0, 5, 8, 13, 17, 28, 39, 46, 54, 60, 68, 77, 85, 94, 103, 110}
 
 func (i tokenType) String() string {
 	idx := int(i) - 0
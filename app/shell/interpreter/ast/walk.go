@@ -42,7 +42,9 @@ func Walk(v Visitor, node Node) {
 		walkList(v, n.Expressions)
 	case *BackgroundStmt:
 		Walk(v, n.Stmt)
-	case *VariableExpr, *SingleQuotedTextExpr, *RawTextExpr:
+	case *TimeStmt:
+		Walk(v, n.Stmt)
+	case *DupStmt, *VariableExpr, *SingleQuotedTextExpr, *RawTextExpr:
 	default:
 		panic("cannot walk node of type: " + reflect.TypeOf(n).String())
 	}
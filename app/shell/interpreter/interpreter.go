@@ -1,13 +1,16 @@
 package interpreter
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
 	"io"
 	"os"
 	"reflect"
+	"slices"
 	"strings"
+	"sync"
 
 	"github.com/codecrafters-io/shell-starter-go/app/shell/interpreter/ast"
 	"golang.org/x/sync/errgroup"
@@ -17,6 +20,10 @@ var (
 	ErrCommandNotFound = errors.New("command not found")
 )
 
+// execBuiltin is the name of the builtin whose redirections apply to
+// the interpreter itself when it is given no command
+const execBuiltin = "exec"
+
 type (
 	CmdFunc       func(ctx context.Context, stdin io.Reader, stdout, stderr io.Writer, args []string) error
 	CmdLookupFunc func(name string) (cmd CmdFunc, found bool, err error)
@@ -56,6 +63,14 @@ func WithEnvFunc(f EnvFunc) interpreterOption {
 	}
 }
 
+// WithInteractive marks the interpreter as evaluating commands typed at
+// a prompt. Interactive interpreters ignore noexec.
+func WithInteractive(interactive bool) interpreterOption {
+	return func(p *Interpreter) {
+		p.interactive = interactive
+	}
+}
+
 func WithOpenFileFunc(f OpenFileFunc) interpreterOption {
 	return func(p *Interpreter) {
 		if f != nil {
@@ -69,9 +84,22 @@ type Interpreter struct {
 	getenv   EnvFunc
 	openFile OpenFileFunc
 
+	vars     *variables
+	status   int
+	statusMu sync.Mutex
+	trapFunc TrapFunc
+	tilde    TildeFunc
+	inTrap   bool
+
+	opts        *Options
+	interactive bool
+
 	stdin  io.Reader
 	stdout io.Writer
 	stderr io.Writer
+	// execFiles are the files stdout and stderr were redirected to by
+	// exec
+	execFiles []io.Closer
 }
 
 func DefaultInterpreter() *Interpreter {
@@ -79,6 +107,8 @@ func DefaultInterpreter() *Interpreter {
 		cmdReg:   func(name string) (cmd CmdFunc, found bool, err error) { return nil, false, nil },
 		getenv:   func(s string) string { return "" },
 		openFile: func(s string, i int, fm os.FileMode) (io.ReadWriteCloser, error) { return nil, os.ErrNotExist },
+		vars:     newVariables(),
+		opts:     newOptions(),
 		stdin:    os.Stdin,
 		stdout:   os.Stdout,
 		stderr:   os.Stderr,
@@ -99,28 +129,48 @@ func (p *Interpreter) Evaluate(input string) error {
 		return fmt.Errorf("parse: %w", err)
 	}
 
-	return p.eval(root)
+	if p.opts.IsSet(OptNoExec) && !p.interactive {
+		return nil
+	}
+
+	return p.eval(context.Background(), root)
 }
 
-func (p *Interpreter) eval(n ast.Node) error {
+func (p *Interpreter) eval(ctx context.Context, n ast.Node) error {
 	switch n := n.(type) {
 	case *ast.Root:
-		return p.evalSequential(n.Cmds)
+		return p.evalSequential(ctx, n.Cmds)
 	case *ast.PipeStmt:
-		return p.evalPipeline(n, nil)
+		p.trap(TrapDebug)
+		return p.evalPipeline(ctx, n, nil)
 	case *ast.CommandStmt:
-		return p.evalCmd(n, nil, nil)
+		p.trap(TrapDebug)
+		return p.evalCmd(ctx, n, nil, nil)
+	case *ast.TimeStmt:
+		return p.evalTime(ctx, n)
 	}
 	return nil
 }
 
-func (p *Interpreter) evalSequential(stmts []ast.Statement) error {
+func (p *Interpreter) evalSequential(ctx context.Context, stmts []ast.Statement) error {
+	var err error
 	for _, stmt := range stmts {
-		if err := p.eval(stmt); err != nil {
+		err = p.eval(ctx, stmt)
+		p.setStatus(err)
+		if err != nil {
+			p.trap(TrapErr)
+		}
+		if err != nil && p.opts.IsSet(OptErrExit) && !p.inTrap {
+			// the constructs errexit exempts, like conditions and
+			// && or || lists, are not part of the grammar yet so
+			// only commands in a pipeline but the last are exempt
+			return &ErrexitError{err}
+		}
+		if err != nil && !IsExitStatus(err) {
 			return err
 		}
 	}
-	return nil
+	return err
 }
 
 // func (p *Interpreter) evalBackground(bg *ast.BackgroundStmt) error {
@@ -130,14 +180,15 @@ func (p *Interpreter) evalSequential(stmts []ast.Statement) error {
 
 // evalPipeline evaluates a pipline statement optionally overriding the output
 // passed in out if no-nil.
-func (p *Interpreter) evalPipeline(pipe *ast.PipeStmt, out io.Writer) error {
+func (p *Interpreter) evalPipeline(ctx context.Context, pipe *ast.PipeStmt, out io.Writer) error {
 	if len(pipe.Cmds) == 0 {
 		return nil
 	}
 	if len(pipe.Cmds) == 1 {
-		return p.evalCmd(pipe.Cmds[0], nil, nil)
+		return p.evalCmd(ctx, pipe.Cmds[0], nil, nil)
 	}
 
+	errs := make([]error, len(pipe.Cmds))
 	eg := errgroup.Group{}
 	var pr *io.PipeReader
 	for i := 0; i < len(pipe.Cmds)-1; i++ {
@@ -148,25 +199,67 @@ func (p *Interpreter) evalPipeline(pipe *ast.PipeStmt, out io.Writer) error {
 			r = &ignoreClosedPipeRead{pr}
 		}
 		eg.Go(func() error {
-			return p.evalCmd(pipe.Cmds[i], r, &ignoreClosedPipeWrite{pw})
+			errs[i] = p.evalCmd(ctx, pipe.Cmds[i], r, &ignoreClosedPipeWrite{pw})
+			return nil
 		})
 
 		pr = nextReader
 	}
 
+	last := len(pipe.Cmds) - 1
 	eg.Go(func() error {
-		return p.evalCmd(pipe.Cmds[len(pipe.Cmds)-1], &ignoreClosedPipeRead{pr}, out)
+		errs[last] = p.evalCmd(ctx, pipe.Cmds[last], &ignoreClosedPipeRead{pr}, out)
+		return nil
 	})
 
-	return eg.Wait()
+	_ = eg.Wait()
+	return p.pipelineResult(errs)
+}
+
+// pipelineResult picks the error that determines the status of a
+// pipeline, which is the last command's or with pipefail the rightmost
+// failure. Other failures that are more than an exit status are reported
+// on stderr as they would otherwise go unnoticed.
+func (p *Interpreter) pipelineResult(errs []error) error {
+	result := errs[len(errs)-1]
+	if p.opts.IsSet(OptPipeFail) {
+		for i := len(errs) - 1; i >= 0; i-- {
+			if errs[i] != nil {
+				result = errs[i]
+				break
+			}
+		}
+	}
+
+	for _, err := range errs {
+		if err != nil && err != result && !IsExitStatus(err) {
+			fmt.Fprintln(p.stderr, err)
+		}
+	}
+	return result
 }
 
-func (p *Interpreter) evalCmd(cmdStmt *ast.CommandStmt, r io.Reader, w io.Writer) error {
-	cmdName, err := p.evalExpression(cmdStmt.Name)
+func (p *Interpreter) evalCmd(ctx context.Context, cmdStmt *ast.CommandStmt, r io.Reader, w io.Writer) error {
+	// pipe ends are closed even if the command never runs so that the
+	// rest of the pipeline does not wait on it
+	if c, ok := r.(io.Closer); ok {
+		defer c.Close()
+	}
+	if c, ok := w.(io.Closer); ok {
+		defer c.Close()
+	}
+
+	cmdName, err := p.evalWord(cmdStmt.Name)
 	if err != nil {
 		return fmt.Errorf("eval command name: %w", err)
 	}
 
+	// exec without a command keeps its redirections for the rest of
+	// the session instead of applying them to a single command
+	if cmdName == execBuiltin && len(cmdStmt.Args.Args) == 0 && r == nil && w == nil {
+		return p.evalExecRedirects(cmdStmt)
+	}
+
 	cmdFunc, found, err := p.cmdReg(cmdName)
 	if err != nil {
 		return fmt.Errorf("look up command: %w", err)
@@ -183,74 +276,120 @@ func (p *Interpreter) evalCmd(cmdStmt *ast.CommandStmt, r io.Reader, w io.Writer
 
 	if r == nil {
 		r = p.stdin
-	} else {
-		if c, ok := r.(io.Closer); ok {
-			defer c.Close()
-		}
 	}
 
-	stdouts := make([]io.Writer, 0)
-	stderrs := make([]io.Writer, 0)
+	stdout, stderr, files, err := p.evalRedirects(cmdStmt, w)
+	for _, f := range files {
+		defer f.Close()
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", cmdName, err)
+	}
 
-	if w != nil {
-		stdouts = append(stdouts, w)
-		if c, ok := w.(io.Closer); ok {
-			defer c.Close()
-		}
-	} else {
-		if len(cmdStmt.StdOut) == 0 {
-			stdouts = append(stdouts, p.stdout)
-		}
+	if p.opts.IsSet(OptXTrace) {
+		p.xtrace(args)
 	}
 
-	for _, n := range cmdStmt.StdOut {
-		wr, err := p.evalStdOutStmt(n)
-		if err != nil {
-			return fmt.Errorf("%s: eval output writer: %w", cmdName, err)
+	return cmdFunc(ctx, r, stdout, stderr, args)
+}
+
+// evalExecRedirects points the interpreter's own stdout and stderr at
+// the redirections of an exec. The files it opens stay open until a
+// later exec points both streams elsewhere.
+func (p *Interpreter) evalExecRedirects(cmdStmt *ast.CommandStmt) error {
+	if p.opts.IsSet(OptXTrace) {
+		p.xtrace([]string{execBuiltin})
+	}
+
+	stdout, stderr, files, err := p.evalRedirects(cmdStmt, nil)
+	if err != nil {
+		for _, f := range files {
+			_ = f.Close()
 		}
-		if c, ok := wr.(io.Closer); ok {
-			defer c.Close()
+		return fmt.Errorf("%s: %w", execBuiltin, err)
+	}
+
+	p.stdout = stdout
+	p.stderr = stderr
+
+	open := make([]io.Closer, 0, 2)
+	for _, f := range slices.Concat(p.execFiles, files) {
+		if any(f) == any(stdout) || any(f) == any(stderr) {
+			open = append(open, f)
+		} else {
+			_ = f.Close()
 		}
-		stdouts = append(stdouts, wr)
+	}
+	p.execFiles = open
+	return nil
+}
+
+// evalRedirects opens the output redirections of cmdStmt and returns the
+// writers for its stdout and stderr. w is the pipe the command writes
+// to, if any. The returned files must be closed by the caller, even
+// when an error is returned.
+func (p *Interpreter) evalRedirects(cmdStmt *ast.CommandStmt, w io.Writer) (stdout, stderr io.Writer, files []io.Closer, err error) {
+	type redirect struct {
+		fd   int
+		stmt ast.Statement
 	}
 
+	// the parser keeps the redirections of each stream apart, but they
+	// apply from left to right like dup2 does, so `2>&1 >log` leaves
+	// stderr where stdout was before
+	redirects := make([]redirect, 0, len(cmdStmt.StdOut)+len(cmdStmt.StdErr))
+	for _, n := range cmdStmt.StdOut {
+		redirects = append(redirects, redirect{1, n})
+	}
 	for _, n := range cmdStmt.StdErr {
-		wr, err := p.evalStdOutStmt(n)
+		redirects = append(redirects, redirect{2, n})
+	}
+	slices.SortStableFunc(redirects, func(a, b redirect) int {
+		return cmp.Compare(a.stmt.Pos(), b.stmt.Pos())
+	})
+
+	fds := map[int]io.Writer{1: p.stdout, 2: p.stderr}
+	if w != nil {
+		fds[1] = w
+	}
+	for _, r := range redirects {
+		if d, ok := r.stmt.(*ast.DupStmt); ok {
+			target, ok := fds[d.TargetFd]
+			if !ok {
+				return nil, nil, files, fmt.Errorf("%d: bad file descriptor", d.TargetFd)
+			}
+			fds[r.fd] = target
+			continue
+		}
+
+		wr, err := p.evalStdOutStmt(r.stmt)
 		if err != nil {
-			return fmt.Errorf("%s: eval err output writer: %w", cmdName, err)
+			return nil, nil, files, fmt.Errorf("eval output writer for fd %d: %w", r.fd, err)
 		}
 		if c, ok := wr.(io.Closer); ok {
-			defer c.Close()
+			files = append(files, c)
 		}
-		stderrs = append(stderrs, wr)
-	}
-
-	if len(stderrs) == 0 {
-		stderrs = append(stderrs, p.stderr)
+		fds[r.fd] = wr
 	}
-
-	stdout := io.MultiWriter(stdouts...)
-	stderr := io.MultiWriter(stderrs...)
-
-	return cmdFunc(context.TODO(), r, stdout, stderr, args)
+	return fds[1], fds[2], files, nil
 }
 
 func (p *Interpreter) evalStdOutStmt(stmt ast.Statement) (io.Writer, error) {
 	switch n := stmt.(type) {
 	case *ast.RedirectStmt:
-		filename, err := p.evalExpression(n.Filename)
+		filename, err := p.evalWord(n.Filename)
 		if err != nil {
 			return nil, fmt.Errorf("eval filename: %w", err)
 		}
 
-		return p.openFile(filename, os.O_WRONLY|os.O_TRUNC|os.O_CREATE, 0644)
+		return p.openFile(filename, os.O_WRONLY|os.O_TRUNC|os.O_CREATE, 0666)
 	case *ast.AppendStmt:
-		filename, err := p.evalExpression(n.Filename)
+		filename, err := p.evalWord(n.Filename)
 		if err != nil {
 			return nil, fmt.Errorf("eval filename: %w", err)
 		}
 
-		return p.openFile(filename, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+		return p.openFile(filename, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0666)
 	default:
 		return nil, fmt.Errorf("unsupported stdout statement of type: %s", reflect.TypeOf(n).String())
 	}
@@ -289,7 +428,18 @@ func (p *Interpreter) evalExpression(expr ast.Expression) (string, error) {
 		}
 		return b.String(), nil
 	case *ast.VariableExpr:
-		return os.Expand(n.Literal, p.getenv), nil
+		var unbound []string
+		val := os.Expand(n.Literal, func(name string) string {
+			val, ok := p.lookupParam(name)
+			if !ok {
+				unbound = append(unbound, name)
+			}
+			return val
+		})
+		if len(unbound) > 0 && p.opts.IsSet(OptNoUnset) {
+			return "", fmt.Errorf("%s: unbound variable", unbound[0])
+		}
+		return val, nil
 	default:
 		return "", fmt.Errorf("unsupported expression of type: %s", reflect.TypeOf(n).String())
 	}
@@ -298,7 +448,7 @@ func (p *Interpreter) evalExpression(expr ast.Expression) (string, error) {
 func (p *Interpreter) evalArgsList(argsList *ast.ArgsList) ([]string, error) {
 	args := make([]string, 0, len(argsList.Args))
 	for _, a := range argsList.Args {
-		s, err := p.evalExpression(a)
+		s, err := p.evalWord(a)
 		if err != nil {
 			return nil, err
 		}
@@ -309,6 +459,21 @@ func (p *Interpreter) evalArgsList(argsList *ast.ArgsList) ([]string, error) {
 	return args, nil
 }
 
+// xtrace prints the expanded command prefixed by PS4 as done by
+// `set -x`.
+func (p *Interpreter) xtrace(args []string) {
+	ps4, ok := p.LookupVar("PS4")
+	if !ok {
+		ps4 = "+ "
+	}
+
+	quoted := make([]string, 0, len(args))
+	for _, arg := range args {
+		quoted = append(quoted, Quote(arg))
+	}
+	fmt.Fprintf(p.stderr, "%s%s\n", ps4, strings.Join(quoted, " "))
+}
+
 type ignoreClosedPipeWrite struct {
 	*io.PipeWriter
 }
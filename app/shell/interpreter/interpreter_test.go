@@ -6,9 +6,11 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"slices"
 	"strings"
 	"testing"
 	"testing/synctest"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -222,6 +224,244 @@ func TestInterpretSingleCommands(t *testing.T) {
 	}
 }
 
+func TestVariables(t *testing.T) {
+	outBuf := bytes.NewBuffer(nil)
+	interp := NewInterpreter(
+		WithIO(nil, outBuf, outBuf),
+		WithCmdLookupFunc(func(name string) (cmd CmdFunc, found bool, err error) {
+			return func(_ context.Context, stdin io.Reader, stdout, stderr io.Writer, args []string) error {
+				if name == "false" {
+					return ExitStatus(3)
+				}
+				fmt.Fprintln(stdout, strings.Join(args[1:], " "))
+				return nil
+			}, true, nil
+		}),
+	)
+	interp.SetVar("name", "mino")
+	interp.SetArrayVar("arr", []string{"a", "b", "c"})
+
+	synctest.Test(t, func(t *testing.T) {
+		err := interp.Evaluate(`false; echo $? $name ${arr[1]} "${arr[@]}"`)
+		require.NoError(t, err)
+		assert.Equal(t, "3 mino b a b c\n", outBuf.String())
+	})
+}
+
+func TestOptions(t *testing.T) {
+	tt := []struct {
+		name     string
+		opts     []string
+		input    string
+		output   string
+		checkErr func(t assert.TestingT, err error, msgAndArgs ...any) bool
+	}{
+		{"pipefail", []string{OptPipeFail}, `false | true; echo $?`, "1\n", assert.NoError},
+		{"no pipefail", nil, `false | true; echo $?`, "0\n", assert.NoError},
+		{"errexit", []string{OptErrExit}, `false; echo unreachable`, "", func(t assert.TestingT, err error, _ ...any) bool {
+			var errexit *ErrexitError
+			return assert.ErrorAs(t, err, &errexit) && assert.Equal(t, 1, StatusOf(err))
+		}},
+		{"nounset", []string{OptNoUnset}, `echo $unset`, "", assert.Error},
+		{"noexec", []string{OptNoExec}, `echo 1`, "", assert.NoError},
+		{"xtrace", []string{OptXTrace}, `echo 'a b'`, "+ echo 'a b'\na b\n", assert.NoError},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			outBuf := bytes.NewBuffer(nil)
+			interp := NewInterpreter(
+				WithIO(nil, outBuf, outBuf),
+				WithCmdLookupFunc(func(name string) (cmd CmdFunc, found bool, err error) {
+					return func(_ context.Context, stdin io.Reader, stdout, stderr io.Writer, args []string) error {
+						switch name {
+						case "false":
+							return ExitStatus(1)
+						case "echo":
+							fmt.Fprintln(stdout, strings.Join(args[1:], " "))
+						}
+						return nil
+					}, true, nil
+				}),
+			)
+			for _, opt := range test.opts {
+				require.NoError(t, interp.Options().Set(opt, true))
+			}
+
+			synctest.Test(t, func(t *testing.T) {
+				err := interp.Evaluate(test.input)
+				test.checkErr(t, err)
+				assert.Equal(t, test.output, outBuf.String())
+			})
+		})
+	}
+}
+
+func TestExecRedirect(t *testing.T) {
+	outBuf := bytes.NewBuffer(nil)
+	errBuf := bytes.NewBuffer(nil)
+	logBuf := bytes.NewBuffer(nil)
+	interp := NewInterpreter(
+		WithIO(nil, outBuf, errBuf),
+		WithCmdLookupFunc(func(name string) (cmd CmdFunc, found bool, err error) {
+			assert.Equal(t, "warn", name)
+			return func(_ context.Context, stdin io.Reader, stdout, stderr io.Writer, args []string) error {
+				fmt.Fprintln(stdout, "out", args[1])
+				fmt.Fprintln(stderr, "err", args[1])
+				return nil
+			}, true, nil
+		}),
+		WithOpenFileFunc(func(s string, i int, fm os.FileMode) (io.ReadWriteCloser, error) {
+			assert.Equal(t, "log", s)
+			return &noOpCloser{logBuf}, nil
+		}),
+	)
+
+	synctest.Test(t, func(t *testing.T) {
+		err := interp.Evaluate(`warn 1 >&2; warn 2 2>&1; exec > log 2>&1; warn 3`)
+		require.NoError(t, err)
+		assert.Equal(t, "out 2\nerr 2\n", outBuf.String())
+		assert.Equal(t, "out 1\nerr 1\n", errBuf.String())
+		assert.Equal(t, "out 3\nerr 3\n", logBuf.String())
+	})
+}
+
+func TestExecRedirectCloses(t *testing.T) {
+	opened := map[string]*closeRecorder{}
+	interp := NewInterpreter(
+		WithIO(nil, io.Discard, io.Discard),
+		WithCmdLookupFunc(func(name string) (cmd CmdFunc, found bool, err error) {
+			return func(_ context.Context, stdin io.Reader, stdout, stderr io.Writer, args []string) error {
+				fmt.Fprintln(stdout, "out", args[1])
+				fmt.Fprintln(stderr, "err", args[1])
+				return nil
+			}, true, nil
+		}),
+		WithOpenFileFunc(func(s string, i int, fm os.FileMode) (io.ReadWriteCloser, error) {
+			f := &closeRecorder{ReadWriter: bytes.NewBuffer(nil)}
+			opened[s] = f
+			return f, nil
+		}),
+	)
+	closed := func() []string {
+		names := make([]string, 0)
+		for name, f := range opened {
+			if f.closed {
+				names = append(names, name)
+			}
+		}
+		slices.Sort(names)
+		return names
+	}
+
+	synctest.Test(t, func(t *testing.T) {
+		require.NoError(t, interp.Evaluate(`exec > a 2> b`))
+		assert.Empty(t, closed())
+
+		// a is still where stderr goes
+		require.NoError(t, interp.Evaluate(`exec 2>&1 > c`))
+		assert.Equal(t, []string{"b"}, closed())
+
+		require.NoError(t, interp.Evaluate(`exec > d > e 2>&1`))
+		assert.Equal(t, []string{"a", "b", "c", "d"}, closed())
+
+		require.NoError(t, interp.Evaluate(`warn 1`))
+		assert.Equal(t, "out 1\nerr 1\n", opened["e"].ReadWriter.(*bytes.Buffer).String())
+	})
+}
+
+type closeRecorder struct {
+	io.ReadWriter
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestRedirectOrder(t *testing.T) {
+	tt := []struct {
+		name   string
+		input  string
+		stdout string
+		stderr string
+		log    string
+	}{
+		{"stdout to stderr", `warn 1 >&2 2>&1`, "", "out 1\nerr 1\n", ""},
+		{"stderr to the old stdout", `warn 1 2>&1 > log`, "err 1\n", "", "out 1\n"},
+		{"both to the file", `warn 1 > log 2>&1`, "", "", "out 1\nerr 1\n"},
+		{"last file wins", `warn 1 2> log 2>&1`, "out 1\nerr 1\n", "", ""},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			outBuf := bytes.NewBuffer(nil)
+			errBuf := bytes.NewBuffer(nil)
+			logBuf := bytes.NewBuffer(nil)
+			interp := NewInterpreter(
+				WithIO(nil, outBuf, errBuf),
+				WithCmdLookupFunc(func(name string) (cmd CmdFunc, found bool, err error) {
+					return func(_ context.Context, stdin io.Reader, stdout, stderr io.Writer, args []string) error {
+						fmt.Fprintln(stdout, "out", args[1])
+						fmt.Fprintln(stderr, "err", args[1])
+						return nil
+					}, true, nil
+				}),
+				WithOpenFileFunc(func(s string, i int, fm os.FileMode) (io.ReadWriteCloser, error) {
+					return &noOpCloser{logBuf}, nil
+				}),
+			)
+
+			synctest.Test(t, func(t *testing.T) {
+				err := interp.Evaluate(test.input)
+				require.NoError(t, err)
+				assert.Equal(t, test.stdout, outBuf.String())
+				assert.Equal(t, test.stderr, errBuf.String())
+				assert.Equal(t, test.log, logBuf.String())
+			})
+		})
+	}
+}
+
+func TestTime(t *testing.T) {
+	tt := []struct {
+		name       string
+		timeFormat string
+		input      string
+		output     string
+	}{
+		{"default", "", `time work`, "\nreal\t0m2.000s\nuser\t0m1.500s\nsys\t0m0.250s\n"},
+		{"posix", "", `time -p work | work`, "real 2.00\nuser 3.00\nsys 0.50\n"},
+		{"format", "%1R %U %S %P%% %M", `time work`, "2.0 1.500 0.250 87.50% 2048\n"},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			errBuf := bytes.NewBuffer(nil)
+			interp := NewInterpreter(
+				WithIO(nil, io.Discard, errBuf),
+				WithCmdLookupFunc(func(name string) (cmd CmdFunc, found bool, err error) {
+					return func(ctx context.Context, stdin io.Reader, stdout, stderr io.Writer, args []string) error {
+						time.Sleep(2 * time.Second)
+						ReportUsage(ctx, Usage{User: 1500 * time.Millisecond, Sys: 250 * time.Millisecond, MaxRSS: 2048})
+						return nil
+					}, true, nil
+				}),
+			)
+			if len(test.timeFormat) > 0 {
+				interp.SetVar("TIMEFORMAT", test.timeFormat)
+			}
+
+			synctest.Test(t, func(t *testing.T) {
+				err := interp.Evaluate(test.input)
+				require.NoError(t, err)
+				assert.Equal(t, test.output, errBuf.String())
+			})
+		})
+	}
+}
+
 type noOpCloser struct {
 	io.ReadWriter
 }
@@ -229,3 +469,31 @@ type noOpCloser struct {
 func (*noOpCloser) Close() error {
 	return nil
 }
+
+func TestTilde(t *testing.T) {
+	outBuf := bytes.NewBuffer(nil)
+	interp := NewInterpreter(
+		WithIO(nil, outBuf, outBuf),
+		WithCmdLookupFunc(func(name string) (cmd CmdFunc, found bool, err error) {
+			return func(_ context.Context, stdin io.Reader, stdout, stderr io.Writer, args []string) error {
+				fmt.Fprintln(stdout, strings.Join(args[1:], " "))
+				return nil
+			}, true, nil
+		}),
+		WithTildeFunc(func(prefix string) (string, bool) {
+			switch prefix {
+			case "":
+				return "/home/mino", true
+			case "1":
+				return "/tmp", true
+			}
+			return "", false
+		}),
+	)
+
+	synctest.Test(t, func(t *testing.T) {
+		err := interp.Evaluate(`echo ~ ~/src ~1/x ~2 a~ "~" ~/"a b" '~'/x`)
+		require.NoError(t, err)
+		assert.Equal(t, "/home/mino /home/mino/src /tmp/x ~2 a~ ~ /home/mino/a b ~/x\n", outBuf.String())
+	})
+}
@@ -0,0 +1,150 @@
+package interpreter
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Names of the options enforced by the interpreter
+const (
+	OptErrExit  = "errexit"
+	OptNoUnset  = "nounset"
+	OptXTrace   = "xtrace"
+	OptPipeFail = "pipefail"
+	OptNoExec   = "noexec"
+	OptNoGlob   = "noglob"
+)
+
+type OptionKind int
+
+const (
+	// SetOption is changed with `set -o name` or its short flag
+	SetOption OptionKind = iota
+	// ShoptOption is changed with `shopt -s name`
+	ShoptOption
+)
+
+// Option is a named shell option. Options can be registered by anything
+// that wants to be configurable through `set` or `shopt`.
+type Option struct {
+	Name  string
+	Short byte
+	Kind  OptionKind
+	On    bool
+
+	// OnChange is called after the value of the option changed
+	OnChange func(on bool)
+}
+
+// Options is the registry of shell options.
+type Options struct {
+	mu   sync.RWMutex
+	opts map[string]*Option
+}
+
+func newOptions() *Options {
+	o := &Options{
+		opts: map[string]*Option{},
+	}
+	o.Register(&Option{Name: OptErrExit, Short: 'e'})
+	o.Register(&Option{Name: OptNoUnset, Short: 'u'})
+	o.Register(&Option{Name: OptXTrace, Short: 'x'})
+	o.Register(&Option{Name: OptNoExec, Short: 'n'})
+	o.Register(&Option{Name: OptNoGlob, Short: 'f'})
+	o.Register(&Option{Name: OptPipeFail})
+	return o
+}
+
+// Register adds opt to the registry, replacing any option with the same
+// name.
+func (o *Options) Register(opt *Option) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.opts[opt.Name] = opt
+}
+
+// Lookup returns the option with the given name.
+func (o *Options) Lookup(name string) (Option, bool) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	opt, ok := o.opts[name]
+	if !ok {
+		return Option{}, false
+	}
+	return *opt, true
+}
+
+// LookupShort returns the set option with the given short flag.
+func (o *Options) LookupShort(short byte) (Option, bool) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	for _, opt := range o.opts {
+		if opt.Short == short && opt.Kind == SetOption {
+			return *opt, true
+		}
+	}
+	return Option{}, false
+}
+
+// IsSet reports whether the option name is turned on.
+func (o *Options) IsSet(name string) bool {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	opt, ok := o.opts[name]
+	return ok && opt.On
+}
+
+// Set turns the option name on or off.
+func (o *Options) Set(name string, on bool) error {
+	o.mu.Lock()
+	opt, ok := o.opts[name]
+	if !ok {
+		o.mu.Unlock()
+		return fmt.Errorf("%s: invalid option name", name)
+	}
+	changed := opt.On != on
+	opt.On = on
+	onChange := opt.OnChange
+	o.mu.Unlock()
+
+	if changed && onChange != nil {
+		onChange(on)
+	}
+	return nil
+}
+
+// List returns the options of the given kind sorted by name.
+func (o *Options) List(kind OptionKind) []Option {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	opts := make([]Option, 0, len(o.opts))
+	for _, opt := range o.opts {
+		if opt.Kind == kind {
+			opts = append(opts, *opt)
+		}
+	}
+	slices.SortFunc(opts, func(a, b Option) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return opts
+}
+
+// Flags returns the short flags of the set options that are turned on,
+// as expanded by `$-`.
+func (o *Options) Flags() string {
+	flags := []byte{}
+	for _, opt := range o.List(SetOption) {
+		if opt.On && opt.Short != 0 {
+			flags = append(flags, opt.Short)
+		}
+	}
+	slices.Sort(flags)
+	return string(flags)
+}
+
+// Options returns the registry of shell options.
+func (p *Interpreter) Options() *Options {
+	return p.opts
+}
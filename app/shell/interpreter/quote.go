@@ -0,0 +1,60 @@
+package interpreter
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Quote quotes s so that the shell reads it back as a single word. Words
+// of safe characters are left as they are, words with control characters
+// are written as $'...' and any other word is put in single quotes.
+func Quote(s string) string {
+	if len(s) == 0 {
+		return "''"
+	}
+	if !strings.ContainsFunc(s, func(r rune) bool { return !isSafe(r) }) {
+		return s
+	}
+	if !strings.ContainsFunc(s, isControl) {
+		return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+	}
+
+	b := strings.Builder{}
+	b.WriteString("$'")
+	for _, r := range s {
+		switch r {
+		case '\n':
+			b.WriteString(`\n`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\\', '\'':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		default:
+			if isControl(r) {
+				fmt.Fprintf(&b, `\%03o`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('\'')
+	return b.String()
+}
+
+func isSafe(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return true
+	case r >= utf8.RuneSelf:
+		return true
+	}
+	return strings.ContainsRune("_@%+=:,./-", r)
+}
+
+func isControl(r rune) bool {
+	return r < 0x20 || r == 0x7f
+}
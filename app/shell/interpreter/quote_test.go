@@ -0,0 +1,32 @@
+package interpreter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuote(t *testing.T) {
+	tt := []struct {
+		in  string
+		out string
+	}{
+		{"", "''"},
+		{"plain-word_1.txt", "plain-word_1.txt"},
+		{"user@host:~/a,b+c=d%", "'user@host:~/a,b+c=d%'"},
+		{"a b", "'a b'"},
+		{"$HOME", "'$HOME'"},
+		{"~", "'~'"},
+		{"it's", `'it'\''s'`},
+		{"ünïcode", "ünïcode"},
+		{"a\nb", `$'a\nb'`},
+		{"tab\there's", `$'tab\there\'s'`},
+		{"\x01\x7f", `$'\001\177'`},
+	}
+
+	for _, test := range tt {
+		t.Run(test.in, func(t *testing.T) {
+			assert.Equal(t, test.out, Quote(test.in))
+		})
+	}
+}
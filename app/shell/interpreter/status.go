@@ -0,0 +1,65 @@
+package interpreter
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ExitStatus is returned by commands that ran to completion but
+// reported failure through a non-zero exit code. It is recorded as
+// `$?` and is not treated as an evaluation error.
+type ExitStatus int
+
+func (e ExitStatus) Error() string {
+	return fmt.Sprintf("exit status %d", int(e))
+}
+
+// ErrexitError is returned when a command fails while errexit is set,
+// which should terminate the shell.
+type ErrexitError struct {
+	Err error
+}
+
+func (e *ErrexitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ErrexitError) Unwrap() error {
+	return e.Err
+}
+
+// StatusOf maps the error returned by a command to its exit code.
+func StatusOf(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	var status ExitStatus
+	if errors.As(err, &status) {
+		return int(status)
+	}
+
+	if errors.Is(err, ErrCommandNotFound) {
+		return 127
+	}
+	return 1
+}
+
+// IsExitStatus reports whether err only carries a non-zero exit code.
+func IsExitStatus(err error) bool {
+	var status ExitStatus
+	return errors.As(err, &status)
+}
+
+// Status returns the exit code of the last evaluated command.
+func (p *Interpreter) Status() int {
+	p.statusMu.Lock()
+	defer p.statusMu.Unlock()
+	return p.status
+}
+
+func (p *Interpreter) setStatus(err error) {
+	p.statusMu.Lock()
+	defer p.statusMu.Unlock()
+	p.status = StatusOf(err)
+}
@@ -0,0 +1,58 @@
+package interpreter
+
+import (
+	"strings"
+
+	"github.com/codecrafters-io/shell-starter-go/app/shell/interpreter/ast"
+)
+
+// TildeFunc expands the tilde prefix of a word, the text between the
+// tilde and the first slash, e.g. "" for ~, "+" for ~+ or "2" for ~2. It
+// returns false to leave the word as is.
+type TildeFunc func(prefix string) (string, bool)
+
+func WithTildeFunc(f TildeFunc) interpreterOption {
+	return func(p *Interpreter) {
+		if f != nil {
+			p.tilde = f
+		}
+	}
+}
+
+// defaultTilde only expands ~ to $HOME
+func (p *Interpreter) defaultTilde(prefix string) (string, bool) {
+	if len(prefix) > 0 {
+		return "", false
+	}
+	return p.lookupParam("HOME")
+}
+
+// evalWord evaluates a command name, argument or redirect target,
+// expanding a leading unquoted tilde.
+func (p *Interpreter) evalWord(expr ast.Expression) (string, error) {
+	// only the unquoted text before the first slash may name the prefix
+	raw, ok := expr.(*ast.RawTextExpr)
+	if multi, isMulti := expr.(*ast.MultiTextExpr); isMulti && len(multi.Expressions) > 0 {
+		raw, ok = multi.Expressions[0].(*ast.RawTextExpr)
+		ok = ok && strings.ContainsRune(raw.Literal, '/')
+	}
+	if !ok || !strings.HasPrefix(raw.Literal, "~") {
+		return p.evalExpression(expr)
+	}
+
+	prefix, _, _ := strings.Cut(raw.Literal[1:], "/")
+	tilde := p.tilde
+	if tilde == nil {
+		tilde = p.defaultTilde
+	}
+	dir, ok := tilde(prefix)
+	if !ok {
+		return p.evalExpression(expr)
+	}
+
+	word, err := p.evalExpression(expr)
+	if err != nil {
+		return "", err
+	}
+	return dir + word[len(prefix)+1:], nil
+}
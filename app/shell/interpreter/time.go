@@ -0,0 +1,143 @@
+package interpreter
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codecrafters-io/shell-starter-go/app/shell/interpreter/ast"
+)
+
+const (
+	// defaultTimeFormat is used when TIMEFORMAT is not set
+	defaultTimeFormat = "\nreal\t%3lR\nuser\t%3lU\nsys\t%3lS"
+	posixTimeFormat   = "real %2R\nuser %2U\nsys %2S"
+)
+
+// Usage is the resource usage of the processes started by a command.
+type Usage struct {
+	User time.Duration
+	Sys  time.Duration
+	// MaxRSS is the largest resident set size in kilobytes
+	MaxRSS int64
+}
+
+type usageKey struct{}
+
+type usageCollector struct {
+	mu    sync.Mutex
+	usage Usage
+}
+
+func (c *usageCollector) add(u Usage) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.usage.User += u.User
+	c.usage.Sys += u.Sys
+	c.usage.MaxRSS = max(c.usage.MaxRSS, u.MaxRSS)
+}
+
+// ReportUsage records the resource usage of a process that was started
+// by a command. It is a no-op unless the command runs under `time`.
+func ReportUsage(ctx context.Context, u Usage) {
+	if ctx == nil {
+		return
+	}
+	if c, ok := ctx.Value(usageKey{}).(*usageCollector); ok {
+		c.add(u)
+	}
+}
+
+func (p *Interpreter) evalTime(ctx context.Context, n *ast.TimeStmt) error {
+	c := &usageCollector{}
+	start := time.Now()
+
+	var err error
+	if n.Stmt != nil {
+		err = p.eval(context.WithValue(ctx, usageKey{}, c), n.Stmt)
+	}
+	elapsed := time.Since(start)
+
+	format, ok := p.LookupVar("TIMEFORMAT")
+	if !ok {
+		format = defaultTimeFormat
+	}
+	if n.Posix {
+		format = posixTimeFormat
+	}
+
+	// an empty TIMEFORMAT disables the report
+	if len(format) > 0 {
+		_, _ = fmt.Fprintln(p.stderr, formatTime(format, elapsed, c.usage))
+	}
+	return err
+}
+
+// formatTime expands the TIMEFORMAT escapes %[p][l]R, %[p][l]U and
+// %[p][l]S for the real, user and system time, %P for the CPU percentage
+// and %M for the max resident set size in kilobytes.
+func formatTime(format string, elapsed time.Duration, u Usage) string {
+	b := strings.Builder{}
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			b.WriteByte(format[i])
+			continue
+		}
+
+		start := i
+		i++
+		if i >= len(format) {
+			b.WriteByte('%')
+			break
+		}
+
+		precision, long := 3, false
+		if d := format[i]; d >= '0' && d <= '9' {
+			precision = min(int(d-'0'), 3)
+			i++
+		}
+		if i < len(format) && format[i] == 'l' {
+			long = true
+			i++
+		}
+		if i >= len(format) {
+			b.WriteString(format[start:])
+			break
+		}
+
+		switch format[i] {
+		case '%':
+			b.WriteByte('%')
+		case 'R':
+			b.WriteString(formatSeconds(elapsed, precision, long))
+		case 'U':
+			b.WriteString(formatSeconds(u.User, precision, long))
+		case 'S':
+			b.WriteString(formatSeconds(u.Sys, precision, long))
+		case 'P':
+			percent := 0.0
+			if elapsed > 0 {
+				percent = float64(u.User+u.Sys) / float64(elapsed) * 100
+			}
+			b.WriteString(strconv.FormatFloat(percent, 'f', 2, 64))
+		case 'M':
+			b.WriteString(strconv.FormatInt(u.MaxRSS, 10))
+		default:
+			b.WriteString(format[start : i+1])
+		}
+	}
+	return b.String()
+}
+
+func formatSeconds(d time.Duration, precision int, long bool) string {
+	if !long {
+		return strconv.FormatFloat(d.Seconds(), 'f', precision, 64)
+	}
+
+	minutes := int(d / time.Minute)
+	seconds := (d % time.Minute).Seconds()
+	return fmt.Sprintf("%dm%.*fs", minutes, precision, seconds)
+}
@@ -0,0 +1,47 @@
+package interpreter
+
+// Trap is a condition raised by the interpreter itself rather than by
+// a signal, which the shell may attach commands to with `trap`.
+type Trap int
+
+const (
+	// TrapDebug is raised before every simple command or pipeline
+	TrapDebug Trap = iota
+	// TrapErr is raised after a statement finished with a non-zero
+	// exit status
+	TrapErr
+)
+
+type TrapFunc func(Trap)
+
+func WithTrapFunc(f TrapFunc) interpreterOption {
+	return func(p *Interpreter) {
+		p.trapFunc = f
+	}
+}
+
+func (p *Interpreter) trap(t Trap) {
+	if p.trapFunc == nil || p.inTrap {
+		return
+	}
+	p.trapFunc(t)
+}
+
+// EvaluateTrap evaluates the commands attached to a trap. Traps do not
+// nest and `$?` is preserved across them.
+func (p *Interpreter) EvaluateTrap(input string) error {
+	if p.inTrap {
+		return nil
+	}
+
+	status := p.Status()
+	p.inTrap = true
+	defer func() {
+		p.inTrap = false
+		p.statusMu.Lock()
+		p.status = status
+		p.statusMu.Unlock()
+	}()
+
+	return p.Evaluate(input)
+}
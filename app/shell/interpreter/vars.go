@@ -0,0 +1,145 @@
+package interpreter
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Variable is a shell variable. Scalars are stored as arrays with a
+// single element so that `$arr` and `${arr[0]}` behave the same.
+type Variable struct {
+	Values   []string
+	Exported bool
+}
+
+func (v *Variable) String() string {
+	if len(v.Values) == 0 {
+		return ""
+	}
+	return v.Values[0]
+}
+
+type variables struct {
+	mu   sync.RWMutex
+	vars map[string]*Variable
+}
+
+func newVariables() *variables {
+	return &variables{
+		vars: map[string]*Variable{},
+	}
+}
+
+func (v *variables) lookup(name string) (*Variable, bool) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	vr, ok := v.vars[name]
+	return vr, ok
+}
+
+func (v *variables) set(name string, values []string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if vr, ok := v.vars[name]; ok {
+		vr.Values = values
+		return
+	}
+	v.vars[name] = &Variable{Values: values}
+}
+
+func (v *variables) unset(name string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	delete(v.vars, name)
+}
+
+func (v *variables) names() []string {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	names := make([]string, 0, len(v.vars))
+	for name := range v.vars {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// VarNames returns the names of all shell variables in sorted order.
+func (p *Interpreter) VarNames() []string {
+	return p.vars.names()
+}
+
+// SetVar sets the shell variable name to the scalar value.
+func (p *Interpreter) SetVar(name, value string) {
+	p.vars.set(name, []string{value})
+}
+
+// SetArrayVar sets the shell variable name to an indexed array.
+func (p *Interpreter) SetArrayVar(name string, values []string) {
+	p.vars.set(name, values)
+}
+
+// UnsetVar removes the shell variable name.
+func (p *Interpreter) UnsetVar(name string) {
+	p.vars.unset(name)
+}
+
+// LookupVar returns the value of the shell variable name, falling back
+// to the environment when no shell variable is set.
+func (p *Interpreter) LookupVar(name string) (string, bool) {
+	if v, ok := p.vars.lookup(name); ok {
+		return v.String(), true
+	}
+
+	if val := p.getenv(name); len(val) > 0 {
+		return val, true
+	}
+	return "", false
+}
+
+// Var returns the value of the shell variable name or an empty string
+// if it is not set.
+func (p *Interpreter) Var(name string) string {
+	val, _ := p.LookupVar(name)
+	return val
+}
+
+// lookupParam resolves the name of a parameter expansion as passed by
+// os.Expand, handling special parameters and array subscripts.
+func (p *Interpreter) lookupParam(name string) (string, bool) {
+	switch name {
+	case "?":
+		return strconv.Itoa(p.Status()), true
+	case "-":
+		return p.opts.Flags(), true
+	case "@", "*":
+		return "", true
+	}
+
+	base, subscript, isArray := strings.Cut(name, "[")
+	if !isArray {
+		return p.LookupVar(name)
+	}
+
+	subscript, _ = strings.CutSuffix(subscript, "]")
+	v, ok := p.vars.lookup(base)
+	if !ok {
+		if subscript == "0" || subscript == "@" || subscript == "*" {
+			return p.LookupVar(base)
+		}
+		return "", false
+	}
+
+	switch subscript {
+	case "@", "*":
+		return strings.Join(v.Values, " "), true
+	}
+
+	idx, err := strconv.Atoi(subscript)
+	if err != nil || idx < 0 || idx >= len(v.Values) {
+		return "", false
+	}
+	return v.Values[idx], true
+}
@@ -0,0 +1,86 @@
+package jump
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type openFileFS interface {
+	OpenFile(string, int) (io.ReadWriteCloser, error)
+}
+
+// ReadFromFile adds the entries of filename to db. Each line of the file
+// holds the directory, its rank and the unix time of the last visit
+// separated by |, as written by z.
+func ReadFromFile(db *DB, fsys openFileFS, filename string) error {
+	file, err := fsys.OpenFile(filename, os.O_RDONLY)
+	if err != nil {
+		return fmt.Errorf("open file: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		e, ok := parseEntry(scanner.Text())
+		if !ok {
+			continue
+		}
+		db.entries[e.Dir] = &e
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("reading file: %w", err)
+	}
+	return nil
+}
+
+func WriteToFile(db *DB, fsys openFileFS, filename string) error {
+	file, err := fsys.OpenFile(filename, os.O_WRONLY|os.O_TRUNC|os.O_CREATE)
+	if err != nil {
+		return fmt.Errorf("open file: %w", err)
+	}
+	defer file.Close()
+
+	dirs := make([]string, 0, len(db.entries))
+	for dir := range db.entries {
+		dirs = append(dirs, dir)
+	}
+	slices.Sort(dirs)
+
+	w := bufio.NewWriter(file)
+	for _, dir := range dirs {
+		e := db.entries[dir]
+		_, _ = fmt.Fprintf(w, "%s|%s|%d\n", e.Dir, strconv.FormatFloat(e.Rank, 'g', -1, 64), e.Last.Unix())
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("file write: %w", err)
+	}
+	return nil
+}
+
+func parseEntry(line string) (Entry, bool) {
+	// directories may contain | so the fields are taken from the end
+	i := strings.LastIndexByte(line, '|')
+	if i < 0 {
+		return Entry{}, false
+	}
+	j := strings.LastIndexByte(line[:i], '|')
+	if j <= 0 {
+		return Entry{}, false
+	}
+
+	rank, err := strconv.ParseFloat(line[j+1:i], 64)
+	if err != nil {
+		return Entry{}, false
+	}
+	last, err := strconv.ParseInt(line[i+1:], 10, 64)
+	if err != nil {
+		return Entry{}, false
+	}
+	return Entry{Dir: line[:j], Rank: rank, Last: time.Unix(last, 0)}, true
+}
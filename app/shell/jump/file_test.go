@@ -0,0 +1,30 @@
+package jump
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/codecrafters-io/shell-starter-go/app/shell/fsys/fsystest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadWriteFile(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	fsys := fsystest.Buffer(buf)
+
+	now := time.Unix(1_700_000_000, 0)
+	db := NewDB()
+	db.Visit("/b", now)
+	db.Visit("/a|b", now)
+	db.Visit("/a|b", now)
+
+	require.NoError(t, WriteToFile(db, fsys, ""))
+	assert.Equal(t, "/a|b|2|1700000000\n/b|1|1700000000\n", buf.String())
+
+	buf.WriteString("corrupt\n")
+	read := NewDB()
+	require.NoError(t, ReadFromFile(read, fsys, ""))
+	assert.Equal(t, db.Query(nil, now), read.Query(nil, now))
+}
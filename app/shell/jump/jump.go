@@ -0,0 +1,128 @@
+package jump
+
+import (
+	"cmp"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// maxRank is the total rank above which every rank is aged, so that
+// directories that are no longer visited are eventually forgotten.
+const maxRank = 9000
+
+// Entry is a visited directory. Rank grows with every visit.
+type Entry struct {
+	Dir  string
+	Rank float64
+	Last time.Time
+}
+
+// Score weighs the rank of e by how recently it was visited.
+func (e Entry) Score(now time.Time) float64 {
+	switch age := now.Sub(e.Last); {
+	case age < time.Hour:
+		return e.Rank * 4
+	case age < 24*time.Hour:
+		return e.Rank * 2
+	case age < 7*24*time.Hour:
+		return e.Rank / 2
+	default:
+		return e.Rank / 4
+	}
+}
+
+// DB keeps the directories that were visited with their frecency.
+type DB struct {
+	entries map[string]*Entry
+}
+
+func NewDB() *DB {
+	return &DB{entries: map[string]*Entry{}}
+}
+
+// Visit records a visit of dir.
+func (db *DB) Visit(dir string, now time.Time) {
+	e, ok := db.entries[dir]
+	if !ok {
+		e = &Entry{Dir: dir}
+		db.entries[dir] = e
+	}
+	e.Rank++
+	e.Last = now
+	db.age()
+}
+
+func (db *DB) age() {
+	total := 0.0
+	for _, e := range db.entries {
+		total += e.Rank
+	}
+	if total <= maxRank {
+		return
+	}
+	for dir, e := range db.entries {
+		e.Rank *= 0.99
+		if e.Rank < 1 {
+			delete(db.entries, dir)
+		}
+	}
+}
+
+// Remove forgets dir.
+func (db *DB) Remove(dir string) {
+	delete(db.entries, dir)
+}
+
+func (db *DB) Len() int {
+	return len(db.entries)
+}
+
+// Query returns the directories matching terms, best first. Terms must
+// appear in order in the path and the last one in its last component.
+// Terms without upper case letters match regardless of case.
+func (db *DB) Query(terms []string, now time.Time) []Entry {
+	matches := make([]Entry, 0)
+	for _, e := range db.entries {
+		if matchTerms(e.Dir, terms) {
+			matches = append(matches, *e)
+		}
+	}
+
+	slices.SortFunc(matches, func(a, b Entry) int {
+		return cmp.Or(
+			cmp.Compare(b.Score(now), a.Score(now)),
+			strings.Compare(a.Dir, b.Dir),
+		)
+	})
+	return matches
+}
+
+func matchTerms(dir string, terms []string) bool {
+	if len(terms) == 0 {
+		return true
+	}
+
+	fold := func(s string) string { return s }
+	if !slices.ContainsFunc(terms, hasUpper) {
+		fold = strings.ToLower
+	}
+
+	path := fold(dir)
+	for _, term := range terms {
+		term = fold(term)
+		i := strings.Index(path, term)
+		if i < 0 {
+			return false
+		}
+		path = path[i+len(term):]
+	}
+
+	last := terms[len(terms)-1]
+	return strings.Contains(fold(filepath.Base(dir)), fold(last))
+}
+
+func hasUpper(s string) bool {
+	return strings.ToLower(s) != s
+}
@@ -0,0 +1,60 @@
+package jump
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuery(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	db := NewDB()
+	db.Visit("/home/mino/src/shell", now.Add(-48*time.Hour))
+	db.Visit("/home/mino/src/shell", now.Add(-48*time.Hour))
+	db.Visit("/home/mino/src/shell", now.Add(-48*time.Hour))
+	db.Visit("/home/mino/src/shell-docs", now.Add(-time.Minute))
+	db.Visit("/home/mino/Shelf", now.Add(-time.Minute))
+	db.Visit("/tmp/src", now)
+
+	dirs := func(entries []Entry) []string {
+		out := make([]string, 0, len(entries))
+		for _, e := range entries {
+			out = append(out, e.Dir)
+		}
+		return out
+	}
+
+	// the recent visit outweighs the older ones
+	assert.Equal(t, []string{
+		"/home/mino/src/shell-docs",
+		"/home/mino/src/shell",
+	}, dirs(db.Query([]string{"src", "shell"}, now)))
+
+	assert.Equal(t, []string{
+		"/home/mino/Shelf",
+		"/home/mino/src/shell-docs",
+		"/home/mino/src/shell",
+	}, dirs(db.Query([]string{"she"}, now)))
+
+	assert.Equal(t, []string{"/home/mino/Shelf"}, dirs(db.Query([]string{"She"}, now)))
+
+	// the last term has to match the last component
+	assert.Equal(t, []string{"/tmp/src"}, dirs(db.Query([]string{"src"}, now)))
+
+	db.Remove("/tmp/src")
+	assert.Empty(t, db.Query([]string{"src"}, now))
+}
+
+func TestAging(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	db := NewDB()
+	db.Visit("/rare", now)
+	for range maxRank {
+		db.Visit("/often", now)
+	}
+
+	assert.Equal(t, 1, db.Len())
+	entries := db.Query(nil, now)
+	assert.Less(t, entries[0].Rank, float64(maxRank))
+}
@@ -0,0 +1,145 @@
+package shell
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/codecrafters-io/shell-starter-go/app/cmd"
+	"github.com/codecrafters-io/shell-starter-go/app/shell/interpreter"
+	"github.com/codecrafters-io/shell-starter-go/app/shell/terminal"
+	"github.com/codecrafters-io/shell-starter-go/assert"
+)
+
+// maxSuggestions bounds the number of "did you mean" suggestions
+const maxSuggestions = 3
+
+// CommandNotFoundHandler is called with the IO and arguments of a command
+// that does not exist, like bash's command_not_found_handle. It returns
+// false when it leaves the command to the next handler.
+type CommandNotFoundHandler func(c *cmd.Command, args []string) (bool, error)
+
+// HandleCommandNotFound adds a handler for missing commands. Handlers run
+// in the order they were added until one handles the command.
+func (s *Shell) HandleCommandNotFound(h CommandNotFoundHandler) {
+	assert.NotNil(h)
+	s.notFoundHandlers = append(s.notFoundHandlers, h)
+}
+
+func (s *Shell) commandNotFound(ctx context.Context, stdin io.Reader, stdout, stderr io.Writer, args []string) error {
+	name := args[0]
+	if s.interp.Options().IsSet(OptAutoCD) && s.isDir(name) {
+		return s.runCommand(ctx, stdin, stdout, stderr, []string{"cd", name})
+	}
+
+	c := &cmd.Command{Name: name, Stdin: stdin, Stdout: stdout, Stderr: stderr}
+	for _, h := range s.notFoundHandlers {
+		if handled, err := h(c, args); handled {
+			return err
+		}
+	}
+
+	err := fmt.Errorf("%s: %w", name, interpreter.ErrCommandNotFound)
+
+	suggestions := s.suggestCommands(name)
+	if len(suggestions) == 0 {
+		return err
+	}
+
+	if len(suggestions) == 1 && s.interp.Options().IsSet(OptAutocorrect) && stdin == s.Stdin && s.tr != nil {
+		if s.confirmCorrection(name, suggestions[0]) {
+			return s.runCommand(ctx, stdin, stdout, stderr, append([]string{suggestions[0]}, args[1:]...))
+		}
+		return err
+	}
+
+	quoted := make([]string, len(suggestions))
+	for i, suggestion := range suggestions {
+		quoted[i] = "`" + suggestion + "'"
+	}
+	return fmt.Errorf("%w\ndid you mean %s?", err, strings.Join(quoted, " or "))
+}
+
+func (s *Shell) confirmCorrection(name, correction string) bool {
+	answer, err := s.tr.ReadLine(terminal.ReadLineOptions{
+		Prompt: fmt.Sprintf("correct '%s' to '%s' [ny]? ", name, correction),
+		NChars: 1,
+	})
+	_, _ = fmt.Fprintln(s.Stdout)
+	return err == nil && (answer == "y" || answer == "Y")
+}
+
+func (s *Shell) runCommand(ctx context.Context, stdin io.Reader, stdout, stderr io.Writer, args []string) error {
+	f, _, err := s.LookupCommand(args[0])
+	if err != nil {
+		return err
+	}
+	return f(ctx, stdin, stdout, stderr, args)
+}
+
+// suggestCommands returns the commands closest to name by edit distance,
+// ranked by the registry and so by how often they were used.
+func (s *Shell) suggestCommands(name string) []string {
+	maxDist := 1
+	if utf8.RuneCountInString(name) > 4 {
+		maxDist = 2
+	}
+
+	best := maxDist + 1
+	suggestions := make([]string, 0, maxSuggestions)
+	for _, m := range s.CommandRegistry.Match("", cmd.MatchOptions{}) {
+		if abs(utf8.RuneCountInString(m.Name)-utf8.RuneCountInString(name)) > maxDist {
+			continue
+		}
+		d := editDistance(name, m.Name)
+		if d == 0 || d > best {
+			continue
+		}
+		if d < best {
+			best = d
+			suggestions = suggestions[:0]
+		}
+		if len(suggestions) < maxSuggestions {
+			suggestions = append(suggestions, m.Name)
+		}
+	}
+	return slices.Clip(suggestions)
+}
+
+// editDistance is the optimal string alignment distance between a and b,
+// the Levenshtein distance counting swapped neighbours as one edit.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(rb)]
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,65 @@
+package shell
+
+import (
+	"testing"
+
+	"github.com/codecrafters-io/shell-starter-go/app/cmd"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEditDistance(t *testing.T) {
+	tt := []struct {
+		a, b string
+		dist int
+	}{
+		{"echo", "echo", 0},
+		{"", "ls", 2},
+		{"ech", "echo", 1},
+		{"echoo", "echo", 1},
+		{"ecko", "echo", 1},
+		{"ehco", "echo", 1},
+		{"sl", "ls", 1},
+		{"gti", "git", 1},
+		{"kitten", "sitting", 3},
+		{"naïve", "naive", 1},
+		{"日本", "本日", 1},
+	}
+
+	for _, test := range tt {
+		t.Run(test.a+"/"+test.b, func(t *testing.T) {
+			assert.Equal(t, test.dist, editDistance(test.a, test.b))
+			assert.Equal(t, test.dist, editDistance(test.b, test.a))
+		})
+	}
+}
+
+func TestSuggestCommands(t *testing.T) {
+	registry := cmd.NewResitry(nil)
+	for _, name := range []string{"cd", "echo", "exit", "export", "type", "pwd", "history", "ls"} {
+		registry.AddBuiltinCommand(name, nil)
+	}
+	s := &Shell{CommandRegistry: registry}
+
+	tt := []struct {
+		name        string
+		suggestions []string
+	}{
+		{"ehco", []string{"echo"}},
+		{"pdw", []string{"pwd"}},
+		{"exi", []string{"exit"}},
+		// names of over four characters allow two edits
+		{"histroyy", []string{"history"}},
+		// only the closest are kept
+		{"exprt", []string{"export"}},
+		{"ext", []string{"exit"}},
+		{"cs", []string{"cd", "ls"}},
+		{"xyz", []string{}},
+		{"echo", []string{}},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.suggestions, s.suggestCommands(test.name))
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package shell
+
+import "github.com/codecrafters-io/shell-starter-go/assert"
+
+// PasteHandler is called with text pasted into the line before it is
+// inserted. It returns the text to insert, e.g. with control characters
+// removed, or false to drop the paste.
+type PasteHandler func(text string) (string, bool)
+
+// HandlePaste adds a handler for pasted text. Handlers run in the order
+// they were added, each on the text the previous one returned.
+func (s *Shell) HandlePaste(h PasteHandler) {
+	assert.NotNil(h)
+	s.pasteHandlers = append(s.pasteHandlers, h)
+}
+
+func (s *Shell) paste(text string) {
+	for _, h := range s.pasteHandlers {
+		var ok bool
+		if text, ok = h(text); !ok {
+			s.tr.Refresh()
+			return
+		}
+	}
+	if len(s.pasteHandlers) > 0 {
+		// handlers may have prompted below the line
+		s.tr.Refresh()
+	}
+	s.tr.Insert(text)
+}
@@ -0,0 +1,14 @@
+package shell
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPasteMultipleLines(t *testing.T) {
+	out := runShell(t, "\x1b[200~echo a\necho b\x1b[201~\r", nil)
+
+	// each pasted line runs as its own command
+	assert.Contains(t, out, "echo a\necho b\na\nb\n")
+}
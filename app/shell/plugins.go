@@ -11,6 +11,10 @@ func NewPluginsCommandFunc(s *Shell) cmd.CommandFunc {
 	return func() *cmd.Command {
 		return &cmd.Command{
 			Name: "plugins",
+			Spec: &cmd.Spec{
+				Synopsis: "plugins",
+				Short:    "List the loaded plugins.",
+			},
 			Run: func(cmd *cmd.Command, args []string) error {
 				assert.Assert(len(args) > 0)
 				s.tr.Writer().StagePushForegroundColor(terminal.Rose)
@@ -0,0 +1,247 @@
+package shell
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/codecrafters-io/shell-starter-go/app/cmd"
+	"github.com/codecrafters-io/shell-starter-go/app/shell/interpreter"
+	"github.com/codecrafters-io/shell-starter-go/assert"
+)
+
+func NewPrintfCommandFunc(s *Shell) cmd.CommandFunc {
+	assert.NotNil(s, "shell")
+	return func() *cmd.Command {
+		return &cmd.Command{
+			Name: "printf",
+			Spec: &cmd.Spec{
+				Synopsis: "printf [-v var] format [arguments]",
+				Short:    "Formats and prints ARGUMENTS under control of the FORMAT.",
+				Flags: []cmd.Flag{
+					{Name: 'v', Arg: "var", Usage: "assign the output to the shell variable VAR"},
+				},
+			},
+			Run: func(cmd *cmd.Command, args []string) error {
+				assert.Assert(len(args) > 0)
+
+				flags, rest, err := cmd.Spec.Parse(args[1:])
+				if err != nil {
+					return fmt.Errorf("printf: %w", err)
+				}
+				if len(rest) == 0 {
+					return errors.New(cmd.Spec.Usage())
+				}
+
+				varName, toVar := flags['v']
+				if toVar && !isValidName(varName) {
+					return fmt.Errorf("printf: `%s': not a valid identifier", varName)
+				}
+
+				out, errs := sprintf(rest[0], rest[1:])
+				for _, err := range errs {
+					_, _ = fmt.Fprintf(cmd.Stderr, "printf: %s\n", err)
+				}
+
+				if toVar {
+					s.interp.SetVar(varName, out)
+				} else if _, err := io.WriteString(cmd.Stdout, out); err != nil {
+					return err
+				}
+
+				if len(errs) > 0 {
+					return interpreter.ExitStatus(1)
+				}
+				return nil
+			},
+		}
+	}
+}
+
+var errStopOutput = errors.New("stop output")
+
+// sprintf formats args according to format the way the printf builtin
+// does. The format is reused until all arguments are consumed. Arguments
+// that fail to convert are reported in the returned errors and formatted
+// as zero.
+func sprintf(format string, args []string) (string, []error) {
+	f := &printfState{args: args}
+	for {
+		consumed := f.argIdx
+		if err := f.format(format); err != nil {
+			break
+		}
+		if f.argIdx >= len(f.args) || f.argIdx == consumed {
+			break
+		}
+	}
+	return f.out.String(), f.errs
+}
+
+type printfState struct {
+	out    strings.Builder
+	args   []string
+	argIdx int
+	errs   []error
+}
+
+func (f *printfState) nextArg() (string, bool) {
+	if f.argIdx >= len(f.args) {
+		return "", false
+	}
+	arg := f.args[f.argIdx]
+	f.argIdx++
+	return arg, true
+}
+
+func (f *printfState) format(format string) error {
+	for i := 0; i < len(format); i++ {
+		c := format[i]
+		switch {
+		case c == '\\':
+			expanded, n, stop := parseEscape(format[i:], false)
+			f.out.WriteString(expanded)
+			if stop {
+				return errStopOutput
+			}
+			i += n - 1
+		case c == '%':
+			n, err := f.directive(format[i+1:])
+			if err != nil {
+				return err
+			}
+			i += n
+		default:
+			f.out.WriteByte(c)
+		}
+	}
+	return nil
+}
+
+// directive formats a single conversion specification. spec holds the
+// format following the '%' and the number of bytes consumed from it is
+// returned.
+func (f *printfState) directive(spec string) (int, error) {
+	i := 0
+	for i < len(spec) && strings.IndexByte("-+ #0", spec[i]) >= 0 {
+		i++
+	}
+	flags := spec[:i]
+
+	width, n := f.specNumber(spec[i:])
+	i += n
+
+	precision := ""
+	if i < len(spec) && spec[i] == '.' {
+		i++
+		p, n := f.specNumber(spec[i:])
+		i += n
+		if len(p) == 0 {
+			p = "0"
+		}
+		precision = "." + p
+	}
+
+	if i >= len(spec) {
+		f.out.WriteString("%" + spec)
+		return len(spec), nil
+	}
+
+	verb := spec[i]
+	i++
+	goFmt := "%" + flags + width + precision
+
+	switch verb {
+	case '%':
+		f.out.WriteByte('%')
+	case 's':
+		arg, _ := f.nextArg()
+		fmt.Fprintf(&f.out, goFmt+"s", arg)
+	case 'b':
+		arg, _ := f.nextArg()
+		expanded, stop := expandEscapes(arg, true)
+		fmt.Fprintf(&f.out, goFmt+"s", expanded)
+		if stop {
+			return i, errStopOutput
+		}
+	case 'q':
+		arg, _ := f.nextArg()
+		fmt.Fprintf(&f.out, goFmt+"s", interpreter.Quote(arg))
+	case 'c':
+		arg, _ := f.nextArg()
+		r, _ := utf8.DecodeRuneInString(arg)
+		if len(arg) == 0 {
+			break
+		}
+		fmt.Fprintf(&f.out, "%"+flags+width+"c", r)
+	case 'd', 'i':
+		fmt.Fprintf(&f.out, goFmt+"d", f.intArg())
+	case 'u':
+		fmt.Fprintf(&f.out, goFmt+"d", uint64(f.intArg()))
+	case 'x', 'X', 'o':
+		fmt.Fprintf(&f.out, goFmt+string(verb), uint64(f.intArg()))
+	case 'f', 'F', 'e', 'E', 'g', 'G':
+		fmt.Fprintf(&f.out, goFmt+string(verb), f.floatArg())
+	default:
+		f.errs = append(f.errs, fmt.Errorf("%%%c: invalid format character", verb))
+		return i, errStopOutput
+	}
+
+	return i, nil
+}
+
+// specNumber reads a width or precision, which is either a run of digits
+// or '*' to take it from the next argument.
+func (f *printfState) specNumber(spec string) (string, int) {
+	if len(spec) > 0 && spec[0] == '*' {
+		return strconv.FormatInt(f.intArg(), 10), 1
+	}
+
+	n := 0
+	for n < len(spec) && spec[n] >= '0' && spec[n] <= '9' {
+		n++
+	}
+	return spec[:n], n
+}
+
+func (f *printfState) intArg() int64 {
+	arg, ok := f.nextArg()
+	if !ok {
+		return 0
+	}
+
+	if len(arg) > 1 && (arg[0] == '\'' || arg[0] == '"') {
+		r, _ := utf8.DecodeRuneInString(arg[1:])
+		return int64(r)
+	}
+
+	val, err := strconv.ParseInt(strings.TrimSpace(arg), 0, 64)
+	if err != nil {
+		if uval, uerr := strconv.ParseUint(strings.TrimSpace(arg), 0, 64); uerr == nil {
+			return int64(uval)
+		}
+		f.errs = append(f.errs, fmt.Errorf("%s: invalid number", arg))
+	}
+	return val
+}
+
+func (f *printfState) floatArg() float64 {
+	arg, ok := f.nextArg()
+	if !ok {
+		return 0
+	}
+
+	if len(arg) > 1 && (arg[0] == '\'' || arg[0] == '"') {
+		r, _ := utf8.DecodeRuneInString(arg[1:])
+		return float64(r)
+	}
+
+	val, err := strconv.ParseFloat(strings.TrimSpace(arg), 64)
+	if err != nil {
+		f.errs = append(f.errs, fmt.Errorf("%s: invalid number", arg))
+	}
+	return val
+}
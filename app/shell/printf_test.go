@@ -0,0 +1,73 @@
+package shell
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSprintf(t *testing.T) {
+	tt := []struct {
+		name   string
+		format string
+		args   []string
+		out    string
+		errs   int
+	}{
+		{"plain", `hello\n`, nil, "hello\n", 0},
+		{"strings", `%s-%5s|%-3s|`, []string{"a", "b", "c"}, "a-    b|c  |", 0},
+		{"format reused", `%s=%d `, []string{"a", "1", "b", "2"}, "a=1 b=2 ", 0},
+		{"missing arguments", `%s|%d|`, nil, "|0|", 0},
+		{"numbers", `%x %o %05.1f %+d`, []string{"255", "8", "3.14159", "7"}, "ff 10 003.1 +7", 0},
+		{"char", `%c%c`, []string{"hello", ""}, "h", 0},
+		{"star width", `%*d|`, []string{"4", "2"}, "   2|", 0},
+		{"percent", `100%%`, nil, "100%", 0},
+		{"invalid number", `%d`, []string{"x"}, "0", 1},
+		{"invalid verb", `a%zb`, nil, "a", 1},
+		{"octal escape", `\101\0102`, nil, "A\x082", 0},
+		{"\\c in format", `a\cb%s`, []string{"x", "y"}, "a", 0},
+		{"%b escapes", `%b|`, []string{`a\tb\0101`}, "a\tbA|", 0},
+		{"%b stops at \\c", `%b|%s`, []string{`a\cb`, "x"}, "a", 0},
+		{"%q", `%q %q`, []string{"a b", "it's"}, `'a b' 'it'\''s'`, 0},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			out, errs := sprintf(test.format, test.args)
+			assert.Equal(t, test.out, out)
+			assert.Len(t, errs, test.errs)
+		})
+	}
+}
+
+func TestExpandEscapes(t *testing.T) {
+	tt := []struct {
+		name      string
+		in        string
+		echoStyle bool
+		out       string
+		stop      bool
+	}{
+		{"no escapes", "plain", false, "plain", false},
+		{"controls", `\a\b\e\f\n\r\t\v\\`, false, "\a\b\x1b\f\n\r\t\v\\", false},
+		{"hex", `\x41\x4g`, false, "A\x04g", false},
+		{"unicode", `é\U0001F600`, false, "é😀", false},
+		{"invalid unicode", `\UFFFFFFFF`, false, `\UFFFFFFFF`, false},
+		{"printf octal", `\101\1`, false, "A\x01", false},
+		{"echo octal", `\0101\101`, true, `A\101`, false},
+		{"quotes", `\"\'`, false, `"'`, false},
+		{"echo keeps quotes escaped", `\"`, true, `\"`, false},
+		{"unknown kept", `\q`, false, `\q`, false},
+		{"trailing backslash", `a\`, false, `a\`, false},
+		{"stop", `a\cb`, true, "a", true},
+		{"stop in printf style", `a\cb`, false, "a", true},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			out, stop := expandEscapes(test.in, test.echoStyle)
+			assert.Equal(t, test.out, out)
+			assert.Equal(t, test.stop, stop)
+		})
+	}
+}
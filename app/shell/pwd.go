@@ -10,6 +10,10 @@ func NewPWDCommandFunc(s *Shell) cmd.CommandFunc {
 	return func() *cmd.Command {
 		return &cmd.Command{
 			Name: "pwd",
+			Spec: &cmd.Spec{
+				Synopsis: "pwd",
+				Short:    "Print the name of the current working directory.",
+			},
 			Run: func(cmd *cmd.Command, args []string) error {
 				_, err := fmt.Fprintln(cmd.Stdout, s.WorkingDir)
 				return err
@@ -0,0 +1,31 @@
+package shell
+
+import (
+	"sync"
+
+	"github.com/codecrafters-io/shell-starter-go/app/shell/history"
+	"golang.org/x/term"
+)
+
+// commandRanker ranks command names by their frecency in the history. The
+// scores are recomputed whenever the history grew or shrank.
+type commandRanker struct {
+	mu      sync.Mutex
+	history term.History
+	len     int
+	scores  map[string]float64
+}
+
+func newCommandRanker(h term.History) *commandRanker {
+	return &commandRanker{history: h, len: -1}
+}
+
+func (c *commandRanker) rank(name string) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if n := c.history.Len(); n != c.len {
+		c.scores = history.CommandFrecency(c.history)
+		c.len = n
+	}
+	return c.scores[name]
+}
@@ -0,0 +1,342 @@
+package shell
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/codecrafters-io/shell-starter-go/app/cmd"
+	"github.com/codecrafters-io/shell-starter-go/app/shell/interpreter"
+	"github.com/codecrafters-io/shell-starter-go/app/shell/terminal"
+	"github.com/codecrafters-io/shell-starter-go/assert"
+)
+
+const defaultIFS = " \t\n"
+
+type readOptions struct {
+	prompt  string
+	array   string
+	delim   byte
+	nchars  int
+	timeout time.Duration
+	raw     bool
+	silent  bool
+}
+
+func NewReadCommandFunc(s *Shell) cmd.CommandFunc {
+	assert.NotNil(s, "shell")
+	return func() *cmd.Command {
+		return &cmd.Command{
+			Name: "read",
+			Spec: &cmd.Spec{
+				Synopsis: "read [-rs] [-a array] [-d delim] [-n nchars] [-p prompt] [-t timeout] [name ...]",
+				Short:    "Read a line from the standard input and split it into fields.",
+				Long:     "The fields are assigned to the NAMEs, the last one getting the rest of the line.\nWithout NAMEs the line is stored in REPLY.",
+				Flags: []cmd.Flag{
+					{Name: 'a', Arg: "array", Usage: "assign the words to the indices of ARRAY"},
+					{Name: 'd', Arg: "delim", Usage: "read until the first character of DELIM"},
+					{Name: 'n', Arg: "nchars", Usage: "return after reading NCHARS characters"},
+					{Name: 'p', Arg: "prompt", Usage: "output PROMPT before reading"},
+					{Name: 'r', Usage: "do not treat backslashes as escapes"},
+					{Name: 's', Usage: "do not echo input coming from a terminal"},
+					{Name: 't', Arg: "timeout", Usage: "fail if no line was read within TIMEOUT seconds"},
+				},
+			},
+			Run: func(cmd *cmd.Command, args []string) error {
+				assert.Assert(len(args) > 0)
+
+				opts, names, err := parseReadOptions(cmd.Spec, args[1:])
+				if err != nil {
+					return fmt.Errorf("read: %w", err)
+				}
+				for _, name := range append([]string{opts.array}, names...) {
+					if len(name) > 0 && !isValidName(name) {
+						return fmt.Errorf("read: `%s': not a valid identifier", name)
+					}
+				}
+
+				var line string
+				if cmd.Stdin == s.Stdin && s.tr != nil {
+					line, err = s.readFromTerminal(opts)
+				} else {
+					line, err = readFromReader(cmd.Stdin, opts)
+				}
+
+				switch {
+				case errors.Is(err, terminal.ErrInterrupted):
+					return interpreter.ExitStatus(130)
+				case errors.Is(err, terminal.ErrReadTimeout):
+					return interpreter.ExitStatus(142)
+				case err != nil && !errors.Is(err, io.EOF):
+					return fmt.Errorf("read: %w", err)
+				}
+
+				s.assignRead(line, names, opts)
+
+				if errors.Is(err, io.EOF) {
+					return interpreter.ExitStatus(1)
+				}
+				return nil
+			},
+		}
+	}
+}
+
+func parseReadOptions(spec *cmd.Spec, args []string) (*readOptions, []string, error) {
+	flags, names, err := spec.Parse(args)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	opts := &readOptions{
+		delim: '\n',
+	}
+	opts.prompt = flags['p']
+	opts.array = flags['a']
+	_, opts.raw = flags['r']
+	_, opts.silent = flags['s']
+
+	if d, ok := flags['d']; ok {
+		opts.delim = 0
+		if len(d) > 0 {
+			opts.delim = d[0]
+		}
+	}
+
+	if n, ok := flags['n']; ok {
+		opts.nchars, err = strconv.Atoi(n)
+		if err != nil || opts.nchars < 0 {
+			return nil, nil, fmt.Errorf("%s: invalid number", n)
+		}
+	}
+
+	if t, ok := flags['t']; ok {
+		secs, err := strconv.ParseFloat(t, 64)
+		if err != nil || secs < 0 {
+			return nil, nil, fmt.Errorf("%s: invalid timeout specification", t)
+		}
+		opts.timeout = time.Duration(secs * float64(time.Second))
+	}
+
+	return opts, names, nil
+}
+
+func (s *Shell) readFromTerminal(opts *readOptions) (string, error) {
+	topts := terminal.ReadLineOptions{
+		Prompt:   opts.prompt,
+		Silent:   opts.silent,
+		Delim:    rune(opts.delim),
+		HasDelim: true,
+		NChars:   opts.nchars,
+		Timeout:  opts.timeout,
+	}
+
+	b := strings.Builder{}
+	for {
+		line, err := s.tr.ReadLine(topts)
+		b.WriteString(line)
+		if err != nil || opts.raw || !hasLineContinuation(line) {
+			return b.String(), err
+		}
+
+		topts.Prompt = ""
+		b.WriteByte('\n')
+	}
+}
+
+func readFromReader(r io.Reader, opts *readOptions) (string, error) {
+	read := func() (string, error) {
+		buf := make([]byte, 0, 64)
+		b := make([]byte, 1)
+		for {
+			if opts.nchars > 0 && utf8.RuneCount(buf) >= opts.nchars {
+				return string(buf), nil
+			}
+
+			n, err := r.Read(b)
+			if n > 0 {
+				if b[0] == opts.delim {
+					if !opts.raw && hasLineContinuation(string(buf)) {
+						buf = append(buf, b[0])
+						continue
+					}
+					return string(buf), nil
+				}
+				buf = append(buf, b[0])
+			}
+			if err != nil {
+				return string(buf), err
+			}
+		}
+	}
+
+	if opts.timeout <= 0 {
+		return read()
+	}
+
+	// pipes and terminals opened as files can be given a deadline,
+	// which leaves nothing waiting on them after a timeout
+	if f, ok := r.(interface{ SetReadDeadline(time.Time) error }); ok {
+		if err := f.SetReadDeadline(time.Now().Add(opts.timeout)); err == nil {
+			defer f.SetReadDeadline(time.Time{})
+			line, err := read()
+			if errors.Is(err, os.ErrDeadlineExceeded) {
+				return "", terminal.ErrReadTimeout
+			}
+			return line, err
+		}
+	}
+
+	// other readers are read from a goroutine, which is left blocked on
+	// the reader after a timeout and drops whatever it reads later. For
+	// a pipe between commands that is only until the command ends, as
+	// the interpreter then closes the pipe. Any other reader keeps it
+	// blocked until input arrives or the reader is closed.
+	type result struct {
+		line string
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		line, err := read()
+		done <- result{line, err}
+	}()
+
+	select {
+	case res := <-done:
+		return res.line, res.err
+	case <-time.After(opts.timeout):
+		return "", terminal.ErrReadTimeout
+	}
+}
+
+func (s *Shell) assignRead(line string, names []string, opts *readOptions) {
+	ifs, ok := s.interp.LookupVar("IFS")
+	if !ok {
+		ifs = defaultIFS
+	}
+
+	if len(opts.array) > 0 {
+		s.interp.SetArrayVar(opts.array, splitFields(line, ifs, 0, opts.raw))
+		return
+	}
+
+	if len(names) == 0 {
+		fields := splitFields(line, "", 1, opts.raw)
+		s.interp.SetVar("REPLY", strings.Join(fields, ""))
+		return
+	}
+
+	fields := splitFields(line, ifs, len(names), opts.raw)
+	for i, name := range names {
+		val := ""
+		if i < len(fields) {
+			val = fields[i]
+		}
+		s.interp.SetVar(name, val)
+	}
+}
+
+// hasLineContinuation reports whether line ends in an unescaped
+// backslash.
+func hasLineContinuation(line string) bool {
+	n := len(line) - len(strings.TrimRight(line, `\`))
+	return n%2 == 1
+}
+
+type readChar struct {
+	r       rune
+	escaped bool
+}
+
+// splitFields splits line into at most n fields following the IFS rules
+// of `read`. The last field receives the remainder of the line. Unless
+// raw is set, backslashes escape the following character and are removed
+// along with escaped newlines.
+func splitFields(line, ifs string, n int, raw bool) []string {
+	chars := make([]readChar, 0, len(line))
+	escaped := false
+	for _, r := range line {
+		switch {
+		case escaped:
+			escaped = false
+			if r != '\n' {
+				chars = append(chars, readChar{r, true})
+			}
+		case r == '\\' && !raw:
+			escaped = true
+		default:
+			chars = append(chars, readChar{r, false})
+		}
+	}
+
+	isIFS := func(c readChar) bool {
+		return !c.escaped && strings.ContainsRune(ifs, c.r)
+	}
+	isIFSSpace := func(c readChar) bool {
+		return isIFS(c) && strings.ContainsRune(defaultIFS, c.r)
+	}
+
+	start, end := 0, len(chars)
+	for start < end && isIFSSpace(chars[start]) {
+		start++
+	}
+	for end > start && isIFSSpace(chars[end-1]) {
+		end--
+	}
+	chars = chars[start:end]
+
+	str := func(cs []readChar) string {
+		b := strings.Builder{}
+		for _, c := range cs {
+			b.WriteRune(c.r)
+		}
+		return b.String()
+	}
+
+	fields := make([]string, 0)
+	for i := 0; i < len(chars); {
+		if n > 0 && len(fields) == n-1 {
+			fields = append(fields, str(chars[i:]))
+			break
+		}
+
+		start := i
+		for i < len(chars) && !isIFS(chars[i]) {
+			i++
+		}
+		fields = append(fields, str(chars[start:i]))
+
+		for i < len(chars) && isIFSSpace(chars[i]) {
+			i++
+		}
+		if i < len(chars) && isIFS(chars[i]) && !isIFSSpace(chars[i]) {
+			i++
+			for i < len(chars) && isIFSSpace(chars[i]) {
+				i++
+			}
+		}
+	}
+
+	return fields
+}
+
+func isValidName(name string) bool {
+	if len(name) == 0 {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r == '_', r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		case i > 0 && r >= '0' && r <= '9':
+		default:
+			return false
+		}
+	}
+	return true
+}
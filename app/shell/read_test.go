@@ -0,0 +1,112 @@
+package shell
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/codecrafters-io/shell-starter-go/app/shell/terminal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReadDelim(t *testing.T) {
+	spec := NewReadCommandFunc(&Shell{})().Spec
+	tt := []struct {
+		args  []string
+		delim byte
+	}{
+		{nil, '\n'},
+		{[]string{"-d", ";"}, ';'},
+		{[]string{"-d", ":,"}, ':'},
+		{[]string{"-d", ""}, 0},
+	}
+
+	for _, test := range tt {
+		opts, _, err := parseReadOptions(spec, test.args)
+		require.NoError(t, err)
+		assert.Equal(t, test.delim, opts.delim)
+	}
+}
+
+func TestReadFromReader(t *testing.T) {
+	tt := []struct {
+		name  string
+		input string
+		opts  readOptions
+		line  string
+		err   error
+	}{
+		{"line", "one\ntwo\n", readOptions{delim: '\n'}, "one", nil},
+		{"continuation", "one\\\ntwo\n", readOptions{delim: '\n'}, "one\\\ntwo", nil},
+		{"raw", "one\\\ntwo\n", readOptions{delim: '\n', raw: true}, "one\\", nil},
+		{"NUL delimiter", "one\ntwo\x00three", readOptions{delim: 0}, "one\ntwo", nil},
+		{"nchars", "hello", readOptions{delim: '\n', nchars: 2}, "he", nil},
+		{"end of input", "one", readOptions{delim: '\n'}, "one", io.EOF},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			line, err := readFromReader(strings.NewReader(test.input), &test.opts)
+			assert.Equal(t, test.line, line)
+			assert.Equal(t, test.err, err)
+		})
+	}
+}
+
+func TestReadFromReaderTimeout(t *testing.T) {
+	pr, pw, err := os.Pipe()
+	require.NoError(t, err)
+	defer pr.Close()
+	defer pw.Close()
+	if err := pr.SetReadDeadline(time.Time{}); err != nil {
+		t.Skip("pipes have no deadlines on this platform")
+	}
+
+	opts := &readOptions{delim: '\n', timeout: 10 * time.Millisecond}
+	_, err = readFromReader(pr, opts)
+	assert.ErrorIs(t, err, terminal.ErrReadTimeout)
+
+	// nothing is left reading the pipe after the timeout
+	_, err = pw.WriteString("line\n")
+	require.NoError(t, err)
+	opts.timeout = time.Second
+	line, err := readFromReader(pr, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, "line", line)
+}
+
+func TestReadFromPipeTimeout(t *testing.T) {
+	// each bubble only ends once the goroutine left reading is gone
+	t.Run("closed", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			pr, _ := io.Pipe()
+			_, err := readFromReader(pr, &readOptions{delim: '\n', timeout: time.Second})
+			assert.ErrorIs(t, err, terminal.ErrReadTimeout)
+
+			// as the interpreter does for pipes once the command ends
+			require.NoError(t, pr.Close())
+		})
+	})
+
+	t.Run("written", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			pr, pw := io.Pipe()
+			opts := &readOptions{delim: '\n', timeout: time.Second}
+			_, err := readFromReader(pr, opts)
+			assert.ErrorIs(t, err, terminal.ErrReadTimeout)
+
+			// the line written next goes to the goroutine and is lost
+			_, err = pw.Write([]byte("lost\n"))
+			require.NoError(t, err)
+			require.NoError(t, pw.Close())
+
+			line, err := readFromReader(pr, opts)
+			assert.Equal(t, "", line)
+			assert.ErrorIs(t, err, io.EOF)
+		})
+	})
+}
@@ -0,0 +1,115 @@
+package shell
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/codecrafters-io/shell-starter-go/app/cmd"
+	"github.com/codecrafters-io/shell-starter-go/app/shell/interpreter"
+	"github.com/codecrafters-io/shell-starter-go/assert"
+)
+
+func NewSetCommandFunc(s *Shell) cmd.CommandFunc {
+	assert.NotNil(s, "shell")
+	return func() *cmd.Command {
+		return &cmd.Command{
+			Name: "set",
+			Spec: &cmd.Spec{
+				Synopsis: "set [-efnux] [-o option-name] [--]",
+				Short:    "Set or unset values of shell options.",
+				Long:     "Using + rather than - turns the options off. Without arguments the\nshell variables are listed.",
+				Flags: []cmd.Flag{
+					{Name: 'e', Usage: "exit immediately if a command exits with a non-zero status"},
+					{Name: 'f', Usage: "disable file name generation"},
+					{Name: 'n', Usage: "read commands but do not execute them"},
+					{Name: 'u', Usage: "treat unset variables as an error"},
+					{Name: 'x', Usage: "print commands as they are executed"},
+					{Name: 'o', Arg: "option-name", Usage: "set the option called OPTION-NAME"},
+				},
+			},
+			Run: func(cmd *cmd.Command, args []string) error {
+				assert.Assert(len(args) > 0)
+
+				opts := s.interp.Options()
+				if len(args) == 1 {
+					for _, name := range s.interp.VarNames() {
+						_, _ = fmt.Fprintf(cmd.Stdout, "%s=%s\n", name, interpreter.Quote(s.interp.Var(name)))
+					}
+					return nil
+				}
+
+				rest := args[1:]
+				for len(rest) > 0 {
+					arg := rest[0]
+					if arg == "--" || arg == "-" {
+						rest = rest[1:]
+						break
+					}
+					if len(arg) < 2 || (arg[0] != '-' && arg[0] != '+') {
+						break
+					}
+					rest = rest[1:]
+
+					on := arg[0] == '-'
+					for _, c := range []byte(arg[1:]) {
+						if c == 'o' {
+							if len(rest) == 0 {
+								printSetOptions(cmd.Stdout, opts, on)
+								continue
+							}
+							name := rest[0]
+							rest = rest[1:]
+							if err := setOption(opts, name, on, interpreter.SetOption); err != nil {
+								return fmt.Errorf("set: %w", err)
+							}
+							continue
+						}
+
+						opt, ok := opts.LookupShort(c)
+						if !ok {
+							return fmt.Errorf("set: %c%c: invalid option", arg[0], c)
+						}
+						_ = opts.Set(opt.Name, on)
+					}
+				}
+
+				if len(rest) > 0 {
+					return fmt.Errorf("set: positional parameters are not supported")
+				}
+				return nil
+			},
+		}
+	}
+}
+
+func setOption(opts *interpreter.Options, name string, on bool, kind interpreter.OptionKind) error {
+	opt, ok := opts.Lookup(name)
+	if !ok || opt.Kind != kind {
+		return fmt.Errorf("%s: invalid option name", name)
+	}
+	return opts.Set(name, on)
+}
+
+// printSetOptions lists the set options either as a table or, for
+// `set +o`, as the commands that would recreate them.
+func printSetOptions(w io.Writer, opts *interpreter.Options, table bool) {
+	for _, opt := range opts.List(interpreter.SetOption) {
+		if table {
+			_, _ = fmt.Fprintf(w, "%-15s\t%s\n", opt.Name, onOff(opt.On))
+			continue
+		}
+
+		flag := '+'
+		if opt.On {
+			flag = '-'
+		}
+		_, _ = fmt.Fprintf(w, "set %co %s\n", flag, opt.Name)
+	}
+}
+
+func onOff(on bool) string {
+	if on {
+		return "on"
+	}
+	return "off"
+}
@@ -7,11 +7,14 @@ import (
 	"io"
 	"io/fs"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/codecrafters-io/shell-starter-go/app/cmd"
+	"github.com/codecrafters-io/shell-starter-go/app/shell/envrc"
 	"github.com/codecrafters-io/shell-starter-go/app/shell/history"
 	"github.com/codecrafters-io/shell-starter-go/app/shell/interpreter"
+	"github.com/codecrafters-io/shell-starter-go/app/shell/jump"
 	"github.com/codecrafters-io/shell-starter-go/app/shell/terminal"
 	"github.com/codecrafters-io/shell-starter-go/assert"
 )
@@ -24,11 +27,15 @@ var (
 
 type env interface {
 	Get(string) string
+	Lookup(string) (string, bool)
+	Set(key, value string) error
+	Unset(string) error
 }
 
 type FS interface {
 	fs.ReadDirFS
 	OpenFile(string, int) (io.ReadWriteCloser, error)
+	MkdirAll(string, fs.FileMode) error
 }
 
 type Shell struct {
@@ -41,6 +48,14 @@ type Shell struct {
 	ExecFunc     func(cmd *cmd.Command, path string, args []string) error
 	FullPathFunc func(string) (string, error)
 
+	// ExecReplaceFunc replaces the shell process with the executable at
+	// path. It only returns if that failed. exec is not supported when it
+	// is nil.
+	ExecReplaceFunc func(cmd *cmd.Command, path string, args []string) error
+	// TerminalSizeFunc returns the size of the terminal, which long
+	// lines are wrapped to
+	TerminalSizeFunc func() (width, height int, err error)
+
 	WorkingDir string
 
 	HistoryContext  *history.HistoryContext
@@ -53,6 +68,19 @@ type Shell struct {
 	plugins     []ShellPlugin
 	keyHandlers *KeyHandlers
 	*hooks
+
+	traps            *traps
+	dirStack         []string
+	dirDB            *jump.DB
+	dirDBChanged     bool
+	envTrust         *envrc.Trust
+	envFile          *loadedEnvFile
+	envReported      string
+	notFoundHandlers []CommandNotFoundHandler
+	pasteHandlers    []PasteHandler
+	foreground       *foregroundJob
+	sigs             chan os.Signal
+	tornDown         bool
 }
 
 func (s *Shell) buildPathCommandFunc(exec, path string) cmd.CommandFunc {
@@ -63,6 +91,16 @@ func (s *Shell) buildPathCommandFunc(exec, path string) cmd.CommandFunc {
 			Stderr: s.Stderr,
 			Stdin:  s.Stdin,
 			Run: func(cmd *cmd.Command, args []string) error {
+				var proc *os.Process
+				cmd.OnStart = func(p *os.Process) {
+					proc = p
+					s.foreground.add(p)
+				}
+				defer func() {
+					if proc != nil {
+						s.foreground.remove(proc)
+					}
+				}()
 				return s.ExecFunc(cmd, path, args)
 			},
 		}
@@ -84,18 +122,28 @@ func (s *Shell) Run() error {
 
 	s.tw = terminal.NewTermWriter(s.Stdout)
 	s.tr = terminal.NewTermReader(s.Stdin, s.tw)
+	s.tr.SizeFunc = s.TerminalSizeFunc
 	s.Stdout = s.tw
 	s.Stderr = &terminalErrWriter{s.tw}
 
 	s.keyHandlers = newEventHandlers()
 	s.hooks = newHooks()
+	s.traps = newTraps()
+	s.foreground = newForegroundJob()
+
+	stopSignals := s.startSignalHandling()
+	defer stopSignals()
 
 	if s.CommandRegistry == nil {
-		registry, err := cmd.LoadFromPathEnv(s.Env.Get("PATH"), s.FS, s.FullPathFunc, s.buildPathCommandFunc)
-		if err != nil {
-			fmt.Fprintf(s.Stderr, "failed to load commands from PATH; %s\n", err)
-			registry = cmd.NewResitry(s.buildPathCommandFunc)
-		}
+		registry := cmd.NewResitry(s.buildPathCommandFunc)
+		// PATH is indexed in the background once the shell is set up
+		// rather than while it starts
+		s.AddHook(HookInitialized, func() {
+			if cacheFile := s.pathIndexCacheFile(); len(cacheFile) > 0 {
+				registry.UseIndexCache(s.FS, cacheFile)
+			}
+			registry.IndexPath(s.Env.Get("PATH"), s.FS, s.FullPathFunc)
+		})
 
 		registry.AddBuiltinCommand("type", NewTypeCommandFunc(registry))
 		registry.AddBuiltinCommand("echo", NewEchoCommandFunc())
@@ -103,8 +151,30 @@ func (s *Shell) Run() error {
 		registry.AddBuiltinCommand("exit", NewExitCommandFunc())
 		registry.AddBuiltinCommand("pwd", NewPWDCommandFunc(s))
 		registry.AddBuiltinCommand("cd", NewCDCommandFunc(s))
+		registry.AddBuiltinCommand("pushd", NewPushdCommandFunc(s))
+		registry.AddBuiltinCommand("popd", NewPopdCommandFunc(s))
+		registry.AddBuiltinCommand("dirs", NewDirsCommandFunc(s))
+		registry.AddBuiltinCommand("z", NewZCommandFunc(s))
+		registry.AddBuiltinCommand("zi", NewZICommandFunc(s))
+		registry.AddBuiltinCommand("allow", NewAllowCommandFunc(s))
+		registry.AddBuiltinCommand("deny", NewDenyCommandFunc(s))
 		registry.AddBuiltinCommand("clear", NewClearCommandFunc())
 		registry.AddBuiltinCommand("plugins", NewPluginsCommandFunc(s))
+		registry.AddBuiltinCommand("read", NewReadCommandFunc(s))
+		registry.AddBuiltinCommand("printf", NewPrintfCommandFunc(s))
+		registry.AddBuiltinCommand("trap", NewTrapCommandFunc(s))
+		registry.AddBuiltinCommand("set", NewSetCommandFunc(s))
+		registry.AddBuiltinCommand("shopt", NewShoptCommandFunc(s))
+		registry.AddBuiltinCommand("exec", NewExecCommandFunc(s))
+		registry.AddBuiltinCommand("ulimit", NewUlimitCommandFunc())
+		registry.AddBuiltinCommand("umask", NewUmaskCommandFunc())
+		registry.AddBuiltinCommand("command", NewCommandCommandFunc(registry))
+		registry.AddBuiltinCommand("builtin", NewBuiltinCommandFunc(registry))
+		registry.AddBuiltinCommand("which", NewWhichCommandFunc(registry))
+		registry.AddBuiltinCommand("hash", NewHashCommandFunc(registry))
+		registry.AddBuiltinCommand("help", NewHelpCommandFunc(registry))
+
+		registry.SetRankFunc(newCommandRanker(s.HistoryContext).rank)
 
 		s.CommandRegistry = registry
 	}
@@ -113,11 +183,29 @@ func (s *Shell) Run() error {
 		interpreter.WithIO(s.Stdin, s.Stdout, s.Stderr),
 		interpreter.WithEnvFunc(s.Env.Get),
 		interpreter.WithCmdLookupFunc(s.LookupCommand),
+		interpreter.WithTrapFunc(s.onInterpreterTrap),
+		interpreter.WithTildeFunc(s.expandTilde),
+		interpreter.WithInteractive(true),
 		interpreter.WithOpenFileFunc(func(name string, flags int, fm os.FileMode) (io.ReadWriteCloser, error) {
 			return s.FS.OpenFile(name, flags)
 		}),
 	)
 
+	s.interp.Options().Register(&interpreter.Option{
+		Name: OptHistAppend,
+		Kind: interpreter.ShoptOption,
+		On:   true,
+	})
+	s.interp.Options().Register(&interpreter.Option{
+		Name: OptAutoCD,
+		Kind: interpreter.ShoptOption,
+	})
+	s.interp.Options().Register(&interpreter.Option{
+		Name: OptAutocorrect,
+		Kind: interpreter.ShoptOption,
+	})
+	s.registerEditModeOptions()
+
 	if histFile := s.Env.Get("HISTFILE"); len(histFile) > 0 {
 		err := history.ReadHistoryFromFile(s.HistoryContext, s.FS, s.Env.Get("HISTFILE"))
 		if err != nil {
@@ -126,16 +214,17 @@ func (s *Shell) Run() error {
 			}
 		}
 	}
-	defer func() {
-		histFile := s.Env.Get("HISTFILE")
-		if len(histFile) <= 0 {
-			return
-		}
-		err := history.AppendHistoryToFile(s.HistoryContext, s.FS, s.Env.Get("HISTFILE"))
-		if err != nil {
-			fmt.Fprintf(s.Stderr, "failed to save command history: %s\n", err)
-		}
-	}()
+	s.loadDirDB()
+	s.AddHook(HookPreExit, s.saveDirDB)
+	s.loadEnvTrust()
+	defer s.tearDown()
+
+	// the env file is checked again after each command to pick up edits
+	s.AddHook(HookInitialized, s.updateEnvFile)
+	s.AddHook(HookPostChdir, s.updateEnvFile)
+	s.AddHook(HookPostEvaluate, s.updateEnvFile)
+	// the line is wrapped again when the terminal is resized
+	s.AddHook(SignalHook("WINCH"), s.tr.Resize)
 
 	for _, p := range s.plugins {
 		p.Register(s)
@@ -143,12 +232,87 @@ func (s *Shell) Run() error {
 
 	s.runHooks(HookInitialized)
 
+	s.tr.SetBracketedPaste(true)
 	s.repl()
+	s.tr.SetBracketedPaste(false)
 
-	s.runHooks(HookPreExit)
+	s.runExitTrap()
 	return nil
 }
 
+// tearDown runs the PreExit hooks and saves the history before the shell
+// exits or exec replaces it. It only does so once.
+func (s *Shell) tearDown() {
+	if s.tornDown {
+		return
+	}
+	s.tornDown = true
+	s.runHooks(HookPreExit)
+	s.saveHistory()
+}
+
+// dataFile returns where the data file called name is kept between
+// sessions, or an empty string if there is no data directory.
+func (s *Shell) dataFile(name string) string {
+	dataDir := s.Env.Get("XDG_DATA_HOME")
+	if len(dataDir) == 0 {
+		home := s.Env.Get("HOME")
+		if len(home) == 0 {
+			return ""
+		}
+		dataDir = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dataDir, name)
+}
+
+// writeDataFile writes the data file called name with write, creating
+// the data directory if needed. The directory is kept private as the
+// files in it tell where the user has been. Nothing is written when there
+// is no data directory.
+func (s *Shell) writeDataFile(name string, write func(filename string) error) error {
+	filename := s.dataFile(name)
+	if len(filename) == 0 {
+		return nil
+	}
+	if err := s.FS.MkdirAll(filepath.Dir(filename), 0o700); err != nil {
+		return err
+	}
+	return write(filename)
+}
+
+// pathIndexCacheFile returns where the index of PATH is cached between
+// sessions, or an empty string if there is no cache directory.
+func (s *Shell) pathIndexCacheFile() string {
+	cacheDir := s.Env.Get("XDG_CACHE_HOME")
+	if len(cacheDir) == 0 {
+		home := s.Env.Get("HOME")
+		if len(home) == 0 {
+			return ""
+		}
+		cacheDir = filepath.Join(home, ".cache")
+	}
+	return filepath.Join(cacheDir, "shell-go-path-index.json")
+}
+
+// saveHistory writes the session's history to HISTFILE, appending to
+// it when histappend is set and replacing it otherwise.
+func (s *Shell) saveHistory() {
+	histFile := s.Env.Get("HISTFILE")
+	if len(histFile) <= 0 {
+		return
+	}
+
+	var err error
+	if s.interp.Options().IsSet(OptHistAppend) {
+		err = history.AppendHistoryToFile(s.HistoryContext, s.FS, histFile)
+	} else {
+		err = history.WriteHistoryToFile(s.HistoryContext, s.FS, histFile)
+	}
+	if err != nil {
+		fmt.Fprintf(s.Stderr, "failed to save command history: %s\n", err)
+	}
+}
+
 func (s *Shell) repl() {
 	for {
 		s.tr.Ready()
@@ -171,19 +335,28 @@ func (s *Shell) repl() {
 
 		s.tw.StagePushForegroundColor(terminal.OffWhiteWarm)
 		s.runHooks(HookPreEvaluate)
-		if err = s.interp.Evaluate(input); err != nil {
-			if errors.Is(err, ErrExit) {
-				return
-			}
-
-			if errors.Is(err, interpreter.ErrCommandNotFound) {
+		err = s.interp.Evaluate(input)
+		if errors.Is(err, ErrExit) {
+			return
+		}
+		if err != nil {
+			switch {
+			case interpreter.IsExitStatus(err):
+				// the command already reported its own failure
+			case errors.Is(err, interpreter.ErrCommandNotFound):
 				fmt.Fprintln(s.Stderr, err)
-			} else {
+			default:
 				fmt.Fprintf(s.Stderr, "error: %s\n", err)
 			}
 		}
 		s.runHooks(HookPostEvaluate)
 		s.tw.StagePopForegroundColor()
+
+		// errexit ends the shell once the failed command is wrapped up
+		var errexit *interpreter.ErrexitError
+		if errors.As(err, &errexit) {
+			return
+		}
 	}
 }
 
@@ -199,20 +372,33 @@ func (s *Shell) read() (string, error) {
 		switch item.Type {
 		case terminal.ItemLineInput:
 			return item.Literal, nil
+		case terminal.ItemPaste:
+			s.paste(item.Literal)
+		case terminal.ItemSignal:
+			if err := s.handleSignal(item.Literal); err != nil {
+				return "", err
+			}
+		case terminal.ItemEOF:
+			return "", ErrExit
 		}
 	}
 }
 
 func (s *Shell) LookupCommand(name string) (f interpreter.CmdFunc, found bool, err error) {
+	s.CommandRegistry.SetPathEnv(s.interp.Var("PATH"))
+
 	cmd, found := s.CommandRegistry.LookupCommand(name)
 	if !found {
-		return nil, false, nil
+		return s.commandNotFound, true, nil
 	}
 
-	return func(_ context.Context, stdin io.Reader, stdout, stderr io.Writer, args []string) error {
+	return func(ctx context.Context, stdin io.Reader, stdout, stderr io.Writer, args []string) error {
 		cmd.Stdin = stdin
 		cmd.Stdout = stdout
 		cmd.Stderr = stderr
+		cmd.OnExit = func(ps *os.ProcessState) {
+			interpreter.ReportUsage(ctx, processUsage(ps))
+		}
 		return cmd.Run(cmd, args)
 	}, true, nil
 }
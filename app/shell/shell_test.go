@@ -0,0 +1,74 @@
+package shell
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/codecrafters-io/shell-starter-go/app/shell/history"
+	"github.com/codecrafters-io/shell-starter-go/app/shell/shelltest"
+	"github.com/codecrafters-io/shell-starter-go/app/shell/terminal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runShell runs a shell reading input in its HOME, a temporary directory
+// unless env sets one, and returns what it wrote with the escape sequences
+// left out.
+func runShell(t *testing.T, input string, env shelltest.MapEnv) string {
+	t.Helper()
+	return stripTerminalEscapes(runShellRaw(t, input, env))
+}
+
+// runShellRaw is runShell keeping the escape sequences.
+func runShellRaw(t *testing.T, input string, env shelltest.MapEnv) string {
+	t.Helper()
+	if env == nil {
+		env = shelltest.MapEnv{}
+	}
+	if _, ok := env["HOME"]; !ok {
+		env["HOME"] = t.TempDir()
+	}
+	home := env["HOME"]
+
+	out := &bytes.Buffer{}
+	s := &Shell{
+		Stdout:         out,
+		Stderr:         out,
+		Stdin:          strings.NewReader(input),
+		Env:            env,
+		FS:             shelltest.OSFS{},
+		FullPathFunc:   filepath.Abs,
+		WorkingDir:     home,
+		HistoryContext: history.NewHistoryContext(history.NewInMemoryHistory()),
+	}
+	require.NoError(t, s.Run())
+	return out.String()
+}
+
+func stripTerminalEscapes(s string) string {
+	b := strings.Builder{}
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\x1b' {
+			b.WriteByte(s[i])
+			continue
+		}
+		// CSI sequences end with a byte in @ to ~
+		if i+1 < len(s) && s[i+1] == '[' {
+			i += 2
+			for i < len(s) && (s[i] < '@' || s[i] > '~') {
+				i++
+			}
+		}
+	}
+	return strings.ReplaceAll(b.String(), "\r", "")
+}
+
+func TestErrexitWrapsUpCommand(t *testing.T) {
+	out := runShellRaw(t, "set -e\ntrap \"echo bye\" EXIT\ncd missing\necho unreachable\n", nil)
+
+	// the command color is popped before the EXIT trap runs
+	assert.True(t, strings.HasSuffix(out, string(terminal.Purple)+"bye\r\n"), "%q", out)
+	assert.NotContains(t, out, "unreachable\r\n")
+}
@@ -0,0 +1,50 @@
+// Package shelltest implements the environment and file system a shell
+// needs for tests.
+package shelltest
+
+import (
+	"io"
+	"io/fs"
+	"os"
+)
+
+// MapEnv is an environment held in a map.
+type MapEnv map[string]string
+
+func (e MapEnv) Get(key string) string {
+	return e[key]
+}
+
+func (e MapEnv) Lookup(key string) (string, bool) {
+	v, ok := e[key]
+	return v, ok
+}
+
+func (e MapEnv) Set(key, value string) error {
+	e[key] = value
+	return nil
+}
+
+func (e MapEnv) Unset(key string) error {
+	delete(e, key)
+	return nil
+}
+
+// OSFS is the file system of the operating system.
+type OSFS struct{}
+
+func (OSFS) Open(name string) (fs.File, error) {
+	return os.Open(name)
+}
+
+func (OSFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return os.ReadDir(name)
+}
+
+func (OSFS) OpenFile(name string, flags int) (io.ReadWriteCloser, error) {
+	return os.OpenFile(name, flags, 0o666)
+}
+
+func (OSFS) MkdirAll(name string, perm fs.FileMode) error {
+	return os.MkdirAll(name, perm)
+}
@@ -0,0 +1,111 @@
+package shell
+
+import (
+	"fmt"
+
+	"github.com/codecrafters-io/shell-starter-go/app/cmd"
+	"github.com/codecrafters-io/shell-starter-go/app/shell/interpreter"
+	"github.com/codecrafters-io/shell-starter-go/assert"
+)
+
+// Names of the shopt options owned by the shell
+const (
+	OptHistAppend = "histappend"
+	// OptAutocorrect offers to run the closest command instead of a
+	// missing one
+	OptAutocorrect = "autocorrect"
+	// OptAutoCD runs cd for commands naming a directory
+	OptAutoCD = "autocd"
+)
+
+func NewShoptCommandFunc(s *Shell) cmd.CommandFunc {
+	assert.NotNil(s, "shell")
+	return func() *cmd.Command {
+		return &cmd.Command{
+			Name: "shopt",
+			Spec: &cmd.Spec{
+				Synopsis: "shopt [-pqsu] [-o] [optname ...]",
+				Short:    "Set and unset shell options.",
+				Flags: []cmd.Flag{
+					{Name: 'o', Usage: "restrict OPTNAMEs to those used with set -o"},
+					{Name: 'p', Usage: "print each option with its status"},
+					{Name: 'q', Usage: "suppress output"},
+					{Name: 's', Usage: "enable each OPTNAME"},
+					{Name: 'u', Usage: "disable each OPTNAME"},
+				},
+			},
+			Run: func(cmd *cmd.Command, args []string) error {
+				assert.Assert(len(args) > 0)
+
+				flags, names, err := cmd.Spec.Parse(args[1:])
+				if err != nil {
+					return fmt.Errorf("shopt: %w", err)
+				}
+
+				_, set := flags['s']
+				_, unset := flags['u']
+				_, quiet := flags['q']
+				_, reusable := flags['p']
+				if set && unset {
+					return fmt.Errorf("shopt: cannot set and unset shell options simultaneously")
+				}
+
+				kind := interpreter.ShoptOption
+				if _, ok := flags['o']; ok {
+					kind = interpreter.SetOption
+				}
+
+				opts := s.interp.Options()
+				if set || unset {
+					for _, name := range names {
+						if err := setOption(opts, name, set, kind); err != nil {
+							return fmt.Errorf("shopt: %w", err)
+						}
+					}
+					if len(names) > 0 {
+						return nil
+					}
+				}
+
+				list := opts.List(kind)
+				if len(names) > 0 {
+					list = make([]interpreter.Option, 0, len(names))
+					for _, name := range names {
+						opt, ok := opts.Lookup(name)
+						if !ok || opt.Kind != kind {
+							return fmt.Errorf("shopt: %s: invalid shell option name", name)
+						}
+						list = append(list, opt)
+					}
+				}
+
+				allOn := true
+				for _, opt := range list {
+					if (set && !opt.On) || (unset && opt.On) {
+						continue
+					}
+					allOn = allOn && opt.On
+					switch {
+					case quiet:
+					case reusable:
+						_, _ = fmt.Fprintf(cmd.Stdout, "shopt -%c %s\n", shoptFlag(opt.On), opt.Name)
+					default:
+						_, _ = fmt.Fprintf(cmd.Stdout, "%-15s\t%s\n", opt.Name, onOff(opt.On))
+					}
+				}
+
+				if len(names) > 0 && !allOn {
+					return interpreter.ExitStatus(1)
+				}
+				return nil
+			},
+		}
+	}
+}
+
+func shoptFlag(on bool) byte {
+	if on {
+		return 's'
+	}
+	return 'u'
+}
@@ -0,0 +1,263 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/codecrafters-io/shell-starter-go/app/shell/interpreter"
+	"github.com/codecrafters-io/shell-starter-go/app/shell/terminal"
+)
+
+// Pseudo signals that can be trapped but are raised by the shell itself
+const (
+	trapExit   = "EXIT"
+	trapErr    = "ERR"
+	trapDebug  = "DEBUG"
+	trapReturn = "RETURN"
+)
+
+var pseudoSignals = []string{trapExit, trapErr, trapDebug, trapReturn}
+
+type signalInfo struct {
+	name string
+	sig  syscall.Signal
+	// forward reports whether the signal is passed on to the
+	// foreground job when the shell receives it
+	forward bool
+}
+
+// SignalHook returns the hook that runs when the shell receives the
+// signal with the given name, e.g. SignalHook("WINCH") for SIGWINCH.
+func SignalHook(name string) Hook {
+	return Hook("SIG" + name)
+}
+
+func lookupSignal(name string) (signalInfo, bool) {
+	for _, si := range signalTable {
+		if si.name == name {
+			return si, true
+		}
+	}
+	return signalInfo{}, false
+}
+
+func signalName(sig os.Signal) string {
+	for _, si := range signalTable {
+		if si.sig == sig {
+			return si.name
+		}
+	}
+	return sig.String()
+}
+
+// parseSignalSpec resolves the signal names and numbers accepted by
+// builtins such as `trap`, e.g. "INT", "SIGINT", "sigint" or "2", to the
+// canonical name without the SIG prefix.
+func parseSignalSpec(spec string) (string, bool) {
+	if n, err := strconv.Atoi(spec); err == nil {
+		if n == 0 {
+			return trapExit, true
+		}
+		for _, si := range signalTable {
+			if int(si.sig) == n {
+				return si.name, true
+			}
+		}
+		return "", false
+	}
+
+	name := strings.ToUpper(spec)
+	if slices.Contains(pseudoSignals, name) {
+		return name, true
+	}
+
+	name = strings.TrimPrefix(name, "SIG")
+	if _, ok := lookupSignal(name); ok {
+		return name, true
+	}
+	return "", false
+}
+
+// traps holds the commands registered with the `trap` builtin keyed by
+// canonical signal name. An empty action means the signal is ignored.
+type traps struct {
+	mu      sync.Mutex
+	actions map[string]string
+}
+
+func newTraps() *traps {
+	return &traps{
+		actions: map[string]string{},
+	}
+}
+
+func (t *traps) get(name string) (string, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	action, ok := t.actions[name]
+	return action, ok
+}
+
+func (t *traps) set(name, action string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.actions[name] = action
+}
+
+func (t *traps) reset(name string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.actions, name)
+}
+
+func (t *traps) names() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	names := make([]string, 0, len(t.actions))
+	for name := range t.actions {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// foregroundJob tracks the processes started by the command the shell
+// is currently waiting on.
+type foregroundJob struct {
+	mu    sync.Mutex
+	procs map[*os.Process]struct{}
+}
+
+func newForegroundJob() *foregroundJob {
+	return &foregroundJob{
+		procs: map[*os.Process]struct{}{},
+	}
+}
+
+func (j *foregroundJob) add(p *os.Process) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.procs[p] = struct{}{}
+}
+
+func (j *foregroundJob) remove(p *os.Process) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	delete(j.procs, p)
+}
+
+func (j *foregroundJob) signal(sig os.Signal) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for p := range j.procs {
+		_ = p.Signal(sig)
+	}
+}
+
+// startSignalHandling catches signals for the lifetime of the shell.
+// Signals are forwarded to the foreground job right away while traps and
+// hooks run from the read loop once the shell is back at the prompt. The
+// returned func stops catching signals.
+func (s *Shell) startSignalHandling() func() {
+	s.sigs = make(chan os.Signal, 8)
+	signal.Notify(s.sigs, defaultSignals...)
+
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case sig := <-s.sigs:
+				name := signalName(sig)
+				if si, ok := lookupSignal(name); ok && si.forward {
+					s.foreground.signal(sig)
+				}
+				s.tr.Post(terminal.Item{
+					Type:    terminal.ItemSignal,
+					Literal: name,
+				})
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(s.sigs)
+		close(done)
+	}
+}
+
+// catchSignal makes sure the shell is notified of the signal with the
+// given name, which is needed for signals that are not caught by default.
+func (s *Shell) catchSignal(name string) {
+	if si, ok := lookupSignal(name); ok {
+		signal.Notify(s.sigs, si.sig)
+	}
+}
+
+// releaseSignal restores the default disposition of a signal that was
+// only caught because it was trapped.
+func (s *Shell) releaseSignal(name string) {
+	si, ok := lookupSignal(name)
+	if !ok || slices.Contains(defaultSignals, os.Signal(si.sig)) {
+		return
+	}
+	signal.Reset(si.sig)
+}
+
+// handleSignal runs from the read loop for every signal the shell has
+// received.
+func (s *Shell) handleSignal(name string) error {
+	s.runHooks(SignalHook(name))
+
+	if action, ok := s.traps.get(name); ok {
+		if len(action) > 0 {
+			s.tr.Erase()
+			s.evalTrap(action)
+			_ = s.tr.Ready()
+		}
+		return nil
+	}
+
+	switch name {
+	case "HUP":
+		// the terminal is gone; exit so that history is saved
+		return ErrExit
+	}
+	return nil
+}
+
+func (s *Shell) onInterpreterTrap(t interpreter.Trap) {
+	var name string
+	switch t {
+	case interpreter.TrapDebug:
+		name = trapDebug
+	case interpreter.TrapErr:
+		name = trapErr
+	default:
+		return
+	}
+
+	if action, ok := s.traps.get(name); ok && len(action) > 0 {
+		s.evalTrap(action)
+	}
+}
+
+func (s *Shell) runExitTrap() {
+	if action, ok := s.traps.get(trapExit); ok && len(action) > 0 {
+		s.evalTrap(action)
+	}
+}
+
+func (s *Shell) evalTrap(action string) {
+	err := s.interp.EvaluateTrap(action)
+	if err != nil && !interpreter.IsExitStatus(err) {
+		fmt.Fprintf(s.Stderr, "trap: %s\n", err)
+	}
+}
@@ -0,0 +1,18 @@
+//go:build !unix
+
+package shell
+
+import (
+	"os"
+	"syscall"
+)
+
+var signalTable = []signalInfo{
+	{"INT", syscall.SIGINT, true},
+	{"TERM", syscall.SIGTERM, true},
+}
+
+var defaultSignals = []os.Signal{
+	syscall.SIGINT,
+	syscall.SIGTERM,
+}
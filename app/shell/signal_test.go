@@ -0,0 +1,99 @@
+package shell
+
+import (
+	"bytes"
+	"os"
+	"os/signal"
+	"testing"
+
+	"github.com/codecrafters-io/shell-starter-go/app/cmd"
+	"github.com/codecrafters-io/shell-starter-go/app/shell/interpreter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSignalSpec(t *testing.T) {
+	tt := []struct {
+		spec string
+		name string
+		ok   bool
+	}{
+		{"INT", "INT", true},
+		{"int", "INT", true},
+		{"SIGTERM", "TERM", true},
+		{"sigterm", "TERM", true},
+		{"2", "INT", true},
+		{"15", "TERM", true},
+		{"0", "EXIT", true},
+		{"EXIT", "EXIT", true},
+		{"err", "ERR", true},
+		{"DEBUG", "DEBUG", true},
+		{"SIGEXIT", "", false},
+		{"NOPE", "", false},
+		{"SIG", "", false},
+		{"999", "", false},
+		{"-1", "", false},
+		{"", "", false},
+	}
+
+	for _, test := range tt {
+		t.Run(test.spec, func(t *testing.T) {
+			name, ok := parseSignalSpec(test.spec)
+			assert.Equal(t, test.ok, ok)
+			assert.Equal(t, test.name, name)
+		})
+	}
+}
+
+func newTrapTestShell(t *testing.T) (*Shell, *bytes.Buffer) {
+	out := &bytes.Buffer{}
+	s := &Shell{
+		Stdout: out,
+		Stderr: out,
+		traps:  newTraps(),
+		sigs:   make(chan os.Signal, 1),
+	}
+	t.Cleanup(func() { signal.Stop(s.sigs) })
+
+	registry := cmd.NewResitry(nil)
+	registry.AddBuiltinCommand("trap", NewTrapCommandFunc(s))
+	registry.AddBuiltinCommand("echo", NewEchoCommandFunc())
+	s.CommandRegistry = registry
+	s.interp = interpreter.NewInterpreter(
+		interpreter.WithIO(nil, out, out),
+		interpreter.WithCmdLookupFunc(s.LookupCommand),
+	)
+	return s, out
+}
+
+func TestTrapPrint(t *testing.T) {
+	s, out := newTrapTestShell(t)
+
+	require.NoError(t, s.interp.Evaluate(`trap "echo it's over" EXIT TERM; trap "" INT; trap -p`))
+	assert.Equal(t, "trap -- 'echo it'\\''s over' EXIT\n"+
+		"trap -- '' SIGINT\n"+
+		"trap -- 'echo it'\\''s over' SIGTERM\n", out.String())
+
+	out.Reset()
+	require.NoError(t, s.interp.Evaluate(`trap -p INT`))
+	assert.Equal(t, "trap -- '' SIGINT\n", out.String())
+
+	// both `trap - SIG` and a lone signal reset the trap
+	out.Reset()
+	require.NoError(t, s.interp.Evaluate(`trap - TERM; trap INT; trap`))
+	assert.Equal(t, "trap -- 'echo it'\\''s over' EXIT\n", out.String())
+
+	out.Reset()
+	err := s.interp.Evaluate(`trap -p NOPE`)
+	assert.ErrorContains(t, err, "NOPE: invalid signal specification")
+}
+
+func TestExitTrap(t *testing.T) {
+	out := runShell(t, "trap 'echo bye' EXIT\nexit\necho unreachable\n", nil)
+	assert.Contains(t, out, "bye\n")
+	assert.NotContains(t, out, "unreachable\n")
+
+	// the end of input exits too
+	out = runShell(t, "trap 'echo bye' 0\n", nil)
+	assert.Contains(t, out, "bye\n")
+}
@@ -0,0 +1,33 @@
+//go:build unix
+
+package shell
+
+import (
+	"os"
+	"syscall"
+)
+
+var signalTable = []signalInfo{
+	{"HUP", syscall.SIGHUP, true},
+	{"INT", syscall.SIGINT, true},
+	{"QUIT", syscall.SIGQUIT, true},
+	{"USR1", syscall.SIGUSR1, true},
+	{"USR2", syscall.SIGUSR2, true},
+	{"PIPE", syscall.SIGPIPE, false},
+	{"ALRM", syscall.SIGALRM, false},
+	{"TERM", syscall.SIGTERM, true},
+	{"CHLD", syscall.SIGCHLD, false},
+	{"CONT", syscall.SIGCONT, false},
+	{"TSTP", syscall.SIGTSTP, false},
+	{"WINCH", syscall.SIGWINCH, false},
+}
+
+// defaultSignals are always caught by the shell so that it can clean up
+// after itself instead of dying with the terminal left in raw mode.
+var defaultSignals = []os.Signal{
+	syscall.SIGHUP,
+	syscall.SIGINT,
+	syscall.SIGQUIT,
+	syscall.SIGTERM,
+	syscall.SIGWINCH,
+}
@@ -0,0 +1,360 @@
+package terminal
+
+import (
+	"slices"
+	"unicode"
+)
+
+// Control keys handled by the line editor
+const (
+	keyCtrlA = 1
+	keyCtrlB = 2
+	keyCtrlE = 5
+	keyCtrlF = 6
+	keyCtrlG = 7
+	keyCtrlK = 11
+	keyCtrlU = 21
+	keyCtrlR = 18
+	keyCtrlS = 19
+	keyCtrlW = 23
+	keyCtrlX = 24
+	keyCtrlY = 25
+	// keyCtrlUnderscore is also sent for Ctrl-/
+	keyCtrlUnderscore = 31
+)
+
+// editKind is the kind of the last edit, which decides whether the next
+// one joins its undo step and whether a kill adds to the last one.
+type editKind int
+
+const (
+	editNone editKind = iota
+	editInsert
+	editDelete
+	editKill
+	editYank
+	editReplace
+	// editPaste is text inserted at once, e.g. by a plugin
+	editPaste
+)
+
+// keyEdits maps the keys sent as escape sequences that move the cursor
+// or edit the line.
+var keyEdits = map[KeyEvent]func(*Terminal){
+	{Key: KeyRight}:                        (*Terminal).forwardChar,
+	{Key: KeyLeft}:                         (*Terminal).backwardChar,
+	{Key: KeyHome}:                         (*Terminal).beginningOfLine,
+	{Key: KeyEnd}:                          (*Terminal).endOfLine,
+	{Key: KeyDelete}:                       (*Terminal).deleteChar,
+	{Key: KeyRight, Mod: ModCtrl}:          (*Terminal).forwardWord,
+	{Key: KeyLeft, Mod: ModCtrl}:           (*Terminal).backwardWord,
+	{Key: KeyRight, Mod: ModAlt}:           (*Terminal).forwardWord,
+	{Key: KeyLeft, Mod: ModAlt}:            (*Terminal).backwardWord,
+	{Key: KeyRune, Mod: ModAlt, Rune: 'b'}: (*Terminal).backwardWord,
+	{Key: KeyRune, Mod: ModAlt, Rune: 'f'}: (*Terminal).forwardWord,
+	{Key: KeyRune, Mod: ModAlt, Rune: 'd'}: (*Terminal).killWord,
+	{Key: KeyBackspace, Mod: ModAlt}:       (*Terminal).backwardKillWord,
+	{Key: KeyRune, Mod: ModAlt, Rune: 'y'}: (*Terminal).yankPop,
+	{Key: KeyRune, Mod: ModAlt, Rune: '/'}: (*Terminal).redo,
+}
+
+// controlEdits maps the control keys that move the cursor or edit the
+// line.
+var controlEdits = map[byte]func(*Terminal){
+	keyCtrlA:          (*Terminal).beginningOfLine,
+	keyCtrlB:          (*Terminal).backwardChar,
+	keyCtrlE:          (*Terminal).endOfLine,
+	keyCtrlF:          (*Terminal).forwardChar,
+	keyCtrlK:          (*Terminal).killLine,
+	keyCtrlU:          (*Terminal).unixLineDiscard,
+	keyCtrlW:          (*Terminal).unixWordRubout,
+	keyCtrlY:          (*Terminal).yank,
+	keyCtrlUnderscore: (*Terminal).undo,
+	keyBackspace:      (*Terminal).backwardDeleteChar,
+	keyDelete:         (*Terminal).backwardDeleteChar,
+}
+
+// Bind makes key emit an ItemKeyBound instead of what the line editor
+// does for it, for plugins to handle.
+func (t *Terminal) Bind(key KeyEvent) {
+	if t.bound == nil {
+		t.bound = map[KeyEvent]bool{}
+	}
+	t.bound[key] = true
+}
+
+func (t *Terminal) Unbind(key KeyEvent) {
+	delete(t.bound, key)
+}
+
+// Insert adds text at the cursor, as an edit that can be undone.
+func (t *Terminal) Insert(text string) {
+	if len(text) == 0 {
+		return
+	}
+	t.saveUndo(editPaste)
+	t.insert([]rune(text)...)
+	t.lastEdit = editPaste
+}
+
+// Refresh redraws the prompt and the line, e.g. after the prompt
+// changed.
+func (t *Terminal) Refresh() {
+	t.refresh()
+}
+
+// Cursor returns the position of the cursor in Line, counted in runes.
+func (t *Terminal) Cursor() int {
+	return t.cursor
+}
+
+// SetCursor moves the cursor to pos, counted in runes from the start of
+// Line.
+func (t *Terminal) SetCursor(pos int) {
+	t.moveTo(pos)
+}
+
+// insert adds r at the cursor and moves the cursor past it.
+func (t *Terminal) insert(r ...rune) {
+	atEnd := t.cursor == len(t.line)
+	t.line = slices.Insert(t.line, t.cursor, r...)
+	t.cursor += len(r)
+	if t.silent {
+		return
+	}
+
+	if atEnd && t.stageAppend(r) {
+		t.tw.Commit()
+		return
+	}
+	t.refresh()
+}
+
+// delete removes the runes between from and to and moves the cursor to
+// from. The removed runes are returned.
+func (t *Terminal) delete(from, to int) []rune {
+	if from >= to {
+		return nil
+	}
+
+	removed := slices.Clone(t.line[from:to])
+	t.line = slices.Delete(t.line, from, to)
+	t.cursor = from
+	t.refresh()
+	return removed
+}
+
+// moveTo places the cursor at pos in the line.
+func (t *Terminal) moveTo(pos int) {
+	t.lastEdit = editNone
+	pos = max(0, min(pos, t.cursorLimit()))
+	if pos == t.cursor {
+		return
+	}
+
+	t.cursor = pos
+	if t.silent {
+		return
+	}
+	if _, _, ok := t.viSelection(); ok {
+		t.refresh()
+		return
+	}
+	t.screen.at = t.screen.prefix + pos
+	t.stageMoveTo(t.positionOf(t.screen.at, t.width()))
+	t.tw.Commit()
+}
+
+// cursorLimit is the last position of the cursor, which stays on the
+// last character rather than after it in vi command mode.
+func (t *Terminal) cursorLimit() int {
+	if t.viCommandMode() && len(t.line) > 0 {
+		return prevGrapheme(t.line, len(t.line))
+	}
+	return len(t.line)
+}
+
+// refresh redraws the prompt and the line and puts the cursor back in
+// place.
+func (t *Terminal) refresh() {
+	if t.silent {
+		return
+	}
+	t.draw(t.prompt(), t.line, t.cursor, t.stageLine)
+	t.tw.Commit()
+}
+
+// stageLine writes the line with the visual selection highlighted.
+func (t *Terminal) stageLine() {
+	from, to, ok := t.viSelection()
+	if !ok {
+		t.tw.StageString(string(t.line))
+		return
+	}
+	t.tw.StageString(string(t.line[:from]))
+	t.tw.Stage(reverseVideo)
+	t.tw.StageString(string(t.line[from:to]))
+	t.tw.Stage(noReverseVideo)
+	t.tw.StageString(string(t.line[to:]))
+}
+
+func (t *Terminal) bell() {
+	if !t.silent {
+		_, _ = t.tw.StageByte(bell).Commit()
+	}
+}
+
+// kill moves the text between from and to to the kill ring. A kill
+// right after another adds to its text, in front of it when killing
+// backward, except in vi mode.
+func (t *Terminal) kill(from, to int, backward bool) {
+	if from >= to {
+		return
+	}
+
+	t.saveUndo(editKill)
+	removed := t.delete(from, to)
+	if t.lastEdit == editKill && t.vi == nil {
+		t.kills.extend(removed, backward)
+	} else {
+		t.kills.push(removed)
+	}
+	t.lastEdit = editKill
+}
+
+// saveUndo saves the line before an edit of the given kind, unless the
+// edit continues the undo step of the last one.
+func (t *Terminal) saveUndo(kind editKind) {
+	switch {
+	case kind == t.lastEdit && (kind == editInsert || kind == editDelete || kind == editReplace):
+		return
+	case t.vi != nil && kind != editReplace:
+		// vi saves the line before each command, and once for the
+		// text typed in insert mode
+		if t.vi.mode != ViInsert || t.vi.saved {
+			return
+		}
+		t.vi.saved = true
+	}
+	t.undos.push(t.line, t.cursor)
+}
+
+// restore puts back a line taken from the undo stack.
+func (t *Terminal) restore(s lineState, ok bool) bool {
+	if !ok {
+		t.bell()
+		return false
+	}
+	t.line, t.cursor = s.line, min(s.cursor, len(s.line))
+	t.lastEdit = editNone
+	t.refresh()
+	return true
+}
+
+func (t *Terminal) undo() { t.restore(t.undos.undo(t.line, t.cursor)) }
+func (t *Terminal) redo() { t.restore(t.undos.redo(t.line, t.cursor)) }
+
+func (t *Terminal) forwardChar()     { t.moveTo(nextGrapheme(t.line, t.cursor)) }
+func (t *Terminal) backwardChar()    { t.moveTo(prevGrapheme(t.line, t.cursor)) }
+func (t *Terminal) beginningOfLine() { t.moveTo(0) }
+func (t *Terminal) endOfLine()       { t.moveTo(len(t.line)) }
+func (t *Terminal) forwardWord()     { t.moveTo(t.wordEnd(t.cursor, isWordRune)) }
+func (t *Terminal) backwardWord()    { t.moveTo(t.wordStart(t.cursor, isWordRune)) }
+
+func (t *Terminal) deleteChar() {
+	if t.cursor < len(t.line) {
+		t.saveUndo(editDelete)
+		t.delete(t.cursor, nextGrapheme(t.line, t.cursor))
+		t.lastEdit = editDelete
+	}
+}
+
+func (t *Terminal) backwardDeleteChar() {
+	if t.cursor == 0 {
+		return
+	}
+	t.saveUndo(editDelete)
+	t.lastEdit = editDelete
+	t.delete(prevGrapheme(t.line, t.cursor), t.cursor)
+}
+
+// killLine kills from the cursor to the end of the line.
+func (t *Terminal) killLine() {
+	t.kill(t.cursor, len(t.line), false)
+}
+
+// unixLineDiscard kills from the start of the line to the cursor.
+func (t *Terminal) unixLineDiscard() {
+	t.kill(0, t.cursor, true)
+}
+
+// unixWordRubout kills the whitespace delimited word before the cursor.
+func (t *Terminal) unixWordRubout() {
+	t.kill(t.wordStart(t.cursor, isNotSpace), t.cursor, true)
+}
+
+// killWord kills to the end of the word after the cursor.
+func (t *Terminal) killWord() {
+	t.kill(t.cursor, t.wordEnd(t.cursor, isWordRune), false)
+}
+
+// backwardKillWord kills to the start of the word before the cursor.
+func (t *Terminal) backwardKillWord() {
+	t.kill(t.wordStart(t.cursor, isWordRune), t.cursor, true)
+}
+
+// yank inserts the last killed text at the cursor.
+func (t *Terminal) yank() {
+	text, ok := t.kills.top()
+	if !ok {
+		return
+	}
+	t.saveUndo(editYank)
+	t.yankStart = t.cursor
+	t.insert(text...)
+	t.lastEdit = editYank
+}
+
+// yankPop replaces the text just yanked with the kill before it.
+func (t *Terminal) yankPop() {
+	if t.lastEdit != editYank {
+		t.bell()
+		return
+	}
+	text, _ := t.kills.rotate()
+	t.line = slices.Replace(t.line, t.yankStart, t.cursor, text...)
+	t.cursor = t.yankStart + len(text)
+	t.refresh()
+}
+
+// wordStart returns the start of the word before pos, skipping the runes
+// in between that are not part of a word.
+func (t *Terminal) wordStart(pos int, inWord func(rune) bool) int {
+	for pos > 0 && !inWord(t.line[pos-1]) {
+		pos--
+	}
+	for pos > 0 && inWord(t.line[pos-1]) {
+		pos--
+	}
+	return pos
+}
+
+// wordEnd returns the end of the word after pos, skipping the runes in
+// between that are not part of a word.
+func (t *Terminal) wordEnd(pos int, inWord func(rune) bool) int {
+	for pos < len(t.line) && !inWord(t.line[pos]) {
+		pos++
+	}
+	for pos < len(t.line) && inWord(t.line[pos]) {
+		pos++
+	}
+	return pos
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func isNotSpace(r rune) bool {
+	return !unicode.IsSpace(r)
+}
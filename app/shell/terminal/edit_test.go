@@ -0,0 +1,100 @@
+package terminal
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLineEditing(t *testing.T) {
+	left, right := imp(keyEscape, "[D"), imp(keyEscape, "[C")
+
+	tt := []struct {
+		name  string
+		input []byte
+		line  string
+	}{
+		{"insert before cursor", imp("ech", left, left, left, "X", keyCarriageReturn), "Xech"},
+		{"insert in the middle", imp("eo", left, "ch", right, "!", byte(keyBackspace), keyCarriageReturn), "echo"},
+		{"home and end", imp("cho", keyEscape, "[H", "e", keyEscape, "[F", "!", keyCarriageReturn), "echo!"},
+		{"ctrl-a and ctrl-e", imp("b", byte(keyCtrlA), "a", byte(keyCtrlE), "c", keyCarriageReturn), "abc"},
+		{"delete forward", imp("abc", byte(keyCtrlA), keyEscape, "[3~", keyCarriageReturn), "bc"},
+		{"delete forward at end", imp("abc", keyEscape, "[3~", keyCarriageReturn), "abc"},
+		{"backspace at start", imp("abc", byte(keyCtrlA), byte(keyDelete), keyCarriageReturn), "abc"},
+		{"alt-b and alt-f", imp("one two-three", keyEscape, "b", keyEscape, "b", "X", keyEscape, "f", "Y", keyCarriageReturn), "one XtwoY-three"},
+		{"ctrl-left and ctrl-right", imp("one two", keyEscape, "[1;5D", keyEscape, "[1;5D", keyEscape, "[1;5C", "!", keyCarriageReturn), "one! two"},
+		{"ctrl-k then ctrl-y", imp("echo mino", left, left, left, left, byte(keyCtrlK), byte(keyCtrlA), byte(keyCtrlY), keyCarriageReturn), "minoecho "},
+		{"ctrl-u", imp("echo mino", left, left, left, left, byte(keyCtrlU), keyCarriageReturn), "mino"},
+		{"ctrl-w", imp("echo foo/bar  ", byte(keyCtrlW), keyCarriageReturn), "echo "},
+		{"ctrl-w then ctrl-y", imp("a b c", byte(keyCtrlW), byte(keyCtrlW), byte(keyCtrlY), keyCarriageReturn), "a b c"},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			tr := NewTermReader(bytes.NewReader(test.input), NewTermWriter(io.Discard))
+			item := tr.NextItem()
+			assert.Equal(t, ItemLineInput, item.Type)
+			assert.Equal(t, test.line, item.Literal)
+			assert.Equal(t, 0, tr.Cursor())
+		})
+	}
+}
+
+func TestKillRingAndUndo(t *testing.T) {
+	altY, undo := imp(keyEscape, "y"), byte(keyCtrlUnderscore)
+
+	tt := []struct {
+		name  string
+		input []byte
+		line  string
+	}{
+		{"ctrl-k kills append", imp("one two", byte(keyCtrlA), byte(keyCtrlK), byte(keyCtrlY), byte(keyCtrlY), keyCarriageReturn), "one twoone two"},
+		{"alt-y yanks earlier kills", imp("aa", byte(keyCtrlU), "bb", byte(keyCtrlU), byte(keyCtrlY), altY, keyCarriageReturn), "aa"},
+		{"alt-y wraps around", imp("aa", byte(keyCtrlU), "bb", byte(keyCtrlU), byte(keyCtrlY), altY, altY, keyCarriageReturn), "bb"},
+		{"alt-y needs a yank", imp("aa", byte(keyCtrlU), "b", altY, keyCarriageReturn), "b"},
+		{"alt-d", imp("one two", byte(keyCtrlA), keyEscape, "d", keyCarriageReturn), " two"},
+		{"alt-backspace", imp("one two-three", keyEscape, byte(keyDelete), keyCarriageReturn), "one two-"},
+		{"alt-d then ctrl-y", imp("one two", byte(keyCtrlA), keyEscape, "d", keyEscape, "d", byte(keyCtrlY), keyCarriageReturn), "one two"},
+		{"typing is undone a word at a time", imp("echo hello", undo, keyCarriageReturn), "echo"},
+		{"undo everything", imp("echo hello", undo, undo, undo, keyCarriageReturn), ""},
+		{"ctrl-x ctrl-u", imp("echo hello", byte(keyCtrlX), byte(keyCtrlU), keyCarriageReturn), "echo"},
+		{"undo a kill", imp("echo hi", byte(keyCtrlW), undo, keyCarriageReturn), "echo hi"},
+		{"backspaces are undone together", imp("abc", byte(keyBackspace), byte(keyBackspace), undo, keyCarriageReturn), "abc"},
+		{"undo a yank-pop", imp("aa", byte(keyCtrlU), "b", byte(keyCtrlY), altY, undo, keyCarriageReturn), "b"},
+		{"alt-/ redoes", imp("echo hi", byte(keyCtrlW), undo, keyEscape, "/", keyCarriageReturn), "echo "},
+		{"an edit drops the redo", imp("ab", undo, "c", keyEscape, "/", keyCarriageReturn), "c"},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			tr := NewTermReader(bytes.NewReader(test.input), NewTermWriter(io.Discard))
+			item := tr.NextItem()
+			assert.Equal(t, ItemLineInput, item.Type)
+			assert.Equal(t, test.line, item.Literal)
+		})
+	}
+}
+
+func TestUndoReplaceWith(t *testing.T) {
+	tr := NewTermReader(bytes.NewReader(imp("x", byte(keyCtrlUnderscore), byte(keyCtrlUnderscore), keyCarriageReturn)), NewTermWriter(io.Discard))
+	_ = tr.ReplaceWith("ls")
+	_ = tr.ReplaceWith("ls -l")
+
+	// consecutive replacements such as browsing history are one step
+	item := tr.NextItem()
+	assert.Equal(t, "", item.Literal)
+}
+
+func TestRefresh(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	tr := NewTermReader(bytes.NewReader(imp("ac", keyEscape, "[D", "b")), NewTermWriter(out))
+	tr.PromptStringFunc = func() string { return "> " }
+	_, _ = tr.Writer().Commit()
+	out.Reset()
+
+	tr.NextItem()
+	assert.Equal(t, "ac\x1b[1D\r\x1b[J> abc\x1b[1D", out.String())
+	assert.Equal(t, 2, tr.Cursor())
+}
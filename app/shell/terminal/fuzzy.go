@@ -0,0 +1,98 @@
+package terminal
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Scores of the runes matched by fuzzyMatch
+const (
+	scoreMatch       = 16
+	scoreGapStart    = -3
+	scoreGapExtend   = -1
+	bonusConsecutive = 8
+	bonusBoundary    = 8
+	bonusCamelCase   = 7
+)
+
+// fuzzyMatch reports whether the runes of each space separated term of
+// query appear in order in s. The score favours matches at the start of
+// words and in runs. Terms with no upper case letters ignore case.
+func fuzzyMatch(query string, s []rune) (int, []int, bool) {
+	score, positions := 0, []int(nil)
+	for _, term := range strings.Fields(query) {
+		termScore, termPositions, ok := fuzzyMatchTerm([]rune(term), s)
+		if !ok {
+			return 0, nil, false
+		}
+		score += termScore
+		positions = append(positions, termPositions...)
+	}
+	slices.Sort(positions)
+	return score, slices.Compact(positions), true
+}
+
+func fuzzyMatchTerm(term, s []rune) (int, []int, bool) {
+	foldCase := !slices.ContainsFunc(term, unicode.IsUpper)
+	eq := func(a, b rune) bool {
+		if foldCase {
+			return unicode.ToLower(a) == b
+		}
+		return a == b
+	}
+
+	// find where the earliest match ends, then go back from there for
+	// the shortest match ending at the same rune
+	end, j := -1, 0
+	for i, r := range s {
+		if eq(r, term[j]) {
+			j++
+			if j == len(term) {
+				end = i
+				break
+			}
+		}
+	}
+	if end < 0 {
+		return 0, nil, false
+	}
+
+	positions := make([]int, len(term))
+	j = len(term) - 1
+	for i := end; i >= 0 && j >= 0; i-- {
+		if eq(s[i], term[j]) {
+			positions[j] = i
+			j--
+		}
+	}
+
+	score := 0
+	for k, pos := range positions {
+		score += scoreMatch + matchBonus(s, pos)
+		if k == 0 {
+			continue
+		}
+		if gap := pos - positions[k-1] - 1; gap > 0 {
+			score += scoreGapStart + scoreGapExtend*(gap-1)
+		} else {
+			score += bonusConsecutive
+		}
+	}
+	return score, positions, true
+}
+
+// matchBonus scores matching the rune at pos by where it is in a word.
+func matchBonus(s []rune, pos int) int {
+	if pos == 0 {
+		return bonusBoundary
+	}
+	prev, r := s[pos-1], s[pos]
+	switch {
+	case strings.ContainsRune(" /_-.:=", prev):
+		return bonusBoundary
+	case unicode.IsLower(prev) && unicode.IsUpper(r):
+		return bonusCamelCase
+	}
+	return 0
+}
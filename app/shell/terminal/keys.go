@@ -0,0 +1,313 @@
+package terminal
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Key identifies a key decoded from an escape sequence
+type Key int
+
+const (
+	KeyUnknown Key = iota
+	// KeyRune is a character typed along with a modifier, e.g. Alt-b
+	KeyRune
+	KeyEscape
+	KeyEnter
+	KeyTab
+	KeyBackspace
+	KeyUp
+	KeyDown
+	KeyRight
+	KeyLeft
+	KeyHome
+	KeyEnd
+	KeyInsert
+	KeyDelete
+	KeyPageUp
+	KeyPageDown
+	KeyF1
+	KeyF2
+	KeyF3
+	KeyF4
+	KeyF5
+	KeyF6
+	KeyF7
+	KeyF8
+	KeyF9
+	KeyF10
+	KeyF11
+	KeyF12
+	KeyPasteStart
+	KeyPasteEnd
+)
+
+var keyNames = map[Key]string{
+	KeyUnknown:    "unknown",
+	KeyEscape:     "esc",
+	KeyEnter:      "enter",
+	KeyTab:        "tab",
+	KeyBackspace:  "backspace",
+	KeyUp:         "up",
+	KeyDown:       "down",
+	KeyRight:      "right",
+	KeyLeft:       "left",
+	KeyHome:       "home",
+	KeyEnd:        "end",
+	KeyInsert:     "insert",
+	KeyDelete:     "delete",
+	KeyPageUp:     "pageup",
+	KeyPageDown:   "pagedown",
+	KeyPasteStart: "paste-start",
+	KeyPasteEnd:   "paste-end",
+}
+
+// Modifier is a set of modifier keys, with the bits used by xterm in the
+// parameters of its sequences.
+type Modifier int
+
+const (
+	ModShift Modifier = 1 << iota
+	ModAlt
+	ModCtrl
+	ModMeta
+)
+
+// KeyEvent is a key pressed along with its modifiers. Rune is only set
+// for KeyRune.
+type KeyEvent struct {
+	Key  Key
+	Mod  Modifier
+	Rune rune
+}
+
+// String names the key the way it is written in key bindings, e.g.
+// "ctrl+left", "alt+b" or "shift+f5".
+func (k KeyEvent) String() string {
+	b := strings.Builder{}
+	for _, m := range []struct {
+		mod  Modifier
+		name string
+	}{{ModCtrl, "ctrl+"}, {ModAlt, "alt+"}, {ModShift, "shift+"}, {ModMeta, "meta+"}} {
+		if k.Mod&m.mod != 0 {
+			b.WriteString(m.name)
+		}
+	}
+
+	switch {
+	case k.Key == KeyRune:
+		b.WriteRune(k.Rune)
+	case k.Key >= KeyF1 && k.Key <= KeyF12:
+		b.WriteString("f" + strconv.Itoa(int(k.Key-KeyF1)+1))
+	default:
+		b.WriteString(keyNames[k.Key])
+	}
+	return b.String()
+}
+
+// ParseKeyEvent parses a key named the way String names it.
+func ParseKeyEvent(s string) (KeyEvent, error) {
+	ev := KeyEvent{}
+	mods := map[string]Modifier{"ctrl": ModCtrl, "alt": ModAlt, "shift": ModShift, "meta": ModMeta}
+	name := s
+	for {
+		prefix, rest, ok := strings.Cut(name, "+")
+		mod, isMod := mods[prefix]
+		if !ok || !isMod || rest == "" {
+			break
+		}
+		ev.Mod |= mod
+		name = rest
+	}
+
+	if r, size := utf8.DecodeRuneInString(name); size == len(name) && size > 0 {
+		ev.Key, ev.Rune = KeyRune, r
+		return ev, nil
+	}
+	if n, err := strconv.Atoi(strings.TrimPrefix(name, "f")); err == nil && name[0] == 'f' && n >= 1 && n <= 12 {
+		ev.Key = KeyF1 + Key(n-1)
+		return ev, nil
+	}
+	for key, keyName := range keyNames {
+		if keyName == name && key != KeyUnknown {
+			ev.Key = key
+			return ev, nil
+		}
+	}
+	return KeyEvent{}, fmt.Errorf("unknown key %q", s)
+}
+
+// maxEscapeLen bounds the escape sequences decoded, longer ones are
+// dropped as unknown keys.
+const maxEscapeLen = 32
+
+// csiFinalKeys maps the final byte of CSI and SS3 sequences to the key
+// they stand for.
+var csiFinalKeys = map[byte]Key{
+	'A': KeyUp,
+	'B': KeyDown,
+	'C': KeyRight,
+	'D': KeyLeft,
+	'H': KeyHome,
+	'F': KeyEnd,
+	'P': KeyF1,
+	'Q': KeyF2,
+	'R': KeyF3,
+	'S': KeyF4,
+}
+
+// tildeKeys maps the first parameter of `CSI n ~` sequences to the key
+// they stand for.
+var tildeKeys = map[int]Key{
+	1: KeyHome, 2: KeyInsert, 3: KeyDelete, 4: KeyEnd,
+	5: KeyPageUp, 6: KeyPageDown, 7: KeyHome, 8: KeyEnd,
+	11: KeyF1, 12: KeyF2, 13: KeyF3, 14: KeyF4, 15: KeyF5,
+	17: KeyF6, 18: KeyF7, 19: KeyF8, 20: KeyF9, 21: KeyF10,
+	23: KeyF11, 24: KeyF12,
+	200: KeyPasteStart, 201: KeyPasteEnd,
+}
+
+// decodeEscape decodes the escape sequence at the start of b. It returns
+// the number of bytes the sequence takes, or 0 if b ends before it does.
+func decodeEscape(b []byte) (KeyEvent, int) {
+	if len(b) < 2 {
+		return KeyEvent{}, 0
+	}
+
+	switch b[1] {
+	case csi:
+		return decodeCSI(b)
+	case 'O':
+		return decodeSS3(b)
+	case keyEscape:
+		return KeyEvent{Key: KeyEscape}, 1
+	}
+
+	// an escape before a key means it was pressed with Alt
+	if !utf8.FullRune(b[1:]) {
+		return KeyEvent{}, 0
+	}
+	r, size := utf8.DecodeRune(b[1:])
+	ev := controlKeyEvent(r)
+	ev.Mod |= ModAlt
+	return ev, 1 + size
+}
+
+// controlKeyEvent names the key that sends r.
+func controlKeyEvent(r rune) KeyEvent {
+	switch r {
+	case keyCarriageReturn, keyLineFeed:
+		return KeyEvent{Key: KeyEnter}
+	case keyTab:
+		return KeyEvent{Key: KeyTab}
+	case keyBackspace, keyDelete:
+		return KeyEvent{Key: KeyBackspace}
+	case rune(keyEscape):
+		return KeyEvent{Key: KeyEscape}
+	}
+	if r > 0 && r < 32 {
+		return KeyEvent{Key: KeyRune, Mod: ModCtrl, Rune: r + 'a' - 1}
+	}
+	return KeyEvent{Key: KeyRune, Rune: r}
+}
+
+// decodeCSI decodes `ESC [ params final` sequences, including the xterm
+// ones carrying modifiers such as `ESC [ 1 ; 5 C` for Ctrl-Right.
+func decodeCSI(b []byte) (KeyEvent, int) {
+	i := 2
+	for i < len(b) && b[i] >= 0x30 && b[i] <= 0x3F {
+		i++
+	}
+	for i < len(b) && b[i] >= 0x20 && b[i] <= 0x2F {
+		i++
+	}
+	if i >= len(b) {
+		if len(b) >= maxEscapeLen {
+			return KeyEvent{Key: KeyUnknown}, len(b)
+		}
+		return KeyEvent{}, 0
+	}
+	if b[i] < 0x40 || b[i] > 0x7E {
+		return KeyEvent{Key: KeyUnknown}, i
+	}
+
+	final, n := b[i], i+1
+	params := csiParams(string(b[2:i]))
+	ev := KeyEvent{}
+	if len(params) > 1 && params[1] > 1 {
+		ev.Mod = Modifier(params[1] - 1)
+	}
+
+	switch final {
+	case '~':
+		key, ok := tildeKeys[param(params, 0, 0)]
+		if !ok {
+			return KeyEvent{Key: KeyUnknown}, n
+		}
+		ev.Key = key
+	case 'Z':
+		ev.Key, ev.Mod = KeyTab, ev.Mod|ModShift
+	case 'u':
+		// CSI codepoint ; modifiers u, sent by terminals reporting
+		// keys unambiguously
+		key := controlKeyEvent(rune(param(params, 0, 0)))
+		ev.Key, ev.Rune, ev.Mod = key.Key, key.Rune, ev.Mod|key.Mod
+	case '[':
+		// the linux console sends F1 to F5 as ESC [ [ A to E
+		if n >= len(b) {
+			return KeyEvent{}, 0
+		}
+		if b[n] < 'A' || b[n] > 'E' {
+			return KeyEvent{Key: KeyUnknown}, n
+		}
+		ev.Key = KeyF1 + Key(b[n]-'A')
+		n++
+	default:
+		key, ok := csiFinalKeys[final]
+		if !ok || strings.ContainsAny(string(b[2:i]), "<=>?") {
+			return KeyEvent{Key: KeyUnknown}, n
+		}
+		ev.Key = key
+	}
+	return ev, n
+}
+
+// decodeSS3 decodes `ESC O final` sequences sent for the arrows, Home,
+// End and F1 to F4 in application mode.
+func decodeSS3(b []byte) (KeyEvent, int) {
+	if len(b) < 3 {
+		return KeyEvent{}, 0
+	}
+	key, ok := csiFinalKeys[b[2]]
+	if !ok {
+		return KeyEvent{Key: KeyUnknown}, 3
+	}
+	return KeyEvent{Key: key}, 3
+}
+
+// csiParams parses the semicolon separated parameters of a sequence,
+// where empty or invalid parameters are -1.
+func csiParams(s string) []int {
+	if len(s) == 0 {
+		return nil
+	}
+	fields := strings.Split(s, ";")
+	params := make([]int, len(fields))
+	for i, f := range fields {
+		n, err := strconv.Atoi(f)
+		if err != nil {
+			n = -1
+		}
+		params[i] = n
+	}
+	return params
+}
+
+func param(params []int, i, def int) int {
+	if i >= len(params) || params[i] < 0 {
+		return def
+	}
+	return params[i]
+}
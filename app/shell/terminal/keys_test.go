@@ -0,0 +1,104 @@
+package terminal
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeEscape(t *testing.T) {
+	tt := []struct {
+		input string
+		key   KeyEvent
+		n     int
+	}{
+		{"\x1b", KeyEvent{}, 0},
+		{"\x1b[", KeyEvent{}, 0},
+		{"\x1b[1;5", KeyEvent{}, 0},
+		{"\x1b[A", KeyEvent{Key: KeyUp}, 3},
+		{"\x1b[Dabc", KeyEvent{Key: KeyLeft}, 3},
+		{"\x1bOH", KeyEvent{Key: KeyHome}, 3},
+		{"\x1b[1;5C", KeyEvent{Key: KeyRight, Mod: ModCtrl}, 6},
+		{"\x1b[1;2A", KeyEvent{Key: KeyUp, Mod: ModShift}, 6},
+		{"\x1b[1;7D", KeyEvent{Key: KeyLeft, Mod: ModCtrl | ModAlt}, 6},
+		{"\x1b[3~", KeyEvent{Key: KeyDelete}, 4},
+		{"\x1b[3;5~", KeyEvent{Key: KeyDelete, Mod: ModCtrl}, 6},
+		{"\x1b[4~", KeyEvent{Key: KeyEnd}, 4},
+		{"\x1b[6~", KeyEvent{Key: KeyPageDown}, 4},
+		{"\x1b[15~", KeyEvent{Key: KeyF5}, 5},
+		{"\x1b[24;2~", KeyEvent{Key: KeyF12, Mod: ModShift}, 7},
+		{"\x1bOP", KeyEvent{Key: KeyF1}, 3},
+		{"\x1b[1;3Q", KeyEvent{Key: KeyF2, Mod: ModAlt}, 6},
+		{"\x1b[[C", KeyEvent{Key: KeyF3}, 4},
+		{"\x1b[Z", KeyEvent{Key: KeyTab, Mod: ModShift}, 3},
+		{"\x1b[200~", KeyEvent{Key: KeyPasteStart}, 6},
+		{"\x1b[97;5u", KeyEvent{Key: KeyRune, Mod: ModCtrl, Rune: 'a'}, 7},
+		{"\x1b[13;2u", KeyEvent{Key: KeyEnter, Mod: ModShift}, 7},
+		{"\x1b[99~", KeyEvent{Key: KeyUnknown}, 5},
+		{"\x1b[<0;1;1M", KeyEvent{Key: KeyUnknown}, 9},
+		{"\x1bb", KeyEvent{Key: KeyRune, Mod: ModAlt, Rune: 'b'}, 2},
+		{"\x1bé", KeyEvent{Key: KeyRune, Mod: ModAlt, Rune: 'é'}, 3},
+		{"\x1b\xc3", KeyEvent{}, 0},
+		{"\x1b\x7f", KeyEvent{Key: KeyBackspace, Mod: ModAlt}, 2},
+		{"\x1b\x17", KeyEvent{Key: KeyRune, Mod: ModCtrl | ModAlt, Rune: 'w'}, 2},
+		{"\x1b\x1b[A", KeyEvent{Key: KeyEscape}, 1},
+	}
+
+	for _, test := range tt {
+		t.Run(test.input, func(t *testing.T) {
+			key, n := decodeEscape([]byte(test.input))
+			assert.Equal(t, test.key, key)
+			assert.Equal(t, test.n, n)
+		})
+	}
+}
+
+func TestKeyEventString(t *testing.T) {
+	assert.Equal(t, "ctrl+left", KeyEvent{Key: KeyLeft, Mod: ModCtrl}.String())
+	assert.Equal(t, "ctrl+alt+shift+f12", KeyEvent{Key: KeyF12, Mod: ModShift | ModAlt | ModCtrl}.String())
+	assert.Equal(t, "alt+b", KeyEvent{Key: KeyRune, Mod: ModAlt, Rune: 'b'}.String())
+	assert.Equal(t, "pagedown", KeyEvent{Key: KeyPageDown}.String())
+}
+
+func TestKeyItems(t *testing.T) {
+	input := imp(keyEscape, "[15~", keyEscape, "[1;2A", keyEscape, "[99~", keyEscape, "x", "ok", keyCarriageReturn)
+	tr := NewTermReader(bytes.NewReader(input), NewTermWriter(io.Discard))
+
+	items := []Item{
+		{Type: ItemKeyFunction, Literal: "f5", Key: KeyEvent{Key: KeyF5}},
+		{Type: ItemKeyUp, Literal: "shift+up", Key: KeyEvent{Key: KeyUp, Mod: ModShift}},
+		{Type: ItemKeyUnknown, Literal: "unknown", Key: KeyEvent{Key: KeyUnknown}},
+		{Type: ItemKeyAlt, Literal: "alt+x", Key: KeyEvent{Key: KeyRune, Mod: ModAlt, Rune: 'x'}},
+		{Type: ItemLineInput, Literal: "ok"},
+	}
+	for _, want := range items {
+		assert.Equal(t, want, tr.NextItem())
+	}
+}
+
+func TestLoneEscape(t *testing.T) {
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	tr := NewTermReader(pr, NewTermWriter(io.Discard))
+	tr.EscapeTimeout = 10 * time.Millisecond
+
+	go func() {
+		_, _ = pw.Write(imp(keyEscape))
+	}()
+	item := tr.NextItem()
+	assert.Equal(t, ItemKeyEscape, item.Type)
+
+	// a sequence split across reads is still decoded as one key
+	tr.EscapeTimeout = time.Second
+	go func() {
+		_, _ = pw.Write(imp("ab", keyEscape))
+		time.Sleep(5 * time.Millisecond)
+		_, _ = pw.Write(imp("[D", "c", keyCarriageReturn))
+	}()
+	item = tr.NextItem()
+	assert.Equal(t, ItemLineInput, item.Type)
+	assert.Equal(t, "acb", item.Literal)
+}
@@ -0,0 +1,55 @@
+package terminal
+
+import "slices"
+
+// killRingSize bounds the number of kills remembered
+const killRingSize = 32
+
+// killRing keeps the text removed by kill commands for yanking it back,
+// the most recent last.
+type killRing struct {
+	entries [][]rune
+	// yanked is the entry last yanked, which yank-pop moves back from
+	yanked int
+}
+
+func (k *killRing) push(text []rune) {
+	k.entries = append(k.entries, slices.Clone(text))
+	if len(k.entries) > killRingSize {
+		k.entries = k.entries[1:]
+	}
+}
+
+// extend adds text to the most recent kill, in front of it if before is
+// set, so that consecutive kills are yanked back as one.
+func (k *killRing) extend(text []rune, before bool) {
+	if len(k.entries) == 0 {
+		k.push(text)
+		return
+	}
+	top := &k.entries[len(k.entries)-1]
+	if before {
+		*top = slices.Concat(text, *top)
+	} else {
+		*top = slices.Concat(*top, text)
+	}
+}
+
+// top returns the most recent kill and makes it the one yanked.
+func (k *killRing) top() ([]rune, bool) {
+	if len(k.entries) == 0 {
+		return nil, false
+	}
+	k.yanked = len(k.entries) - 1
+	return k.entries[k.yanked], true
+}
+
+// rotate returns the kill before the one last yanked, wrapping around
+// to the most recent one.
+func (k *killRing) rotate() ([]rune, bool) {
+	if len(k.entries) == 0 {
+		return nil, false
+	}
+	k.yanked = (k.yanked - 1 + len(k.entries)) % len(k.entries)
+	return k.entries[k.yanked], true
+}
@@ -0,0 +1,93 @@
+package terminal
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPaste(t *testing.T) {
+	start, end := "\x1b[200~", "\x1b[201~"
+
+	tt := []struct {
+		name  string
+		input []byte
+		items []Item
+	}{
+		{
+			"line breaks become newlines",
+			imp(start, "echo a\recho b\r\n", end),
+			[]Item{{Type: ItemPaste, Literal: "echo a\necho b\n"}},
+		},
+		{
+			"keys in the paste are text",
+			imp(start, "\t\x1b[A\x03", end, "x\r"),
+			[]Item{{Type: ItemPaste, Literal: "\t\x1b[A\x03"}, {Type: ItemLineInput, Literal: "x"}},
+		},
+		{
+			"stray end",
+			imp(end, "x\r"),
+			[]Item{{Type: ItemLineInput, Literal: "x"}},
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			// pastes may come split in any way
+			r := iotest.OneByteReader(bytes.NewReader(test.input))
+			tr := NewTermReader(r, NewTermWriter(io.Discard))
+			for _, want := range test.items {
+				assert.Equal(t, want, tr.NextItem())
+			}
+		})
+	}
+}
+
+func TestPasteInsert(t *testing.T) {
+	tr := NewTermReader(bytes.NewReader(imp("\x1b[200~b\nc\x1b[201~", byte(keyCtrlUnderscore), keyCarriageReturn)), NewTermWriter(io.Discard))
+	_ = tr.ReplaceWith("ad")
+	tr.SetCursor(1)
+
+	item := tr.NextItem()
+	assert.Equal(t, ItemPaste, item.Type)
+	tr.Insert(item.Literal)
+	assert.Equal(t, "ab\ncd", tr.Line())
+	assert.Equal(t, 4, tr.Cursor())
+
+	// a paste is undone at once
+	item = tr.NextItem()
+	assert.Equal(t, "ad", item.Literal)
+}
+
+func TestPasteReadLine(t *testing.T) {
+	tr := NewTermReader(bytes.NewReader(imp("\x1b[200~one\ntwo\x1b[201~\r")), NewTermWriter(io.Discard))
+
+	// a newline in the paste ends the line read
+	line, err := tr.ReadLine(ReadLineOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "one", line)
+	line, err = tr.ReadLine(ReadLineOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "two", line)
+}
+
+func TestPasteMode(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	tr := NewTermReader(bytes.NewReader(imp("x\r")), NewTermWriter(out))
+	tr.SetBracketedPaste(true)
+
+	_ = tr.Ready()
+	_ = tr.Ready()
+	tr.NextItem()
+	assert.Equal(t, 1, strings.Count(out.String(), "\x1b[?2004h"))
+	assert.True(t, strings.HasSuffix(out.String(), "x\x1b[?2004l\r\r\n"), out.String())
+
+	out.Reset()
+	_ = tr.Ready()
+	tr.SetBracketedPaste(false)
+	assert.True(t, strings.HasSuffix(out.String(), "\x1b[?2004h\r\x1b[J$ \x1b[?2004l"), out.String())
+}
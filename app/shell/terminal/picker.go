@@ -0,0 +1,353 @@
+package terminal
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	defaultPickHeight = 10
+	// pickPreviewHeight bounds the lines of preview shown
+	pickPreviewHeight = 8
+)
+
+var (
+	clearBelow = []byte{keyEscape, csi, 'J'}
+	bold       = []byte{keyEscape, csi, '1', 'm'}
+	noBold     = []byte{keyEscape, csi, '2', '2', 'm'}
+)
+
+// PickOptions configures a single call to Pick.
+type PickOptions struct {
+	// Prompt is shown before the query, "> " by default
+	Prompt string
+	// Query is the query the pick starts with
+	Query string
+	// Multi allows marking several candidates with Tab
+	Multi bool
+	// Height is the number of candidates shown at once
+	Height int
+	// Preview returns the text shown below the candidates for the
+	// highlighted one
+	Preview func(candidate string) string
+}
+
+// picker is the state of a pick in progress, drawn below the line
+type picker struct {
+	opts       PickOptions
+	candidates [][]rune
+	query      []rune
+	matches    []pickMatch
+	// selected is the highlighted match and offset the first one shown
+	selected int
+	offset   int
+	// marked holds the indexes of the candidates marked with Tab
+	marked map[int]bool
+
+	done, cancelled bool
+}
+
+type pickMatch struct {
+	index     int
+	score     int
+	positions []int
+}
+
+// Pick lets the user choose among candidates, narrowed down by fuzzy
+// matching a query, in a list drawn below the line. Ties keep the order
+// of candidates. It returns the chosen candidates, none if the pick was
+// cancelled.
+func (t *Terminal) Pick(candidates []string, opts PickOptions) ([]string, error) {
+	if opts.Prompt == "" {
+		opts.Prompt = "> "
+	}
+	if opts.Height <= 0 {
+		opts.Height = defaultPickHeight
+	}
+
+	p := &picker{
+		opts:   opts,
+		query:  []rune(opts.Query),
+		marked: map[int]bool{},
+	}
+	for _, c := range candidates {
+		p.candidates = append(p.candidates, []rune(c))
+	}
+	p.filter()
+
+	t.picker = p
+	defer func() {
+		t.picker = nil
+		t.tw.StageByte(keyCarriageReturn)
+		t.tw.Stage(clearBelow)
+		t.tw.Stage(cursorUp(1))
+		t.screen.cursor = position{row: t.screen.end.row}
+		t.refresh()
+	}()
+	t.stageMoveTo(t.screen.end)
+	t.tw.Stage(newLine)
+	t.renderPicker()
+
+	// items posted while picking belong to the shell's read loop
+	posted := make([]Item, 0)
+	defer func() {
+		for _, item := range posted {
+			t.Post(item)
+		}
+	}()
+
+	for {
+		item := t.NextItem()
+		switch item.Type {
+		case ItemSignal:
+			if item.Literal == "WINCH" {
+				t.Resize()
+			}
+			posted = append(posted, item)
+		case ItemPicked:
+			return p.result(candidates), nil
+		case ItemEOF:
+			return nil, io.EOF
+		case ItemError:
+			return nil, fmt.Errorf("pick: %s", item.Literal)
+		}
+	}
+}
+
+// readPick handles the keys typed while picking.
+func readPick(t *Terminal) stateFunc {
+	key, n := t.decodeKey()
+	if n == 0 {
+		if t.view[0] == keyEscape && !t.escapeExpired {
+			return advanceEscape
+		}
+		return advance
+	}
+	t.escapeExpired = false
+	t.advanceView(n)
+
+	p := t.picker
+	p.handleKey(key)
+	if p.done {
+		return t.emit(ItemPicked, "")
+	}
+	t.renderPicker()
+	return readInput
+}
+
+// decodeKey decodes the key at the start of the view. It returns 0 if
+// the view ends before the key does.
+func (t *Terminal) decodeKey() (KeyEvent, int) {
+	if t.view[0] == keyEscape {
+		key, n := decodeEscape(t.view)
+		if n == 0 && t.escapeExpired {
+			return KeyEvent{Key: KeyEscape}, 1
+		}
+		return key, n
+	}
+	if !utf8.FullRune(t.view) {
+		return KeyEvent{}, 0
+	}
+	r, size := utf8.DecodeRune(t.view)
+	return controlKeyEvent(r), size
+}
+
+func (p *picker) handleKey(key KeyEvent) {
+	ctrl := func(r rune) KeyEvent { return KeyEvent{Key: KeyRune, Mod: ModCtrl, Rune: r} }
+
+	switch key {
+	case KeyEvent{Key: KeyEnter}:
+		p.done = true
+	case KeyEvent{Key: KeyEscape}, ctrl('c'), ctrl('g'):
+		p.done, p.cancelled = true, true
+	case KeyEvent{Key: KeyUp}, ctrl('p'), ctrl('k'):
+		p.move(-1)
+	case KeyEvent{Key: KeyDown}, ctrl('n'):
+		p.move(1)
+	case KeyEvent{Key: KeyPageUp}:
+		p.move(-p.opts.Height)
+	case KeyEvent{Key: KeyPageDown}:
+		p.move(p.opts.Height)
+	case KeyEvent{Key: KeyTab}, KeyEvent{Key: KeyTab, Mod: ModShift}:
+		if !p.opts.Multi || len(p.matches) == 0 {
+			return
+		}
+		index := p.matches[p.selected].index
+		p.marked[index] = !p.marked[index]
+		if key.Mod == ModShift {
+			p.move(-1)
+		} else {
+			p.move(1)
+		}
+	case KeyEvent{Key: KeyBackspace}:
+		if len(p.query) > 0 {
+			p.query = p.query[:prevGrapheme(p.query, len(p.query))]
+			p.filter()
+		}
+	case ctrl('u'):
+		p.query = p.query[:0]
+		p.filter()
+	default:
+		if key.Key == KeyRune && key.Mod == 0 {
+			p.query = append(p.query, key.Rune)
+			p.filter()
+		}
+	}
+}
+
+// filter matches the candidates against the query, best first.
+func (p *picker) filter() {
+	p.matches = p.matches[:0]
+	query := string(p.query)
+	for i, c := range p.candidates {
+		if score, positions, ok := fuzzyMatch(query, c); ok {
+			p.matches = append(p.matches, pickMatch{index: i, score: score, positions: positions})
+		}
+	}
+	slices.SortStableFunc(p.matches, func(a, b pickMatch) int {
+		return b.score - a.score
+	})
+	p.selected, p.offset = 0, 0
+}
+
+func (p *picker) move(delta int) {
+	if len(p.matches) == 0 {
+		return
+	}
+	p.selected = max(0, min(p.selected+delta, len(p.matches)-1))
+	if p.selected < p.offset {
+		p.offset = p.selected
+	}
+	if p.selected >= p.offset+p.opts.Height {
+		p.offset = p.selected - p.opts.Height + 1
+	}
+}
+
+// result returns the marked candidates in their order, or else the
+// highlighted one.
+func (p *picker) result(candidates []string) []string {
+	if p.cancelled || len(p.matches) == 0 {
+		return nil
+	}
+
+	picked := make([]string, 0)
+	for i, c := range candidates {
+		if p.marked[i] {
+			picked = append(picked, c)
+		}
+	}
+	if len(picked) == 0 {
+		picked = append(picked, candidates[p.matches[p.selected].index])
+	}
+	return picked
+}
+
+// renderPicker draws the query, the matches and the preview from the
+// row below the line, and leaves the cursor after the query.
+func (t *Terminal) renderPicker() {
+	p := t.picker
+	width := t.width()
+	rows := 0
+	nextRow := func() {
+		t.tw.Stage(ClearLine)
+		t.tw.Stage(newLine)
+		rows++
+	}
+
+	// the end of a query too long for the row is shown
+	promptWidth := textWidth([]rune(stripEscapes(p.opts.Prompt)))
+	query := p.query
+	for len(query) > 0 && promptWidth+textWidth(query) >= width {
+		query = query[nextGrapheme(query, 0):]
+	}
+	t.tw.StageByte(keyCarriageReturn)
+	t.tw.Stage(clearBelow)
+	t.tw.StageString(p.opts.Prompt + string(query))
+	nextRow()
+
+	t.tw.StagePushForegroundColor(Grey)
+	info := "  " + strconv.Itoa(len(p.matches)) + "/" + strconv.Itoa(len(p.candidates))
+	if n := countMarked(p.marked); n > 0 {
+		info += " (" + strconv.Itoa(n) + " marked)"
+	}
+	t.tw.StageString(info)
+	t.tw.StagePopForegroundColor()
+
+	for i := p.offset; i < min(len(p.matches), p.offset+p.opts.Height); i++ {
+		nextRow()
+		m := p.matches[i]
+		cursor, mark := ' ', ' '
+		if i == p.selected {
+			cursor = '>'
+			t.tw.Stage(reverseVideo)
+		}
+		if p.marked[m.index] {
+			mark = '*'
+		}
+		t.tw.StageString(string([]rune{cursor, mark, ' '}))
+		t.stageMatch(p.candidates[m.index], m.positions, width-3)
+		t.tw.Stage(noReverseVideo)
+	}
+
+	if p.opts.Preview != nil && len(p.matches) > 0 {
+		nextRow()
+		t.tw.StagePushForegroundColor(Grey)
+		t.tw.StageString(strings.Repeat("─", min(width, 40)))
+		t.tw.StagePopForegroundColor()
+
+		preview := p.opts.Preview(string(p.candidates[p.matches[p.selected].index]))
+		lines := strings.Split(strings.TrimRight(preview, "\n"), "\n")
+		for _, line := range lines[:min(len(lines), pickPreviewHeight)] {
+			nextRow()
+			t.tw.StageString(string(truncateLine([]rune(line), width)))
+		}
+	}
+
+	t.tw.Stage(ClearLine)
+	t.tw.Stage(cursorUp(rows))
+	t.tw.StageByte(keyCarriageReturn)
+	t.tw.StageMove(promptWidth + textWidth(query))
+	t.tw.Commit()
+}
+
+// stageMatch writes what fits in width columns of s with the runes at
+// positions in bold.
+func (t *Terminal) stageMatch(s []rune, positions []int, width int) {
+	s = truncateLine(s, width)
+	for i, r := range s {
+		matched := slices.Contains(positions, i)
+		if matched {
+			t.tw.Stage(bold)
+		}
+		t.tw.StageRune(r)
+		if matched {
+			t.tw.Stage(noBold)
+		}
+	}
+}
+
+// truncateLine returns what fits in width columns of s.
+func truncateLine(s []rune, width int) []rune {
+	// tabs and other control characters would throw off the layout
+	s = slices.Clone(s)
+	for i, r := range s {
+		if r < 32 {
+			s[i] = ' '
+		}
+	}
+	return truncateWidth(s, max(0, width))
+}
+
+func countMarked(marked map[int]bool) int {
+	n := 0
+	for _, m := range marked {
+		if m {
+			n++
+		}
+	}
+	return n
+}
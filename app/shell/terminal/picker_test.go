@@ -0,0 +1,134 @@
+package terminal
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFuzzyMatch(t *testing.T) {
+	tt := []struct {
+		query     string
+		s         string
+		ok        bool
+		positions []int
+	}{
+		{"", "anything", true, nil},
+		{"gst", "git status", true, []int{0, 4, 5}},
+		{"gst", "git stash", true, []int{0, 4, 5}},
+		{"tsg", "git status", false, nil},
+		{"Git", "git status", false, nil},
+		{"git", "Git status", true, []int{0, 1, 2}},
+		{"st sh", "git stash", true, []int{4, 5, 7, 8}},
+		{"ab", "xaxab", true, []int{3, 4}},
+	}
+
+	for _, test := range tt {
+		t.Run(test.query+" in "+test.s, func(t *testing.T) {
+			_, positions, ok := fuzzyMatch(test.query, []rune(test.s))
+			assert.Equal(t, test.ok, ok)
+			assert.Equal(t, test.positions, positions)
+		})
+	}
+}
+
+func TestFuzzyMatchScore(t *testing.T) {
+	score := func(query, s string) int {
+		score, _, _ := fuzzyMatch(query, []rune(s))
+		return score
+	}
+
+	assert.Greater(t, score("ab", "ab"), score("ab", "a-b"))
+	assert.Greater(t, score("fb", "foo-bar"), score("fb", "xfxb"))
+	assert.Greater(t, score("mf", "myFile"), score("mf", "myyf"))
+}
+
+func TestPick(t *testing.T) {
+	down, up := imp(keyEscape, "[B"), imp(keyEscape, "[A")
+	candidates := []string{"git status", "go test ./...", "git stash", "ls -l"}
+
+	tt := []struct {
+		name   string
+		input  []byte
+		opts   PickOptions
+		picked []string
+	}{
+		{"enter picks the first", imp(keyCarriageReturn), PickOptions{}, []string{"git status"}},
+		{"arrows move", imp(down, down, up, keyCarriageReturn), PickOptions{}, []string{"go test ./..."}},
+		{"query narrows", imp("gsh", keyCarriageReturn), PickOptions{}, []string{"git stash"}},
+		{"backspace widens", imp("lsx", byte(keyDelete), keyCarriageReturn), PickOptions{}, []string{"ls -l"}},
+		{"initial query", imp(keyCarriageReturn), PickOptions{Query: "ls"}, []string{"ls -l"}},
+		{"esc cancels", imp(keyEscape, keyEscape), PickOptions{}, nil},
+		{"ctrl-g cancels", imp(byte(keyCtrlG)), PickOptions{}, nil},
+		{"no match", imp("zzz", keyCarriageReturn), PickOptions{}, nil},
+		{"tab marks", imp(keyTab, down, keyTab, keyCarriageReturn), PickOptions{Multi: true}, []string{"git status", "git stash"}},
+		{"tab unmarks", imp(keyTab, up, keyTab, keyCarriageReturn), PickOptions{Multi: true}, []string{"go test ./..."}},
+		{"tab needs multi", imp(keyTab, keyCarriageReturn), PickOptions{}, []string{"git status"}},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			tr := NewTermReader(bytes.NewReader(test.input), NewTermWriter(io.Discard))
+			picked, err := tr.Pick(candidates, test.opts)
+			assert.NoError(t, err)
+			assert.Equal(t, test.picked, picked)
+		})
+	}
+}
+
+func TestPickRender(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	tr := NewTermReader(bytes.NewReader(imp("b", keyCarriageReturn)), NewTermWriter(out))
+	tr.PromptStringFunc = func() string { return "$ " }
+	_, _ = tr.Writer().Commit()
+	out.Reset()
+
+	picked, err := tr.Pick([]string{"abc", "xyz"}, PickOptions{
+		Preview: func(c string) string { return "preview of " + c },
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"abc"}, picked)
+
+	// the last drawing before enter, from the row below the line
+	frames := strings.Split(out.String(), "\r\x1b[J")
+	frame := frames[len(frames)-3]
+	assert.Contains(t, frame, "> b\x1b[K\r\r\n")
+	assert.Contains(t, frame, "  1/2")
+	assert.Contains(t, frame, "\x1b[7m>  a\x1b[1mb\x1b[22mc\x1b[27m")
+	assert.Contains(t, frame, "preview of abc")
+	assert.True(t, strings.HasSuffix(frame, "\x1b[4A\r\x1b[3C"), frame)
+	// the list is cleared and the line drawn again
+	assert.True(t, strings.HasSuffix(out.String(), "\r\x1b[J\x1b[1A\r\x1b[J$ "), out.String())
+}
+
+func TestBind(t *testing.T) {
+	tr := NewTermReader(bytes.NewReader(imp("a", byte(keyCtrlR), keyEscape, "c", byte(keyCtrlY), keyCarriageReturn)), NewTermWriter(io.Discard))
+	ctrlR, err := ParseKeyEvent("ctrl+r")
+	assert.NoError(t, err)
+	altC, err := ParseKeyEvent("alt+c")
+	assert.NoError(t, err)
+	tr.Bind(ctrlR)
+	tr.Bind(altC)
+
+	item := tr.NextItem()
+	assert.Equal(t, Item{Type: ItemKeyBound, Literal: "ctrl+r", Key: ctrlR}, item)
+	tr.Insert("bc")
+	item = tr.NextItem()
+	assert.Equal(t, Item{Type: ItemKeyBound, Literal: "alt+c", Key: altC}, item)
+	tr.Unbind(altC)
+	item = tr.NextItem()
+	assert.Equal(t, "abc", item.Literal)
+}
+
+func TestParseKeyEvent(t *testing.T) {
+	for _, name := range []string{"ctrl+t", "alt+c", "ctrl+alt+left", "shift+f5", "+", "ctrl++", "pageup", "é"} {
+		key, err := ParseKeyEvent(name)
+		assert.NoError(t, err)
+		assert.Equal(t, name, key.String())
+	}
+	_, err := ParseKeyEvent("ctrl+nope")
+	assert.Error(t, err)
+}
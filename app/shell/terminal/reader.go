@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"time"
+	"unicode"
 	"unicode/utf8"
 
 	"github.com/codecrafters-io/shell-starter-go/assert"
@@ -15,7 +17,6 @@ import (
 const (
 	keyCtrlC = 3 // ^C
 	// keyCtrlD          = 4
-	// keyCtrlU          = 21
 	keyBackspace      = 8
 	keyDelete         = 127
 	keyCarriageReturn = '\r'
@@ -23,6 +24,7 @@ const (
 	keyTab            = '\t'
 
 	keyEscape byte = 0x1B // 27
+	bell      byte = 0x07
 	// Control Sequence Introducer
 	csi byte = 0x5B // '['
 )
@@ -34,20 +36,25 @@ var (
 	ClearLine   = []byte{keyEscape, csi, 'K'}
 	clearScreen = []byte{keyEscape, '[', '2', 'J'}
 
-	pasteStart = []byte{keyEscape, '[', '2', '0', '0', '~'}
-	// pasteEnd   = []byte{keyEscape, '[', '2', '0', '1', '~'}
-
-	resetColor   = []byte{keyEscape, '[', '0', 'm'}
-	Purple       = []byte{keyEscape, '[', '3', '8', ';', '5', ';', '1', '4', '1', 'm'}
-	Grey         = []byte{keyEscape, '[', '9', '0', 'm'}
-	Cyan         = []byte{keyEscape, '[', '3', '6', 'm'}
-	PastelRed    = []byte("\x1b[38;2;255;140;140m") // soft pink-red
-	Salmon       = []byte("\x1b[38;2;255;160;122m") // salmon / coral-ish
-	Rose         = []byte("\x1b[38;2;255;120;170m") // rosy magenta-red
-	Red          = []byte{0x1b, '[', '3', '1', 'm'}
-	OffWhiteWarm = []byte("\x1b[38;2;245;244;240m") // warm paper
-	OffWhiteCool = []byte("\x1b[38;2;236;239;244m") // cool soft gray
-	Ivory        = []byte("\x1b[38;2;255;252;240m") // ivory (very light)
+	// pasteEnd ends the text pasted in bracketed paste mode, which is
+	// turned on and off by the pasteMode sequences
+	pasteEnd     = []byte{keyEscape, csi, '2', '0', '1', '~'}
+	pasteModeOn  = []byte{keyEscape, csi, '?', '2', '0', '0', '4', 'h'}
+	pasteModeOff = []byte{keyEscape, csi, '?', '2', '0', '0', '4', 'l'}
+
+	resetColor     = []byte{keyEscape, '[', '0', 'm'}
+	reverseVideo   = []byte{keyEscape, '[', '7', 'm'}
+	noReverseVideo = []byte{keyEscape, '[', '2', '7', 'm'}
+	Purple         = []byte{keyEscape, '[', '3', '8', ';', '5', ';', '1', '4', '1', 'm'}
+	Grey           = []byte{keyEscape, '[', '9', '0', 'm'}
+	Cyan           = []byte{keyEscape, '[', '3', '6', 'm'}
+	PastelRed      = []byte("\x1b[38;2;255;140;140m") // soft pink-red
+	Salmon         = []byte("\x1b[38;2;255;160;122m") // salmon / coral-ish
+	Rose           = []byte("\x1b[38;2;255;120;170m") // rosy magenta-red
+	Red            = []byte{0x1b, '[', '3', '1', 'm'}
+	OffWhiteWarm   = []byte("\x1b[38;2;245;244;240m") // warm paper
+	OffWhiteCool   = []byte("\x1b[38;2;236;239;244m") // cool soft gray
+	Ivory          = []byte("\x1b[38;2;255;252;240m") // ivory (very light)
 )
 
 type ItemType int
@@ -63,8 +70,61 @@ const (
 	ItemKeyTab
 	ItemBackspace
 	ItemKeyUnknown
+	ItemTimeout
+	// ItemSignal is posted from outside of the terminal, e.g. when
+	// the shell receives a signal, and carries the signal name
+	ItemSignal
+	// The keys below are only emitted when the line editor has no
+	// binding for them. Item.Key tells the modifiers they were pressed
+	// with.
+	ItemKeyLeft
+	ItemKeyRight
+	ItemKeyHome
+	ItemKeyEnd
+	ItemKeyInsert
+	ItemKeyDelete
+	ItemKeyPageUp
+	ItemKeyPageDown
+	// ItemKeyFunction is one of F1 to F12
+	ItemKeyFunction
+	ItemKeyEscape
+	// ItemKeyAlt is a character, Enter or Backspace pressed with Alt
+	ItemKeyAlt
+	// ItemSearch asks for the history entry matching the search in
+	// progress, see Terminal.Search
+	ItemSearch
+	// ItemPicked ends a pick, see Terminal.Pick
+	ItemPicked
+	// ItemKeyBound is a key bound with Terminal.Bind, in Item.Key
+	ItemKeyBound
+	// ItemPaste carries text pasted in bracketed paste mode, for the
+	// shell to insert with Terminal.Insert
+	ItemPaste
 )
 
+// keyItemTypes maps the decoded keys to the items emitted for them
+var keyItemTypes = map[Key]ItemType{
+	KeyUp:        ItemKeyUp,
+	KeyDown:      ItemKeyDown,
+	KeyLeft:      ItemKeyLeft,
+	KeyRight:     ItemKeyRight,
+	KeyHome:      ItemKeyHome,
+	KeyEnd:       ItemKeyEnd,
+	KeyInsert:    ItemKeyInsert,
+	KeyDelete:    ItemKeyDelete,
+	KeyPageUp:    ItemKeyPageUp,
+	KeyPageDown:  ItemKeyPageDown,
+	KeyTab:       ItemKeyTab,
+	KeyEscape:    ItemKeyEscape,
+	KeyRune:      ItemKeyAlt,
+	KeyEnter:     ItemKeyAlt,
+	KeyBackspace: ItemKeyAlt,
+}
+
+// defaultEscapeTimeout is how long the rest of an escape sequence is
+// waited for before the escape is taken for the Esc key.
+const defaultEscapeTimeout = 50 * time.Millisecond
+
 var (
 	defaultPromptFunc = func() string { return "$ " }
 )
@@ -72,6 +132,8 @@ var (
 type Item struct {
 	Type    ItemType
 	Literal string
+	// Key is the decoded key for the items emitted for escape sequences
+	Key KeyEvent
 }
 
 type stateFunc func(*Terminal) stateFunc
@@ -80,21 +142,102 @@ type Terminal struct {
 	r  io.Reader
 	tw *TermWriter
 
-	// line is the current user input
-	line []rune
+	// line is the current user input and cursor the position in it
+	// where keys are inserted
+	line   []rune
+	cursor int
+	// kills holds the text removed by kill commands, yankStart is where
+	// the text last yanked from it starts
+	kills     killRing
+	yankStart int
+	undos     undoStack
+	lastEdit  editKind
+	// vi is the state of the vi line editor, nil in emacs mode
+	vi *viState
+	// search is the history search in progress and lastSearch the
+	// query of the last one
+	search     *searchState
+	lastSearch string
+	picker     *picker
+	// bound holds the keys emitted as ItemKeyBound rather than edits
+	bound map[KeyEvent]bool
+
+	// bracketedPaste is set when pastes are to be marked while a line
+	// is read, pasteMode when the terminal was asked to, and pasted
+	// holds the text of the paste being read
+	bracketedPaste bool
+	pasteMode      bool
+	pasting        bool
+	pasted         []byte
+
+	// screen is what was last drawn of the prompt and the line
+	screen screen
+
 	item Item
 	view []byte
-	buf  [256]byte
+
+	// reads are served by a separate goroutine so that waiting
+	// for input can be abandoned once the deadline passes
+	readReqs    chan struct{}
+	readResults chan readResult
+	readPending bool
+	deadline    time.Time
+	posted      chan Item
+
+	silent   bool
+	delim    rune
+	hasDelim bool
+	nchars   int
+
+	// escapeExpired is set once the rest of an escape sequence was
+	// waited for in vain
+	escapeExpired bool
+	// EscapeTimeout is how long to wait for the rest of an escape
+	// sequence before taking the escape for the Esc key
+	EscapeTimeout time.Duration
 
 	CharacterReadHook func(r rune)
 	PromptStringFunc  func() string
+	// SizeFunc returns the width and height of the terminal, which
+	// long lines are wrapped to
+	SizeFunc func() (width, height int, err error)
+}
+
+type readResult struct {
+	b   []byte
+	err error
 }
 
 func NewTermReader(r io.Reader, tw *TermWriter) *Terminal {
-	return &Terminal{
+	t := &Terminal{
 		PromptStringFunc: defaultPromptFunc,
+		EscapeTimeout:    defaultEscapeTimeout,
 		r:                r,
 		tw:               tw,
+		readReqs:         make(chan struct{}),
+		readResults:      make(chan readResult),
+		posted:           make(chan Item, 16),
+	}
+	go t.readLoop()
+	return t
+}
+
+func (t *Terminal) readLoop() {
+	buf := make([]byte, 256)
+	for range t.readReqs {
+		n, err := t.r.Read(buf)
+		t.readResults <- readResult{bytes.Clone(buf[:n]), err}
+	}
+}
+
+// Post queues item to be returned by NextItem ahead of any further
+// input. It is safe to call from other goroutines.
+func (t *Terminal) Post(item Item) {
+	select {
+	case t.posted <- item:
+	default:
+		// drop the item rather than block the caller when
+		// nothing has been reading for a while
 	}
 }
 
@@ -112,7 +255,7 @@ func (t *Terminal) NextItem() Item {
 		Literal: "EOF",
 	}
 
-	state := advance
+	state := readInput
 	for {
 		state = state(t)
 		if state == nil {
@@ -129,21 +272,31 @@ func (t *Terminal) prompt() string {
 	return defaultPromptFunc()
 }
 
+// ReplaceWith replaces the line with input, as an edit that can be
+// undone.
 func (t *Terminal) ReplaceWith(input string) error {
+	if input != string(t.line) {
+		t.saveUndo(editReplace)
+		t.lastEdit = editReplace
+	}
 	t.line = []rune(input)
-	t.tw.StageByte(keyCarriageReturn)
-	t.tw.StageString(t.prompt())
-	t.tw.Stage(ClearLine)
-	t.tw.StageString(input)
-	t.tw.Commit()
+	t.cursor = t.cursorLimit()
+	t.refresh()
 	return nil
 }
 
 func (t *Terminal) Ready() error {
-	t.tw.StageByte(keyCarriageReturn)
-	t.tw.StageString(t.prompt())
-	if len(t.line) > 0 {
-		t.tw.StageString(t.Line())
+	t.setPasteMode(t.bracketedPaste)
+	// the prompt is drawn anew where the cursor is
+	t.screen = screen{}
+	if t.search != nil {
+		t.renderSearch()
+		return nil
+	}
+	if t.silent {
+		t.draw(t.prompt(), nil, 0, func() {})
+	} else {
+		t.draw(t.prompt(), t.line, t.cursor, t.stageLine)
 	}
 	_, err := t.tw.Commit()
 	return err
@@ -155,17 +308,6 @@ func (t *Terminal) ClearScreen() error {
 	return t.Ready()
 }
 
-func (t *Terminal) eraseLastKey() {
-	if len(t.line) == 0 {
-		return
-	}
-
-	t.line = t.line[:len(t.line)-1]
-	t.tw.Stage([]byte{0x1b, '[', '1', 'D'})
-	t.tw.Stage([]byte{0x1b, '[', '0', 'K'})
-	t.tw.Commit()
-}
-
 func (t *Terminal) error(e error) stateFunc {
 	t.item = Item{
 		Type:    ItemError,
@@ -200,23 +342,58 @@ func (t *Terminal) isViewCurrent(r byte) bool {
 }
 
 func advance(t *Terminal) stateFunc {
-	if len(t.view) > 0 {
-		// moves view to front of bof
-		// effectively removing the already read parts
-		// of the view
-		n := copy(t.buf[:], t.view)
-		t.view = t.buf[:n]
+	return t.fill(t.deadline, timedOut)
+}
+
+// advanceEscape reads the rest of an escape sequence, giving up after
+// EscapeTimeout so that a lone escape is not held back.
+func advanceEscape(t *Terminal) stateFunc {
+	deadline := time.Now().Add(t.EscapeTimeout)
+	if !t.deadline.IsZero() && t.deadline.Before(deadline) {
+		deadline = t.deadline
 	}
+	return t.fill(deadline, func(t *Terminal) stateFunc {
+		t.escapeExpired = true
+		return readInput
+	})
+}
 
-	readBuf := t.buf[len(t.view):]
+func timedOut(t *Terminal) stateFunc {
+	return t.emit(ItemTimeout, "")
+}
 
-	n, err := t.r.Read(readBuf)
-	if err != nil && !errors.Is(err, io.EOF) {
-		return t.error(fmt.Errorf("advance: %w", err))
+// fill waits for more input until deadline, if set, and then continues
+// with onTimeout.
+func (t *Terminal) fill(deadline time.Time, onTimeout stateFunc) stateFunc {
+	if !t.readPending {
+		t.readReqs <- struct{}{}
+		t.readPending = true
 	}
 
-	t.view = t.buf[:n+len(t.view)]
-	return readInput
+	var timeout <-chan time.Time
+	if !deadline.IsZero() {
+		timer := time.NewTimer(time.Until(deadline))
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case res := <-t.readResults:
+		t.readPending = false
+		if res.err != nil && !errors.Is(res.err, io.EOF) {
+			return t.error(fmt.Errorf("advance: %w", res.err))
+		}
+		if len(res.b) == 0 && errors.Is(res.err, io.EOF) {
+			return t.emit(ItemEOF, "EOF")
+		}
+
+		t.view = append(t.view, res.b...)
+		return readInput
+	case item := <-t.posted:
+		return t.emitItem(item)
+	case <-timeout:
+		return onTimeout
+	}
 }
 
 func readInput(t *Terminal) stateFunc {
@@ -224,9 +401,30 @@ func readInput(t *Terminal) stateFunc {
 }
 
 func readKey(t *Terminal) stateFunc {
+	if t.pasting {
+		return readPaste
+	}
 	if len(t.view) == 0 {
 		return advance
 	}
+	if t.picker != nil {
+		return readPick
+	}
+	if t.search != nil {
+		return readSearch
+	}
+	// a delimiter such as NUL ends the line before it is taken as an
+	// edit
+	if t.hasDelim && t.delim < 32 && rune(t.view[0]) == t.delim {
+		t.advanceView(1)
+		return t.emitLine(false)
+	}
+	if b := t.view[0]; (b < 32 || b == keyDelete) && b != keyEscape {
+		if key := controlKeyEvent(rune(b)); t.bound[key] {
+			t.advanceView(1)
+			return t.emitItem(Item{Type: ItemKeyBound, Literal: key.String(), Key: key})
+		}
+	}
 
 	// https://i.sstatic.net/X9e5B.png
 	// From ascii control char table
@@ -234,8 +432,6 @@ func readKey(t *Terminal) stateFunc {
 	case keyCtrlC:
 		t.advanceView(1)
 		return t.emit(ItemKeyCtrlC, string(b))
-	case keyBackspace, keyDelete:
-		return handleKey
 	case 12: // ^L
 		t.advanceView(1)
 		return t.emit(ItemKeyCtrlL, string(b))
@@ -245,38 +441,132 @@ func readKey(t *Terminal) stateFunc {
 	case 16: // ^P
 		t.advanceView(1)
 		return t.emit(ItemKeyUp, string(b))
+	case keyCtrlX:
+		// Ctrl-X Ctrl-U undoes, Ctrl-X before any other key is dropped
+		if len(t.view) < 2 {
+			return advance
+		}
+		t.advanceView(1)
+		if t.view[0] == keyCtrlU {
+			t.advanceView(1)
+			t.undo()
+		}
+		return readInput
+	}
+
+	if t.viCommandMode() && (t.view[0] == keyBackspace || t.view[0] == keyDelete) {
+		t.advanceView(1)
+		return t.viKey('h')
+	}
+	if t.viCommandMode() && t.view[0] == keyCtrlR {
+		t.advanceView(1)
+		t.redo()
+		return readInput
+	}
+	if (t.view[0] == keyCtrlR || t.view[0] == keyCtrlS) && !t.silent {
+		t.startSearch(t.view[0] == keyCtrlR)
+		t.advanceView(1)
+		return readInput
+	}
+	if edit, ok := controlEdits[t.view[0]]; ok {
+		t.advanceView(1)
+		edit(t)
+		return readInput
 	}
 
 	if t.view[0] != keyEscape {
 		return handleKey
 	}
+	return readEscape
+}
 
-	if len(t.view) >= 3 {
-		if t.view[0] == keyEscape && t.view[1] == csi {
-			// https://gist.github.com/fnky/458719343aabd01cfb17a3a4f7296797#cursor-controls
-			switch b := t.view[2]; b {
-			case 'A':
-				t.advanceView(3)
-				return t.emit(ItemKeyUp, string(b))
-			case 'B':
-				t.advanceView(3)
-				return t.emit(ItemKeyDown, string(b))
-			}
+// readEscape decodes the escape sequence at the start of the view and
+// applies the edit bound to its key, or emits an item for it.
+func readEscape(t *Terminal) stateFunc {
+	// https://gist.github.com/fnky/458719343aabd01cfb17a3a4f7296797
+	key, n := decodeEscape(t.view)
+	if n == 0 {
+		if !t.escapeExpired {
+			return advanceEscape
 		}
+		key, n = KeyEvent{Key: KeyEscape}, 1
 	}
+	t.escapeExpired = false
+	t.advanceView(n)
 
-	if len(t.view) >= 6 {
-		if bytes.Equal(t.view[:6], pasteStart) {
-			t.advanceView(6)
-			return readPaste
+	if t.bound[key] {
+		return t.emitItem(Item{Type: ItemKeyBound, Literal: key.String(), Key: key})
+	}
+	if t.vi != nil {
+		if state, ok := t.viEscape(key); ok {
+			return state
 		}
 	}
 
-	return handleKey
+	if edit, ok := keyEdits[key]; ok {
+		edit(t)
+		return readInput
+	}
+
+	switch key.Key {
+	case KeyPasteStart:
+		t.pasting = true
+		return readPaste
+	case KeyPasteEnd:
+		return readInput
+	}
+
+	typ, ok := keyItemTypes[key.Key]
+	if key.Key >= KeyF1 && key.Key <= KeyF12 {
+		typ, ok = ItemKeyFunction, true
+	}
+	if !ok {
+		typ = ItemKeyUnknown
+	}
+	return t.emitItem(Item{Type: typ, Literal: key.String(), Key: key})
 }
 
+// readPaste reads the pasted text up to the end of the paste and emits
+// it, with line breaks as newlines.
 func readPaste(t *Terminal) stateFunc {
-	return nil
+	if end := bytes.Index(t.view, pasteEnd); end >= 0 {
+		text := append(t.pasted, t.view[:end]...)
+		t.advanceView(end + len(pasteEnd))
+		t.pasting, t.pasted = false, nil
+
+		text = bytes.ReplaceAll(text, []byte("\r\n"), []byte("\n"))
+		text = bytes.ReplaceAll(text, []byte("\r"), []byte("\n"))
+		return t.emit(ItemPaste, string(text))
+	}
+
+	// the end of the paste may be split between reads
+	n := max(0, len(t.view)-len(pasteEnd)+1)
+	t.pasted = append(t.pasted, t.view[:n]...)
+	t.advanceView(n)
+	return advance
+}
+
+// SetBracketedPaste sets whether the terminal is asked to mark pasted
+// text while a line is read, so that it is inserted as it is instead of
+// being taken for typed keys.
+func (t *Terminal) SetBracketedPaste(on bool) {
+	t.bracketedPaste = on
+	if !on {
+		t.setPasteMode(false)
+	}
+}
+
+func (t *Terminal) setPasteMode(on bool) {
+	if on == t.pasteMode {
+		return
+	}
+	t.pasteMode = on
+	if on {
+		t.tw.Stage(pasteModeOn)
+	} else {
+		t.tw.Stage(pasteModeOff)
+	}
+	t.tw.Commit()
 }
 
 func handleKey(t *Terminal) stateFunc {
@@ -287,19 +577,31 @@ func handleKey(t *Terminal) stateFunc {
 	key, size := utf8.DecodeRune(t.view)
 	t.advanceView(size)
 
+	if t.hasDelim && key == t.delim {
+		return t.emitLine(false)
+	}
+
 	switch key {
 	case keyTab:
 		return t.emit(ItemKeyTab, string(key))
 	case keyCarriageReturn, keyLineFeed:
+		if t.hasDelim {
+			// newlines are ordinary input when reading up to
+			// another delimiter
+			if t.isViewCurrent(keyLineFeed) {
+				t.advanceView(1)
+			}
+			return t.addToLine('\n')
+		}
 		return handleEnterKey
-	case keyBackspace, keyDelete:
-		t.eraseLastKey()
-		return readInput
 	default:
+		if t.viCommandMode() {
+			return t.viKey(key)
+		}
 		if key >= 32 {
 			return t.addToLine(key)
 		}
-		return advance
+		return readInput
 	}
 }
 
@@ -307,20 +609,39 @@ func handleEnterKey(t *Terminal) stateFunc {
 	if t.isViewCurrent(keyLineFeed) {
 		t.advanceView(1)
 	}
+	return t.emitLine(!t.silent)
+}
+
+func (t *Terminal) emitLine(echoNewLine bool) stateFunc {
 	line := string(t.line)
 	t.line = t.line[:0]
-	t.tw.Stage(newLine)
-	t.tw.Commit()
+	t.cursor = 0
+	t.undos.clear()
+	t.lastEdit = editNone
+	t.viReset()
+	// commands are run with pastes unmarked
+	t.setPasteMode(false)
+	if echoNewLine {
+		t.MoveBelow()
+	}
 	return t.emit(ItemLineInput, line)
 }
 
 func (t *Terminal) addToLine(r rune) stateFunc {
-	t.line = append(t.line, r)
-	t.tw.StageRune(r)
-	t.tw.Commit()
+	// typing is undone a word at a time
+	if unicode.IsSpace(r) && t.cursor > 0 && !unicode.IsSpace(t.line[t.cursor-1]) {
+		t.lastEdit = editNone
+	}
+	t.saveUndo(editInsert)
+	t.insert(r)
+	t.lastEdit = editInsert
 
 	if t.CharacterReadHook != nil {
 		t.CharacterReadHook(r)
 	}
+
+	if t.nchars > 0 && len(t.line) >= t.nchars {
+		return t.emitLine(false)
+	}
 	return readInput
 }
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"io"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -17,12 +18,12 @@ func TestNextToken(t *testing.T) {
 		{
 			"simple with linefeed",
 			imp("echo mino", keyCarriageReturn),
-			[]Item{{ItemLineInput, "echo mino"}},
+			[]Item{{Type: ItemLineInput, Literal: "echo mino"}},
 		},
 		{
 			"left right keys then simple input",
 			imp(keyEscape, "[D", keyEscape, "[C", "echo mino", keyCarriageReturn),
-			[]Item{{ItemLineInput, "[D[Cecho mino"}},
+			[]Item{{Type: ItemLineInput, Literal: "echo mino"}},
 		},
 	}
 
@@ -39,6 +40,42 @@ func TestNextToken(t *testing.T) {
 	}
 }
 
+func TestReadLine(t *testing.T) {
+	t.Run("delimiter and character count", func(t *testing.T) {
+		tr := NewTermReader(bytes.NewReader(imp("one\rtwo;three", keyCarriageReturn)), NewTermWriter(io.Discard))
+
+		line, err := tr.ReadLine(ReadLineOptions{Delim: ';', HasDelim: true})
+		assert.NoError(t, err)
+		assert.Equal(t, "one\ntwo", line)
+
+		line, err = tr.ReadLine(ReadLineOptions{NChars: 3})
+		assert.NoError(t, err)
+		assert.Equal(t, "thr", line)
+	})
+
+	t.Run("NUL delimiter", func(t *testing.T) {
+		tr := NewTermReader(bytes.NewReader(imp("one\rtwo", byte(0), "three", keyCarriageReturn)), NewTermWriter(io.Discard))
+
+		line, err := tr.ReadLine(ReadLineOptions{Delim: 0, HasDelim: true})
+		assert.NoError(t, err)
+		assert.Equal(t, "one\ntwo", line)
+
+		// without a delimiter NUL is ignored and the line ends with it
+		line, err = tr.ReadLine(ReadLineOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, "three", line)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		pr, pw := io.Pipe()
+		defer pw.Close()
+		tr := NewTermReader(pr, NewTermWriter(io.Discard))
+
+		_, err := tr.ReadLine(ReadLineOptions{Timeout: 10 * time.Millisecond})
+		assert.ErrorIs(t, err, ErrReadTimeout)
+	})
+}
+
 func imp(args ...any) []byte {
 	input := make([]byte, 0, len(args))
 	for i := range args {
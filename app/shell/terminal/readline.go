@@ -0,0 +1,98 @@
+package terminal
+
+import (
+	"errors"
+	"io"
+	"time"
+)
+
+var (
+	ErrReadTimeout = errors.New("read timed out")
+	ErrInterrupted = errors.New("interrupted")
+)
+
+// ReadLineOptions configures a single call to ReadLine.
+type ReadLineOptions struct {
+	Prompt string
+	// Silent disables echoing of typed characters
+	Silent bool
+	// Delim ends the line instead of a newline when HasDelim is set,
+	// which lets it be NUL
+	Delim    rune
+	HasDelim bool
+	// NChars ends the line once that many characters have been read
+	NChars int
+	// Timeout abandons the read when it is positive and has elapsed
+	Timeout time.Duration
+}
+
+// ReadLine reads a single line with the terminal's line editing outside
+// of the shell's read loop. It is meant for builtins such as `read` that
+// prompt for input while a command is being evaluated.
+func (t *Terminal) ReadLine(opts ReadLineOptions) (string, error) {
+	prevPrompt, prevHook, prevLine, prevCursor := t.PromptStringFunc, t.CharacterReadHook, t.line, t.cursor
+	prevPasteMode := t.pasteMode
+	defer func() {
+		t.setPasteMode(prevPasteMode)
+		t.PromptStringFunc = prevPrompt
+		t.CharacterReadHook = prevHook
+		t.line = prevLine
+		t.cursor = prevCursor
+		t.undos.clear()
+		t.silent = false
+		t.delim, t.hasDelim = 0, false
+		t.nchars = 0
+		t.deadline = time.Time{}
+	}()
+
+	t.PromptStringFunc = func() string { return opts.Prompt }
+	t.CharacterReadHook = nil
+	t.line = nil
+	t.cursor = 0
+	t.silent = opts.Silent
+	t.nchars = opts.NChars
+	if opts.HasDelim && opts.Delim != '\n' {
+		t.delim, t.hasDelim = opts.Delim, true
+	}
+	if opts.Timeout > 0 {
+		t.deadline = time.Now().Add(opts.Timeout)
+	}
+
+	if err := t.Ready(); err != nil {
+		return "", err
+	}
+
+	// items posted while reading belong to the shell's read loop
+	posted := make([]Item, 0)
+	defer func() {
+		for _, item := range posted {
+			t.Post(item)
+		}
+	}()
+
+	for {
+		item := t.NextItem()
+		switch item.Type {
+		case ItemSignal:
+			if item.Literal == "WINCH" {
+				t.Resize()
+			}
+			posted = append(posted, item)
+		case ItemLineInput:
+			return item.Literal, nil
+		case ItemEOF:
+			return string(t.line), io.EOF
+		case ItemTimeout:
+			return string(t.line), ErrReadTimeout
+		case ItemPaste:
+			// pasted text is read as if typed, so that a newline
+			// or the delimiter in it ends the line
+			t.view = append([]byte(item.Literal), t.view...)
+		case ItemKeyCtrlC:
+			t.MoveBelow()
+			return "", ErrInterrupted
+		case ItemError:
+			return "", errors.New(item.Literal)
+		}
+	}
+}
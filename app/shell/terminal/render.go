@@ -0,0 +1,232 @@
+package terminal
+
+import (
+	"fmt"
+	"slices"
+)
+
+// defaultWidth is the width of the terminal when SizeFunc is not set or
+// fails
+const defaultWidth = 80
+
+// tabWidth is the distance between tab stops
+const tabWidth = 8
+
+// position is a row and column on the screen, with rows counted from
+// the row the prompt starts on.
+type position struct {
+	row, col int
+}
+
+// advance returns where text written from p ends on a screen of the
+// given width. A column equal to width is the margin, where the cursor
+// waits for the next character to wrap it to the next row.
+func (p position) advance(text []rune, width int) position {
+	for len(text) > 0 {
+		n := graphemeLen(text)
+		g := text[:n]
+		text = text[n:]
+
+		switch g[0] {
+		case '\n':
+			p = position{row: p.row + 1}
+			continue
+		case '\t':
+			// a tab stops at the margin rather than wrapping
+			p = p.wrapped(width)
+			p.col = min((p.col/tabWidth+1)*tabWidth, width-1)
+			continue
+		}
+
+		w := graphemeWidth(g)
+		if p.col+w > width {
+			p = position{row: p.row + 1}
+		}
+		p.col += w
+	}
+	return p
+}
+
+// wrapped returns where the cursor shows for p, the start of the next
+// row when p is at the margin.
+func (p position) wrapped(width int) position {
+	if p.col >= width {
+		return position{row: p.row + 1}
+	}
+	return p
+}
+
+// screen is what was last drawn of the prompt and the line, for
+// redrawing them in place.
+type screen struct {
+	// text is the prompt and the line drawn and prefix the length of
+	// the prompt in it
+	text   []rune
+	prefix int
+	// at is the index in text of the rune the cursor is on, cursor is
+	// where that is on the screen and end where text ends
+	at          int
+	cursor, end position
+}
+
+// width returns the width of the terminal.
+func (t *Terminal) width() int {
+	if t.SizeFunc != nil {
+		if w, _, err := t.SizeFunc(); err == nil && w > 0 {
+			return w
+		}
+	}
+	return defaultWidth
+}
+
+// draw redraws prompt and text from the row the prompt starts on and
+// leaves the cursor cursor runes into text. stage writes text, which
+// lets parts of it be highlighted.
+func (t *Terminal) draw(prompt string, text []rune, cursor int, stage func()) {
+	width := t.width()
+	t.stageErase()
+	t.tw.StageString(prompt)
+	stage()
+
+	s := &t.screen
+	s.text = append(append(s.text[:0], []rune(stripEscapes(prompt))...), text...)
+	s.prefix = len(s.text) - len(text)
+	s.at = s.prefix + cursor
+	s.end = position{}.advance(s.text, width)
+	if s.end.col >= width {
+		// the cursor can only be moved once it left the margin
+		t.tw.Stage(newLine)
+		s.end = s.end.wrapped(width)
+	}
+	s.cursor = s.end
+	t.stageMoveTo(t.positionOf(s.at, width))
+}
+
+// positionOf returns where the rune at index i of the text drawn shows.
+func (t *Terminal) positionOf(i, width int) position {
+	return position{}.advance(t.screen.text[:i], width).wrapped(width)
+}
+
+// stageAppend writes text after what was drawn, which the cursor is at
+// the end of. It returns false, having written nothing, when the text
+// has to be drawn again instead.
+func (t *Terminal) stageAppend(text []rune) bool {
+	s := &t.screen
+	if s.at != len(s.text) || slices.Contains(text, '\n') {
+		return false
+	}
+	// text joining the last character drawn, e.g. an accent, may
+	// change its width
+	if last := prevGrapheme(s.text, len(s.text)); len(s.text) > s.prefix && last >= s.prefix &&
+		graphemeLen(append(slices.Clone(s.text[last:]), text...)) > len(s.text)-last {
+		return false
+	}
+
+	width := t.width()
+	t.tw.StageString(string(text))
+	s.text = append(s.text, text...)
+	s.at = len(s.text)
+	s.end = s.end.advance(text, width)
+	if s.end.col >= width {
+		t.tw.Stage(newLine)
+		s.end = s.end.wrapped(width)
+	}
+	s.cursor = s.end
+	return true
+}
+
+// stageMoveTo moves the cursor to p.
+func (t *Terminal) stageMoveTo(p position) {
+	s := &t.screen
+	if p.row < s.cursor.row {
+		t.tw.Stage(cursorUp(s.cursor.row - p.row))
+	} else if p.row > s.cursor.row {
+		t.tw.Stage(cursorDown(p.row - s.cursor.row))
+	}
+	t.tw.StageMove(p.col - s.cursor.col)
+	s.cursor = p
+}
+
+// stageErase moves the cursor to the start of the row the prompt starts
+// on and clears everything drawn from there.
+func (t *Terminal) stageErase() {
+	t.tw.Stage(cursorUp(t.screen.cursor.row))
+	t.tw.StageByte(keyCarriageReturn)
+	t.tw.Stage(clearBelow)
+	t.screen.cursor = position{}
+}
+
+// ShowHint shows hint in grey after the cursor, which is at the end of
+// the line, cut to the room left on its row. It is cleared when the line
+// is drawn again.
+func (t *Terminal) ShowHint(hint string) {
+	if t.silent || t.screen.at != len(t.screen.text) {
+		return
+	}
+	// the cursor has to stay off the margin to be moved back
+	text := truncateWidth([]rune(hint), t.width()-t.screen.cursor.col-1)
+	t.tw.Stage(ClearLine)
+	t.tw.StagePushForegroundColor(Grey)
+	t.tw.StageString(string(text))
+	t.tw.StageMove(-textWidth(text))
+	t.tw.StagePopForegroundColor()
+	t.tw.Commit()
+}
+
+// Erase clears the prompt and the line from the screen, e.g. to write
+// something in their place before Ready draws them again.
+func (t *Terminal) Erase() {
+	t.stageErase()
+	t.screen = screen{}
+	t.tw.Commit()
+}
+
+// MoveBelow moves the cursor to the start of the row below the line, so
+// that what is written next does not overwrite it. Ready draws the
+// prompt and the line again.
+func (t *Terminal) MoveBelow() {
+	t.stageMoveTo(t.screen.end)
+	t.tw.Stage(newLine)
+	t.screen = screen{}
+	t.tw.Commit()
+}
+
+// Resize redraws the prompt and the line for the width SizeFunc now
+// returns, e.g. on SIGWINCH. Terminals wrap what they show again for
+// their new width, so the cursor is taken to be where the text before it
+// now ends.
+func (t *Terminal) Resize() {
+	width := t.width()
+	s := &t.screen
+	s.end = position{}.advance(s.text, width).wrapped(width)
+	if t.picker != nil {
+		// the cursor is on the query, below the line
+		t.renderPicker()
+		return
+	}
+	s.cursor = t.positionOf(s.at, width)
+
+	switch {
+	case t.search != nil:
+		t.renderSearch()
+	case t.silent:
+		t.draw(t.prompt(), nil, 0, func() {})
+		t.tw.Commit()
+	default:
+		t.refresh()
+	}
+}
+
+func cursorUp(n int) []byte {
+	if n <= 0 {
+		return nil
+	}
+	return fmt.Appendf(nil, "\x1b[%dA", n)
+}
+
+func cursorDown(n int) []byte {
+	if n <= 0 {
+		return nil
+	}
+	return fmt.Appendf(nil, "\x1b[%dB", n)
+}
@@ -0,0 +1,55 @@
+package terminal
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPositionAdvance(t *testing.T) {
+	tt := []struct {
+		name string
+		text string
+		end  position
+	}{
+		{"fits", "abc", position{0, 3}},
+		{"at the margin", "abcdefghij", position{0, 10}},
+		{"wraps", "abcdefghijk", position{1, 1}},
+		{"newline", "ab\ncd", position{1, 2}},
+		{"newline at the margin", "abcdefghij\nk", position{1, 1}},
+		{"tab", "a\tb", position{0, 9}},
+		{"tab stops before the margin", "abcdefghi\tb", position{0, 10}},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.end, position{}.advance([]rune(test.text), 10))
+		})
+	}
+}
+
+func TestWrappedLine(t *testing.T) {
+	width := 10
+	out := bytes.NewBuffer(nil)
+	tr := NewTermReader(bytes.NewReader(imp("abcdefgh", byte(keyCtrlA), byte(keyCtrlE), "ijklmnop", byte(keyCtrlA))), NewTermWriter(out))
+	tr.SizeFunc = func() (int, int, error) { return width, 24, nil }
+	_ = tr.Ready()
+	_, _ = tr.Writer().Commit()
+	out.Reset()
+
+	tr.NextItem()
+	frames := out.String()
+	// filling the row moves the cursor to the next one
+	assert.True(t, strings.HasPrefix(frames, "abcdefgh\r\r\n"), frames)
+	// ctrl-a and ctrl-e go up and down a row
+	assert.Contains(t, frames, "\r\r\n\x1b[1A\x1b[2C\x1b[1B\x1b[2D")
+	assert.True(t, strings.HasSuffix(frames, "ijklmnop\x1b[1A\x1b[6D"), frames)
+
+	// the line is drawn again from the row the prompt starts on
+	out.Reset()
+	width = 5
+	tr.Resize()
+	assert.Equal(t, "\r\x1b[J$ abcdefghijklmnop\x1b[3A\x1b[1D", out.String())
+}
@@ -0,0 +1,173 @@
+package terminal
+
+import (
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// Search is the incremental history search in progress, for the
+// handlers of ItemSearch to look up its match.
+type Search struct {
+	Query string
+	// Backward is set when searching older entries
+	Backward bool
+	// Next is set when the entry shown should be skipped, e.g. when
+	// Ctrl-R is pressed again
+	Next bool
+}
+
+// searchState is the state of the history search, shown in place of the
+// line until it is accepted or cancelled.
+type searchState struct {
+	Search
+	query []rune
+	match []rune
+	// at is where the query was found in match, -1 for nowhere
+	at     int
+	failed bool
+}
+
+// Search returns the history search in progress.
+func (t *Terminal) Search() (Search, bool) {
+	if t.search == nil {
+		return Search{}, false
+	}
+	return t.search.Search, true
+}
+
+// ShowSearchMatch shows the entry found for the search in progress, or
+// that there is none when ok is false.
+func (t *Terminal) ShowSearchMatch(match string, ok bool) {
+	s := t.search
+	if s == nil {
+		return
+	}
+
+	s.failed = !ok
+	if ok {
+		s.match = []rune(match)
+		i := strings.Index(match, s.Query)
+		if s.Backward {
+			i = strings.LastIndex(match, s.Query)
+		}
+		s.at = -1
+		if i >= 0 {
+			s.at = utf8.RuneCountInString(match[:i])
+		}
+	}
+	t.renderSearch()
+}
+
+func (t *Terminal) startSearch(backward bool) {
+	t.search = &searchState{
+		Search: Search{Backward: backward},
+		match:  slices.Clone(t.line),
+		at:     -1,
+	}
+	t.renderSearch()
+}
+
+// readSearch handles the keys typed while searching. Keys that do not
+// edit the query accept the match and are then handled as usual.
+func readSearch(t *Terminal) stateFunc {
+	if !utf8.FullRune(t.view) {
+		return advance
+	}
+
+	s := t.search
+	r, size := utf8.DecodeRune(t.view)
+	switch {
+	case r == keyCtrlR || r == keyCtrlS:
+		t.advanceView(size)
+		s.Backward = r == keyCtrlR
+		if len(s.query) == 0 {
+			// an empty search looks for the last query again
+			s.query = []rune(t.lastSearch)
+		}
+		// the entry shown is only skipped if it matched
+		return t.searchFor(s.at >= 0)
+	case r == keyCtrlG:
+		t.advanceView(size)
+		t.endSearch(false)
+		return readInput
+	case r == keyBackspace || r == keyDelete:
+		t.advanceView(size)
+		if len(s.query) > 0 {
+			s.query = s.query[:len(s.query)-1]
+		}
+		return t.searchFor(false)
+	case r >= 32 && r != utf8.RuneError:
+		t.advanceView(size)
+		s.query = append(s.query, r)
+		return t.searchFor(false)
+	}
+
+	t.endSearch(true)
+	return readKey
+}
+
+// searchFor emits the search for the query, unless it is empty.
+func (t *Terminal) searchFor(next bool) stateFunc {
+	s := t.search
+	s.Query, s.Next = string(s.query), next
+	if len(s.query) == 0 {
+		s.at, s.failed = -1, false
+		t.renderSearch()
+		return readInput
+	}
+	return t.emit(ItemSearch, s.Query)
+}
+
+// endSearch leaves the search, making its match the line if accept is
+// set.
+func (t *Terminal) endSearch(accept bool) {
+	s := t.search
+	t.search = nil
+	if len(s.query) > 0 {
+		t.lastSearch = string(s.query)
+	}
+
+	if accept && !slices.Equal(s.match, t.line) {
+		t.saveUndo(editReplace)
+		t.line = s.match
+		t.cursor = len(t.line)
+		if s.at >= 0 {
+			t.cursor = s.at
+		}
+	}
+	t.cursor = min(t.cursor, t.cursorLimit())
+	t.lastEdit = editNone
+	t.refresh()
+}
+
+// renderSearch draws the query and its match in place of the prompt and
+// the line, with the cursor where the query was found.
+func (t *Terminal) renderSearch() {
+	s := t.search
+	label := "reverse-i-search"
+	if !s.Backward {
+		label = "i-search"
+	}
+	if s.failed {
+		label = "failed " + label
+	}
+
+	found := s.at >= 0 && s.at+len(s.query) <= len(s.match)
+	cursor := len(s.match)
+	if found {
+		cursor = s.at
+	}
+	t.draw("("+label+")'"+string(s.query)+"': ", s.match, cursor, func() {
+		if !found {
+			t.tw.StageString(string(s.match))
+			return
+		}
+		t.tw.StageString(string(s.match[:s.at]))
+		t.tw.Stage(reverseVideo)
+		t.tw.StageString(string(s.match[s.at : s.at+len(s.query)]))
+		t.tw.Stage(noReverseVideo)
+		t.tw.StageString(string(s.match[s.at+len(s.query):]))
+	})
+	t.tw.Commit()
+}
@@ -0,0 +1,84 @@
+package terminal
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// searchEntries answers the searches of tr from entries, the newest
+// first, until a line is read.
+func searchEntries(tr *Terminal, entries []string) Item {
+	pos := -1
+	for {
+		item := tr.NextItem()
+		if item.Type != ItemSearch {
+			return item
+		}
+
+		s, _ := tr.Search()
+		step := 1
+		if !s.Backward {
+			step = -1
+		}
+		start := pos
+		if start < 0 || s.Next {
+			start += step
+		}
+		found := false
+		for i := start; i >= 0 && i < len(entries); i += step {
+			if strings.Contains(entries[i], s.Query) {
+				pos, found = i, true
+				tr.ShowSearchMatch(entries[i], true)
+				break
+			}
+		}
+		if !found {
+			tr.ShowSearchMatch("", false)
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	entries := []string{"echo two", "ls -l", "echo one"}
+	ctrlR, ctrlS, ctrlG := byte(keyCtrlR), byte(keyCtrlS), byte(keyCtrlG)
+
+	tt := []struct {
+		name  string
+		input []byte
+		line  string
+	}{
+		{"enter accepts", imp(ctrlR, "ls", keyCarriageReturn), "ls -l"},
+		{"ctrl-r cycles", imp(ctrlR, "echo", ctrlR, keyCarriageReturn), "echo one"},
+		{"ctrl-s goes back", imp(ctrlR, "echo", ctrlR, ctrlS, keyCarriageReturn), "echo two"},
+		{"no further match keeps the last", imp(ctrlR, "echo", ctrlR, ctrlR, keyCarriageReturn), "echo one"},
+		{"backspace edits the query", imp(ctrlR, "lx", byte(keyDelete), keyCarriageReturn), "ls -l"},
+		{"ctrl-g cancels", imp("pwd", ctrlR, "ls", ctrlG, keyCarriageReturn), "pwd"},
+		{"arrows accept and edit", imp(ctrlR, "two", keyEscape, "[D", "X", keyCarriageReturn), "echoX two"},
+		{"ctrl-e accepts and edits", imp(ctrlR, "ls", byte(keyCtrlE), "a", keyCarriageReturn), "ls -la"},
+		{"ctrl-r repeats the last search", imp(ctrlR, "ls", ctrlG, ctrlR, ctrlR, keyCarriageReturn), "ls -l"},
+		{"accepting can be undone", imp("pwd", ctrlR, "ls", byte(keyCtrlE), byte(keyCtrlUnderscore), keyCarriageReturn), "pwd"},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			tr := NewTermReader(bytes.NewReader(test.input), NewTermWriter(io.Discard))
+			item := searchEntries(tr, entries)
+			assert.Equal(t, ItemLineInput, item.Type)
+			assert.Equal(t, test.line, item.Literal)
+		})
+	}
+}
+
+func TestSearchRender(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	tr := NewTermReader(bytes.NewReader(imp(byte(keyCtrlR), "ls", byte(keyCtrlC))), NewTermWriter(out))
+	_, _ = tr.Writer().Commit()
+	out.Reset()
+
+	searchEntries(tr, []string{"echo ls -l"})
+	assert.Contains(t, out.String(), "\r\x1b[J(reverse-i-search)'ls': echo \x1b[7mls\x1b[27m -l\x1b[5D")
+}
@@ -0,0 +1,60 @@
+package terminal
+
+import "slices"
+
+// lineState is the line and cursor to go back to when undoing an edit
+type lineState struct {
+	line   []rune
+	cursor int
+}
+
+// undoStack keeps the states of the line before each change, for the
+// line being edited only, and the states undone for redoing them.
+type undoStack struct {
+	states []lineState
+	undone []lineState
+}
+
+// push saves the line before a change. A new change drops what was
+// undone.
+func (u *undoStack) push(line []rune, cursor int) {
+	u.states = append(u.states, lineState{line: slices.Clone(line), cursor: cursor})
+	u.undone = u.undone[:0]
+}
+
+// undo returns the state before the last change and keeps line and
+// cursor for redoing it.
+func (u *undoStack) undo(line []rune, cursor int) (lineState, bool) {
+	if len(u.states) == 0 {
+		return lineState{}, false
+	}
+	s := u.states[len(u.states)-1]
+	u.states = u.states[:len(u.states)-1]
+	u.undone = append(u.undone, lineState{line: slices.Clone(line), cursor: cursor})
+	return s, true
+}
+
+// redo returns the state last undone and keeps line and cursor for
+// undoing it again.
+func (u *undoStack) redo(line []rune, cursor int) (lineState, bool) {
+	if len(u.undone) == 0 {
+		return lineState{}, false
+	}
+	s := u.undone[len(u.undone)-1]
+	u.undone = u.undone[:len(u.undone)-1]
+	u.states = append(u.states, lineState{line: slices.Clone(line), cursor: cursor})
+	return s, true
+}
+
+// dropUnchanged removes the last state if the line is still the same,
+// e.g. after entering and leaving insert mode without typing.
+func (u *undoStack) dropUnchanged(line []rune) {
+	if n := len(u.states); n > 0 && slices.Equal(u.states[n-1].line, line) {
+		u.states = u.states[:n-1]
+	}
+}
+
+func (u *undoStack) clear() {
+	u.states = u.states[:0]
+	u.undone = u.undone[:0]
+}
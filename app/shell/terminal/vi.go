@@ -0,0 +1,829 @@
+package terminal
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// EditMode selects the key bindings of the line editor
+type EditMode int
+
+const (
+	EditModeEmacs EditMode = iota
+	EditModeVi
+)
+
+// ViMode is the mode of the vi line editor
+type ViMode int
+
+const (
+	ViInsert ViMode = iota
+	ViNormal
+	ViVisual
+)
+
+func (m ViMode) String() string {
+	switch m {
+	case ViNormal:
+		return "normal"
+	case ViVisual:
+		return "visual"
+	default:
+		return "insert"
+	}
+}
+
+const (
+	viOperators = "dcy"
+	viMotions   = "hlwWbBeE0^$|;, "
+	viFinds     = "fFtT"
+	viObjects   = "wW\"'`()b[]{}B<>"
+	viCommands  = "xXsSDCYpPr~iaIAu.vjk+-"
+	// viChanges are the commands repeated by `.`
+	viChanges = "dcxXsSDCpPr~iaIA"
+)
+
+// viAliases are the commands that are short for an operator and a motion
+var viAliases = map[rune][2]rune{
+	'x': {'d', 'l'},
+	'X': {'d', 'h'},
+	's': {'c', 'l'},
+	'S': {'c', 'c'},
+	'D': {'d', '$'},
+	'C': {'c', '$'},
+	'Y': {'y', 'y'},
+}
+
+// viState is the state of the vi line editor
+type viState struct {
+	mode ViMode
+	// pending holds the keys of the command being typed
+	pending []rune
+	// anchor is the end of the visual selection opposite to the cursor
+	anchor int
+	// insertStart is where the text typed in insert mode starts
+	insertStart int
+	// saved is set once the line before the text typed in insert mode
+	// is on the undo stack
+	saved bool
+
+	lastChange *viChange
+	// recording is the change whose text is being typed in insert mode
+	recording *viChange
+	lastFind  viMotion
+}
+
+// viChange is a change repeated by `.` along with the text typed in
+// insert mode after it.
+type viChange struct {
+	cmd  viCommand
+	text []rune
+}
+
+type viMotion struct {
+	count int
+	key   rune
+	// arg is the character of f, F, t and T or the object of i and a
+	arg rune
+}
+
+// viCommand is a command typed in normal or visual mode. key is the
+// command or operator, zero for a lone motion.
+type viCommand struct {
+	count  int
+	key    rune
+	arg    rune
+	motion viMotion
+}
+
+type viParse int
+
+const (
+	viIncomplete viParse = iota
+	viComplete
+	viInvalid
+)
+
+// SetEditMode switches the key bindings of the line editor.
+func (t *Terminal) SetEditMode(m EditMode) {
+	if m == EditModeVi {
+		if t.vi == nil {
+			t.vi = &viState{}
+		}
+		return
+	}
+	t.vi = nil
+}
+
+func (t *Terminal) EditMode() EditMode {
+	if t.vi != nil {
+		return EditModeVi
+	}
+	return EditModeEmacs
+}
+
+// ViMode returns the mode of the vi line editor, for prompts to show. It
+// reports false in emacs mode.
+func (t *Terminal) ViMode() (ViMode, bool) {
+	if t.vi == nil {
+		return ViInsert, false
+	}
+	return t.vi.mode, true
+}
+
+// viCommandMode reports whether keys are commands rather than text.
+func (t *Terminal) viCommandMode() bool {
+	return t.vi != nil && t.vi.mode != ViInsert
+}
+
+// viSelection returns the range of the visual selection.
+func (t *Terminal) viSelection() (int, int, bool) {
+	if t.vi == nil || t.vi.mode != ViVisual || len(t.line) == 0 {
+		return 0, 0, false
+	}
+	from, to := min(t.vi.anchor, t.cursor), max(t.vi.anchor, t.cursor)
+	return from, min(to+1, len(t.line)), true
+}
+
+func (t *Terminal) viSetMode(m ViMode) {
+	t.vi.mode = m
+	t.cursor = min(t.cursor, t.cursorLimit())
+	// the prompt may show the mode
+	t.refresh()
+}
+
+func (t *Terminal) viReset() {
+	if t.vi != nil {
+		t.vi.mode = ViInsert
+		t.vi.pending = t.vi.pending[:0]
+		t.vi.recording = nil
+		t.vi.saved = false
+	}
+}
+
+// viEscape handles the keys sent as escape sequences that vi binds.
+func (t *Terminal) viEscape(key KeyEvent) (stateFunc, bool) {
+	switch {
+	case key == KeyEvent{Key: KeyEscape}:
+		t.viEscapeKey()
+		return readInput, true
+	case key.Mod != ModAlt:
+		return nil, false
+	}
+
+	// a key typed right after Esc comes as that key with Alt
+	switch key.Key {
+	case KeyRune:
+		t.viEscapeKey()
+		return t.viKey(key.Rune), true
+	case KeyBackspace:
+		t.viEscapeKey()
+		return t.viKey('h'), true
+	case KeyEnter:
+		t.viEscapeKey()
+		return handleEnterKey, true
+	}
+	return nil, false
+}
+
+func (t *Terminal) viEscapeKey() {
+	v := t.vi
+	switch v.mode {
+	case ViInsert:
+		if v.recording != nil {
+			if t.cursor >= v.insertStart {
+				v.recording.text = slices.Clone(t.line[v.insertStart:t.cursor])
+			}
+			v.lastChange, v.recording = v.recording, nil
+		}
+		t.undos.dropUnchanged(t.line)
+		t.cursor = prevGrapheme(t.line, t.cursor)
+		t.viSetMode(ViNormal)
+	case ViVisual:
+		t.viSetMode(ViNormal)
+	default:
+		v.pending = v.pending[:0]
+	}
+}
+
+// viKey adds r to the pending command and runs it once complete.
+func (t *Terminal) viKey(r rune) stateFunc {
+	v := t.vi
+	v.pending = append(v.pending, r)
+	cmd, status := parseViCommand(v.pending, v.mode == ViVisual)
+	switch status {
+	case viIncomplete:
+		return readInput
+	case viInvalid:
+		v.pending = v.pending[:0]
+		t.bell()
+		return readInput
+	}
+
+	v.pending = v.pending[:0]
+	state := t.viExecute(cmd)
+	if limit := t.cursorLimit(); t.cursor > limit {
+		t.moveTo(limit)
+	}
+	return state
+}
+
+func parseViCommand(keys []rune, visual bool) (viCommand, viParse) {
+	cmd := viCommand{}
+	cmd.count, keys = parseViCount(keys)
+	if len(keys) == 0 {
+		return cmd, viIncomplete
+	}
+
+	switch k := keys[0]; {
+	case strings.ContainsRune(viOperators, k):
+		cmd.key = k
+		if visual {
+			return cmd, viComplete
+		}
+		cmd.motion.count, keys = parseViCount(keys[1:])
+		if len(keys) == 0 {
+			return cmd, viIncomplete
+		}
+		if keys[0] == k {
+			// dd, cc and yy act on the whole line
+			cmd.motion.key = k
+			return cmd, viComplete
+		}
+		return parseViMotion(cmd, keys, true)
+	case visual && (k == 'i' || k == 'a'):
+		return parseViMotion(cmd, keys, true)
+	case visual && k == 'o':
+		cmd.key = k
+		return cmd, viComplete
+	case k == 'r':
+		if len(keys) < 2 {
+			return cmd, viIncomplete
+		}
+		cmd.key, cmd.arg = k, keys[1]
+		return cmd, viComplete
+	case strings.ContainsRune(viCommands, k):
+		cmd.key = k
+		return cmd, viComplete
+	default:
+		return parseViMotion(cmd, keys, false)
+	}
+}
+
+// parseViMotion parses a motion, or a text object if objects is set.
+func parseViMotion(cmd viCommand, keys []rune, objects bool) (viCommand, viParse) {
+	m := keys[0]
+	switch {
+	case strings.ContainsRune(viMotions, m):
+		cmd.motion.key = m
+		return cmd, viComplete
+	case strings.ContainsRune(viFinds, m), objects && (m == 'i' || m == 'a'):
+		if len(keys) < 2 {
+			return cmd, viIncomplete
+		}
+		if (m == 'i' || m == 'a') && !strings.ContainsRune(viObjects, keys[1]) {
+			return cmd, viInvalid
+		}
+		cmd.motion.key, cmd.motion.arg = m, keys[1]
+		return cmd, viComplete
+	}
+	return cmd, viInvalid
+}
+
+// parseViCount parses the count in front of a command or motion. A
+// leading 0 is the motion to the start of the line.
+func parseViCount(keys []rune) (int, []rune) {
+	n := 0
+	for len(keys) > 0 && keys[0] >= '0' && keys[0] <= '9' && (n > 0 || keys[0] != '0') {
+		n = n*10 + int(keys[0]-'0')
+		keys = keys[1:]
+	}
+	return n, keys
+}
+
+func (t *Terminal) viExecute(cmd viCommand) stateFunc {
+	v := t.vi
+	if v.mode == ViVisual {
+		t.viVisual(cmd)
+		return readInput
+	}
+
+	if alias, ok := viAliases[cmd.key]; ok {
+		cmd.key, cmd.motion.key = alias[0], alias[1]
+	}
+
+	count := max(1, cmd.count)
+	if strings.ContainsRune(viChanges, cmd.key) {
+		t.undos.push(t.line, t.cursor)
+		change := &viChange{cmd: cmd}
+		defer func() {
+			if v.mode == ViInsert {
+				v.recording = change
+			} else {
+				v.lastChange = change
+			}
+		}()
+	}
+
+	switch cmd.key {
+	case 0:
+		pos, _, ok := t.viMotionTarget(cmd.motion, count)
+		if !ok {
+			t.bell()
+			return readInput
+		}
+		t.moveTo(pos)
+	case 'd', 'c', 'y':
+		from, to, ok := t.viOperatorRange(cmd)
+		if !ok {
+			t.bell()
+			return readInput
+		}
+		t.viOperate(cmd.key, from, to)
+	case 'p', 'P':
+		text, ok := t.kills.top()
+		if !ok {
+			t.bell()
+			return readInput
+		}
+		if cmd.key == 'p' && len(t.line) > 0 {
+			t.cursor++
+		}
+		t.insert(slices.Repeat(text, count)...)
+		t.moveTo(t.cursor - 1)
+	case 'r':
+		if t.cursor+count > len(t.line) {
+			t.bell()
+			return readInput
+		}
+		for i := range count {
+			t.line[t.cursor+i] = cmd.arg
+		}
+		t.cursor += count - 1
+		t.refresh()
+	case '~':
+		end := min(len(t.line), t.cursor+count)
+		toggleCase(t.line[t.cursor:end])
+		t.cursor = end
+		t.refresh()
+	case 'i':
+		t.viInsert(t.cursor)
+	case 'a':
+		t.viInsert(min(len(t.line), t.cursor+1))
+	case 'I':
+		t.viInsert(firstNonBlank(t.line))
+	case 'A':
+		t.viInsert(len(t.line))
+	case 'v':
+		v.anchor = t.cursor
+		t.viSetMode(ViVisual)
+	case 'u':
+		for range count {
+			if !t.restore(t.undos.undo(t.line, t.cursor)) {
+				break
+			}
+		}
+	case '.':
+		t.viRepeat(cmd.count)
+	case 'j', '+':
+		return t.emit(ItemKeyDown, string(cmd.key))
+	case 'k', '-':
+		return t.emit(ItemKeyUp, string(cmd.key))
+	}
+	return readInput
+}
+
+// viRepeat runs the last change again, with count instead of its own if
+// one is given.
+func (t *Terminal) viRepeat(count int) {
+	v := t.vi
+	if v.lastChange == nil {
+		t.bell()
+		return
+	}
+
+	last := *v.lastChange
+	cmd := last.cmd
+	if count > 0 {
+		cmd.count, cmd.motion.count = count, 0
+	}
+	t.viExecute(cmd)
+	if v.mode == ViInsert {
+		t.insert(last.text...)
+		t.viEscapeKey()
+	}
+}
+
+func (t *Terminal) viVisual(cmd viCommand) {
+	v := t.vi
+	from, to, _ := t.viSelection()
+
+	switch cmd.key {
+	case 0:
+		if m := cmd.motion; m.key == 'i' || m.key == 'a' {
+			objFrom, objTo, ok := t.viTextObject(m.key == 'a', m.arg)
+			if !ok || objFrom == objTo {
+				t.bell()
+				return
+			}
+			v.anchor, t.cursor = objFrom, objTo-1
+			t.refresh()
+			return
+		}
+		pos, _, ok := t.viMotionTarget(cmd.motion, max(1, cmd.count))
+		if !ok {
+			t.bell()
+			return
+		}
+		t.moveTo(pos)
+	case 'v':
+		t.viSetMode(ViNormal)
+	case 'o':
+		v.anchor, t.cursor = t.cursor, v.anchor
+		t.refresh()
+	case 'y', 'Y':
+		t.cursor = from
+		t.viSetMode(ViNormal)
+		t.viOperate('y', from, to)
+	case 'd', 'x', 'X', 'D':
+		t.undos.push(t.line, t.cursor)
+		t.cursor = from
+		t.viSetMode(ViNormal)
+		t.viOperate('d', from, to)
+	case 'c', 's', 'S', 'C':
+		t.undos.push(t.line, t.cursor)
+		t.cursor = from
+		t.viSetMode(ViNormal)
+		t.viOperate('c', from, to)
+	case 'r', '~':
+		t.undos.push(t.line, t.cursor)
+		if cmd.key == '~' {
+			toggleCase(t.line[from:to])
+		} else {
+			for i := from; i < to; i++ {
+				t.line[i] = cmd.arg
+			}
+		}
+		t.cursor = from
+		t.viSetMode(ViNormal)
+	default:
+		t.bell()
+	}
+}
+
+// viOperate applies the operator d, c or y to the text between from
+// and to.
+func (t *Terminal) viOperate(op rune, from, to int) {
+	switch op {
+	case 'y':
+		if from < to {
+			t.kills.push(t.line[from:to])
+		}
+		t.moveTo(from)
+	case 'd':
+		t.kill(from, to, false)
+		if t.cursor != from {
+			t.moveTo(from)
+		}
+	case 'c':
+		t.kill(from, to, false)
+		t.viInsert(from)
+	}
+}
+
+func (t *Terminal) viInsert(pos int) {
+	// the command saved the line already
+	t.vi.saved = true
+	t.cursor = pos
+	t.vi.insertStart = pos
+	t.viSetMode(ViInsert)
+}
+
+// viOperatorRange returns the text an operator applies to.
+func (t *Terminal) viOperatorRange(cmd viCommand) (int, int, bool) {
+	m := cmd.motion
+	count := max(1, cmd.count) * max(1, m.count)
+	switch {
+	case m.key == cmd.key:
+		return 0, len(t.line), true
+	case m.key == 'i' || m.key == 'a':
+		return t.viTextObject(m.key == 'a', m.arg)
+	case cmd.key == 'c' && (m.key == 'w' || m.key == 'W') &&
+		t.cursor < len(t.line) && !unicode.IsSpace(t.line[t.cursor]):
+		// cw changes up to the end of the word the cursor is on,
+		// even when that is the cursor itself
+		pos := t.cursor - 1
+		for range count {
+			pos = viWordEnd(t.line, pos, m.key == 'W')
+		}
+		return t.cursor, pos + 1, true
+	}
+
+	pos, inclusive, ok := t.viMotionTarget(m, count)
+	if !ok {
+		return 0, 0, false
+	}
+	if pos < t.cursor {
+		return pos, t.cursor, true
+	}
+	if inclusive {
+		pos = min(len(t.line), pos+1)
+	}
+	return t.cursor, pos, true
+}
+
+// viMotionTarget returns where a motion moves the cursor and whether an
+// operator includes that position.
+func (t *Terminal) viMotionTarget(m viMotion, count int) (int, bool, bool) {
+	line, pos := t.line, t.cursor
+	switch m.key {
+	case 'h':
+		ok := pos > 0
+		for range count {
+			pos = prevGrapheme(line, pos)
+		}
+		return pos, false, ok
+	case 'l', ' ':
+		ok := pos < len(line)
+		for range count {
+			pos = nextGrapheme(line, pos)
+		}
+		return pos, false, ok
+	case '0':
+		return 0, false, true
+	case '^':
+		return firstNonBlank(line), false, true
+	case '$':
+		return max(0, len(line)-1), true, true
+	case '|':
+		return min(count-1, max(0, len(line)-1)), false, true
+	case 'w', 'W':
+		for range count {
+			pos = viNextWordStart(line, pos, m.key == 'W')
+		}
+		return pos, false, true
+	case 'b', 'B':
+		for range count {
+			pos = viPrevWordStart(line, pos, m.key == 'B')
+		}
+		return pos, false, true
+	case 'e', 'E':
+		for range count {
+			pos = viWordEnd(line, pos, m.key == 'E')
+		}
+		return pos, true, true
+	case 'f', 'F', 't', 'T':
+		t.vi.lastFind = m
+		return t.viFind(m.key, m.arg, count)
+	case ';', ',':
+		find := t.vi.lastFind
+		if find.key == 0 {
+			return 0, false, false
+		}
+		if m.key == ',' {
+			// the same find in the opposite direction
+			if unicode.IsUpper(find.key) {
+				find.key = unicode.ToLower(find.key)
+			} else {
+				find.key = unicode.ToUpper(find.key)
+			}
+		}
+		return t.viFind(find.key, find.arg, count)
+	}
+	return 0, false, false
+}
+
+// viFind returns the position of the count-th c after the cursor for f
+// and t or before it for F and T.
+func (t *Terminal) viFind(key, c rune, count int) (int, bool, bool) {
+	pos := t.cursor
+	forward := key == 'f' || key == 't'
+	for range count {
+		i := -1
+		if forward && pos+1 < len(t.line) {
+			if j := slices.Index(t.line[pos+1:], c); j >= 0 {
+				i = pos + 1 + j
+			}
+		} else if !forward {
+			for j := pos - 1; j >= 0 && i < 0; j-- {
+				if t.line[j] == c {
+					i = j
+				}
+			}
+		}
+		if i < 0 {
+			return 0, false, false
+		}
+		pos = i
+	}
+
+	switch key {
+	case 't':
+		pos--
+	case 'T':
+		pos++
+	}
+	return pos, forward, true
+}
+
+// viTextObject returns the range of the word, quoted string or bracketed
+// text around the cursor, with its surroundings if around is set.
+func (t *Terminal) viTextObject(around bool, obj rune) (int, int, bool) {
+	if len(t.line) == 0 {
+		return 0, 0, false
+	}
+	pos := min(t.cursor, len(t.line)-1)
+
+	switch obj {
+	case 'w', 'W':
+		from, to := viWordObject(t.line, pos, obj == 'W', around)
+		return from, to, true
+	case '"', '\'', '`':
+		return viQuoteObject(t.line, pos, obj, around)
+	}
+
+	pairs := map[rune][2]rune{
+		'(': {'(', ')'}, ')': {'(', ')'}, 'b': {'(', ')'},
+		'[': {'[', ']'}, ']': {'[', ']'},
+		'{': {'{', '}'}, '}': {'{', '}'}, 'B': {'{', '}'},
+		'<': {'<', '>'}, '>': {'<', '>'},
+	}
+	pair := pairs[obj]
+	return viBracketObject(t.line, pos, pair[0], pair[1], around)
+}
+
+func viWordObject(line []rune, pos int, big, around bool) (int, int) {
+	class := viClass(line[pos], big)
+	from, to := pos, pos+1
+	for from > 0 && viClass(line[from-1], big) == class {
+		from--
+	}
+	for to < len(line) && viClass(line[to], big) == class {
+		to++
+	}
+	if !around {
+		return from, to
+	}
+
+	if class == 0 {
+		// the blanks and the word after them
+		if to < len(line) {
+			next := viClass(line[to], big)
+			for to < len(line) && viClass(line[to], big) == next {
+				to++
+			}
+		}
+		return from, to
+	}
+	end := to
+	for end < len(line) && unicode.IsSpace(line[end]) {
+		end++
+	}
+	if end > to {
+		return from, end
+	}
+	for from > 0 && unicode.IsSpace(line[from-1]) {
+		from--
+	}
+	return from, to
+}
+
+func viQuoteObject(line []rune, pos int, quote rune, around bool) (int, int, bool) {
+	quotes := make([]int, 0)
+	for i, r := range line {
+		if r == quote && (i == 0 || line[i-1] != '\\') {
+			quotes = append(quotes, i)
+		}
+	}
+
+	for i := 0; i+1 < len(quotes); i += 2 {
+		open, end := quotes[i], quotes[i+1]
+		if end < pos {
+			continue
+		}
+		if !around {
+			return open + 1, end, true
+		}
+		to := end + 1
+		for to < len(line) && unicode.IsSpace(line[to]) {
+			to++
+		}
+		return open, to, true
+	}
+	return 0, 0, false
+}
+
+func viBracketObject(line []rune, pos int, open, end rune, around bool) (int, int, bool) {
+	from, depth := -1, 0
+	for i := pos; i >= 0 && from < 0; i-- {
+		switch line[i] {
+		case end:
+			if i != pos {
+				depth++
+			}
+		case open:
+			if depth == 0 {
+				from = i
+			}
+			depth--
+		}
+	}
+	if from < 0 {
+		return 0, 0, false
+	}
+
+	depth = 0
+	for i := from + 1; i < len(line); i++ {
+		switch line[i] {
+		case open:
+			depth++
+		case end:
+			if depth > 0 {
+				depth--
+				continue
+			}
+			if around {
+				return from, i + 1, true
+			}
+			return from + 1, i, true
+		}
+	}
+	return 0, 0, false
+}
+
+// viClass groups runes into blanks, word characters and punctuation. Big
+// words only tell blanks apart.
+func viClass(r rune, big bool) int {
+	switch {
+	case unicode.IsSpace(r):
+		return 0
+	case big || r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r):
+		return 1
+	default:
+		return 2
+	}
+}
+
+func viNextWordStart(line []rune, pos int, big bool) int {
+	if pos >= len(line) {
+		return len(line)
+	}
+	if class := viClass(line[pos], big); class != 0 {
+		for pos < len(line) && viClass(line[pos], big) == class {
+			pos++
+		}
+	}
+	for pos < len(line) && viClass(line[pos], big) == 0 {
+		pos++
+	}
+	return pos
+}
+
+func viPrevWordStart(line []rune, pos int, big bool) int {
+	for pos > 0 && viClass(line[pos-1], big) == 0 {
+		pos--
+	}
+	if pos == 0 {
+		return 0
+	}
+	class := viClass(line[pos-1], big)
+	for pos > 0 && viClass(line[pos-1], big) == class {
+		pos--
+	}
+	return pos
+}
+
+func viWordEnd(line []rune, pos int, big bool) int {
+	pos++
+	for pos < len(line) && viClass(line[pos], big) == 0 {
+		pos++
+	}
+	if pos >= len(line) {
+		return max(0, len(line)-1)
+	}
+	class := viClass(line[pos], big)
+	for pos+1 < len(line) && viClass(line[pos+1], big) == class {
+		pos++
+	}
+	return pos
+}
+
+func firstNonBlank(line []rune) int {
+	for i, r := range line {
+		if !unicode.IsSpace(r) {
+			return i
+		}
+	}
+	return len(line)
+}
+
+func toggleCase(runes []rune) {
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			runes[i] = unicode.ToLower(r)
+		} else {
+			runes[i] = unicode.ToUpper(r)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package terminal
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestViEditing(t *testing.T) {
+	esc := imp(keyEscape)
+
+	tt := []struct {
+		name  string
+		input []byte
+		line  string
+	}{
+		{"insert mode types text", imp("echo hi"), "echo hi"},
+		{"x deletes under the cursor", imp("echo hix", esc, "x"), "echo hi"},
+		{"counts", imp("one two three four", esc, "0", "2dw"), "three four"},
+		{"counts multiply", imp("a b c d e f", esc, "0", "2d2w"), "e f"},
+		{"dw on the last word", imp("one two", esc, "0w", "dw"), "one "},
+		{"cw changes to the end of the word", imp("one two three", esc, "0w", "cwX", esc), "one X three"},
+		{"de and db", imp("one two three", esc, "0w", "de", "$", "db"), "one  e"},
+		{"dollar and zero", imp("abc def", esc, "0", "d$", "idone", esc), "done"},
+		{"caret", imp("  abc", esc, "^", "iX", esc), "  Xabc"},
+		{"f and t", imp("a,b,c,d", esc, "0", "dt,", "f,", "x"), ",bc,d"},
+		{"F and T backwards", imp("a,b,c,d", esc, "dF,"), "a,b,cd"},
+		{"semicolon and comma repeat the find", imp("a,b,c,d", esc, "0", "f,", ";", ";", ",", "x"), "a,bc,d"},
+		{"inner word", imp("echo hello world", esc, "0w", "ll", "diw"), "echo  world"},
+		{"around word", imp("echo hello world", esc, "0w", "daw"), "echo world"},
+		{"inner quotes", imp(`echo "hello world" x`, esc, "0", `ci"bye`, esc), `echo "bye" x`},
+		{"around quotes", imp(`echo "hello world" x`, esc, "0fw", `da"`), `echo x`},
+		{"inner parens", imp("f(a, (b), c)", esc, "0fb", "dib"), "f(a, (), c)"},
+		{"around nested parens", imp("f(a, (b), c)", esc, "0fa", "da("), "f"},
+		{"dd clears the line", imp("echo hi", esc, "dd"), ""},
+		{"cc", imp("echo hi", esc, "ccls", esc), "ls"},
+		{"yank and put", imp("ab", esc, "0", "yw", "$p"), "abab"},
+		{"put before", imp("ab", esc, "0", "yl", "2P"), "aaab"},
+		{"D and C", imp("abc def", esc, "0w", "D", "0", "Cx", esc), "x"},
+		{"r and tilde", imp("abc", esc, "0", "rX", "l", "2~"), "XBC"},
+		{"a, A and I", imp("bd", esc, "0", "ac", esc, "Ae", esc, "Ia", esc), "abcde"},
+		{"s substitutes", imp("abc", esc, "0", "2sX", esc), "Xc"},
+		{"undo", imp("one two", esc, "0", "dw", "x", "u"), "two"},
+		{"undo with count", imp("one two", esc, "0", "dw", "x", "2u"), "one two"},
+		{"undo an insert in one step", imp("a", esc, "abc", esc, "u"), "a"},
+		{"undo the first insert", imp("abc", esc, "u"), ""},
+		{"ctrl-r redoes", imp("one two", esc, "0", "dw", "u", byte(keyCtrlR)), "two"},
+		{"p puts the last kill", imp("one two", esc, "0", "dw", "x", "p"), "wto"},
+		{"repeat a delete", imp("one two three", esc, "0", "dw", "."), "three"},
+		{"repeat a change", imp("a b c", esc, "0", "cwX", esc, "w", ".", "w", "."), "X X X"},
+		{"repeat with a new count", imp("1 2 3 4 5", esc, "0", "dw", "3."), "5"},
+		{"visual delete", imp("echo hello world", esc, "0w", "ve", "d"), "echo  world"},
+		{"visual change", imp("echo hello world", esc, "0w", "vlllo", "c", "X", esc), "echo Xo world"},
+		{"visual yank then put", imp("ab", esc, "0", "vly", "$p"), "abab"},
+		{"visual text object", imp("f(a b)", esc, "0fa", "vi(", "~"), "f(A B)"},
+		{"escape cancels visual", imp("abc", esc, "0", "vl", esc, "x"), "ac"},
+		{"cw on a one letter word", imp("a b", esc, "0", "cwX", esc), "X b"},
+		{"invalid commands are dropped", imp("ab", esc, "0", "dq", "Q", "x"), "b"},
+		{"backspace moves left", imp("abc", esc, byte(keyDelete), "x"), "ac"},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			tr := NewTermReader(bytes.NewReader(imp(test.input, keyCarriageReturn)), NewTermWriter(io.Discard))
+			tr.SetEditMode(EditModeVi)
+
+			item := tr.NextItem()
+			assert.Equal(t, ItemLineInput, item.Type)
+			assert.Equal(t, test.line, item.Literal)
+
+			mode, ok := tr.ViMode()
+			assert.True(t, ok)
+			assert.Equal(t, ViInsert, mode)
+		})
+	}
+}
+
+func TestViHistoryKeys(t *testing.T) {
+	tr := NewTermReader(bytes.NewReader(imp("ab", keyEscape, keyEscape, "kj")), NewTermWriter(io.Discard))
+	tr.SetEditMode(EditModeVi)
+
+	assert.Equal(t, ItemKeyUp, tr.NextItem().Type)
+	assert.Equal(t, ItemKeyDown, tr.NextItem().Type)
+
+	mode, _ := tr.ViMode()
+	assert.Equal(t, ViNormal, mode)
+	assert.Equal(t, 1, tr.Cursor())
+}
+
+func TestViPromptMode(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	tr := NewTermReader(bytes.NewReader(imp("a", keyEscape, keyEscape, "i")), NewTermWriter(out))
+	tr.SetEditMode(EditModeVi)
+	tr.PromptStringFunc = func() string {
+		mode, _ := tr.ViMode()
+		return "[" + mode.String() + "] "
+	}
+
+	tr.NextItem()
+	assert.Contains(t, out.String(), "\r\x1b[J[normal] a")
+	assert.Contains(t, out.String(), "\r\x1b[J[insert] a")
+}
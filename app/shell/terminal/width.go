@@ -0,0 +1,426 @@
+package terminal
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// eastAsianWide holds the runes of East Asian Width W and F, which take
+// two columns, with the emoji that are shown as pictures by default.
+var eastAsianWide = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{0x1100, 0x115f, 1},
+		{0x231a, 0x231b, 1},
+		{0x2329, 0x232a, 1},
+		{0x23e9, 0x23ec, 1},
+		{0x23f0, 0x23f3, 3},
+		{0x25fd, 0x25fe, 1},
+		{0x2614, 0x2615, 1},
+		{0x2648, 0x2653, 1},
+		{0x267f, 0x2693, 20},
+		{0x26a1, 0x26a1, 1},
+		{0x26aa, 0x26ab, 1},
+		{0x26bd, 0x26be, 1},
+		{0x26c4, 0x26c5, 1},
+		{0x26ce, 0x26d4, 6},
+		{0x26ea, 0x26ea, 1},
+		{0x26f2, 0x26f3, 1},
+		{0x26f5, 0x26fa, 5},
+		{0x26fd, 0x2705, 8},
+		{0x270a, 0x270b, 1},
+		{0x2728, 0x2728, 1},
+		{0x274c, 0x274e, 2},
+		{0x2753, 0x2755, 1},
+		{0x2757, 0x2757, 1},
+		{0x2795, 0x2797, 1},
+		{0x27b0, 0x27bf, 15},
+		{0x2b1b, 0x2b1c, 1},
+		{0x2b50, 0x2b55, 5},
+		{0x2e80, 0x2e99, 1},
+		{0x2e9b, 0x2ef3, 1},
+		{0x2f00, 0x2fd5, 1},
+		{0x2ff0, 0x2fff, 1},
+		{0x3000, 0x303e, 1},
+		{0x3041, 0x3096, 1},
+		{0x3099, 0x30ff, 1},
+		{0x3105, 0x312f, 1},
+		{0x3131, 0x318e, 1},
+		{0x3190, 0x31e5, 1},
+		{0x31ef, 0x321e, 1},
+		{0x3220, 0x3247, 1},
+		{0x3250, 0x4dbf, 1},
+		{0x4e00, 0xa48c, 1},
+		{0xa490, 0xa4c6, 1},
+		{0xa960, 0xa97c, 1},
+		{0xac00, 0xd7a3, 1},
+		{0xf900, 0xfaff, 1},
+		{0xfe10, 0xfe19, 1},
+		{0xfe30, 0xfe52, 1},
+		{0xfe54, 0xfe66, 1},
+		{0xfe68, 0xfe6b, 1},
+		{0xff01, 0xff60, 1},
+		{0xffe0, 0xffe6, 1},
+	},
+	R32: []unicode.Range32{
+		{0x16fe0, 0x16fe4, 1},
+		{0x16ff0, 0x16ff1, 1},
+		{0x17000, 0x187f7, 1},
+		{0x18800, 0x18cd5, 1},
+		{0x18d00, 0x18d08, 1},
+		{0x1aff0, 0x1affe, 1},
+		{0x1b000, 0x1b122, 1},
+		{0x1b132, 0x1b132, 1},
+		{0x1b150, 0x1b152, 1},
+		{0x1b155, 0x1b155, 1},
+		{0x1b164, 0x1b167, 1},
+		{0x1b170, 0x1b2fb, 1},
+		{0x1f004, 0x1f004, 1},
+		{0x1f0cf, 0x1f0cf, 1},
+		{0x1f18e, 0x1f18e, 1},
+		{0x1f191, 0x1f19a, 1},
+		{0x1f200, 0x1f202, 1},
+		{0x1f210, 0x1f23b, 1},
+		{0x1f240, 0x1f248, 1},
+		{0x1f250, 0x1f251, 1},
+		{0x1f260, 0x1f265, 1},
+		{0x1f300, 0x1f320, 1},
+		{0x1f32d, 0x1f335, 1},
+		{0x1f337, 0x1f37c, 1},
+		{0x1f37e, 0x1f393, 1},
+		{0x1f3a0, 0x1f3ca, 1},
+		{0x1f3cf, 0x1f3d3, 1},
+		{0x1f3e0, 0x1f3f0, 1},
+		{0x1f3f4, 0x1f3f4, 1},
+		{0x1f3f8, 0x1f43e, 1},
+		{0x1f440, 0x1f440, 1},
+		{0x1f442, 0x1f4fc, 1},
+		{0x1f4ff, 0x1f53d, 1},
+		{0x1f54b, 0x1f54e, 1},
+		{0x1f550, 0x1f567, 1},
+		{0x1f57a, 0x1f57a, 1},
+		{0x1f595, 0x1f596, 1},
+		{0x1f5a4, 0x1f5a4, 1},
+		{0x1f5fb, 0x1f64f, 1},
+		{0x1f680, 0x1f6c5, 1},
+		{0x1f6cc, 0x1f6cc, 1},
+		{0x1f6d0, 0x1f6d2, 1},
+		{0x1f6d5, 0x1f6d7, 1},
+		{0x1f6dc, 0x1f6df, 1},
+		{0x1f6eb, 0x1f6ec, 1},
+		{0x1f6f4, 0x1f6fc, 1},
+		{0x1f7e0, 0x1f7eb, 1},
+		{0x1f7f0, 0x1f7f0, 1},
+		{0x1f90c, 0x1f93a, 1},
+		{0x1f93c, 0x1f945, 1},
+		{0x1f947, 0x1f9ff, 1},
+		{0x1fa70, 0x1fa7c, 1},
+		{0x1fa80, 0x1fa89, 1},
+		{0x1fa8f, 0x1fac6, 1},
+		{0x1face, 0x1fadc, 1},
+		{0x1fadf, 0x1fae9, 1},
+		{0x1faf0, 0x1faf8, 1},
+		{0x20000, 0x2fffd, 1},
+		{0x30000, 0x3fffd, 1},
+	},
+}
+
+// pictographic approximates Extended_Pictographic, the runes that emoji
+// sequences are made of.
+var pictographic = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{0x00a9, 0x00ae, 5},
+		{0x203c, 0x2049, 13},
+		{0x2122, 0x2139, 23},
+		{0x2194, 0x2199, 1},
+		{0x21a9, 0x21aa, 1},
+		{0x2300, 0x23ff, 1},
+		{0x24c2, 0x24c2, 1},
+		{0x25aa, 0x25ab, 1},
+		{0x25b6, 0x25c0, 10},
+		{0x25fb, 0x25fe, 1},
+		{0x2600, 0x27bf, 1},
+		{0x2934, 0x2935, 1},
+		{0x2b05, 0x2b07, 1},
+		{0x2b1b, 0x2b1c, 1},
+		{0x2b50, 0x2b55, 5},
+		{0x3030, 0x303d, 13},
+		{0x3297, 0x3299, 2},
+	},
+	R32: []unicode.Range32{
+		{0x1f000, 0x1f0ff, 1},
+		{0x1f10d, 0x1f10f, 1},
+		{0x1f12f, 0x1f12f, 1},
+		{0x1f16c, 0x1f171, 1},
+		{0x1f17e, 0x1f17f, 1},
+		{0x1f18e, 0x1f18e, 1},
+		{0x1f191, 0x1f19a, 1},
+		{0x1f1ad, 0x1f1e5, 1},
+		{0x1f201, 0x1f20f, 1},
+		{0x1f21a, 0x1f22f, 21},
+		{0x1f232, 0x1f23a, 1},
+		{0x1f23c, 0x1f23f, 1},
+		{0x1f249, 0x1f3fa, 1},
+		{0x1f400, 0x1f53d, 1},
+		{0x1f546, 0x1f64f, 1},
+		{0x1f680, 0x1f6ff, 1},
+		{0x1f774, 0x1f77f, 1},
+		{0x1f7d5, 0x1f7ff, 1},
+		{0x1f80c, 0x1f80f, 1},
+		{0x1f848, 0x1f84f, 1},
+		{0x1f85a, 0x1f85f, 1},
+		{0x1f888, 0x1f88f, 1},
+		{0x1f8ae, 0x1f8ff, 1},
+		{0x1f90c, 0x1f93a, 1},
+		{0x1f93c, 0x1f945, 1},
+		{0x1f947, 0x1faff, 1},
+		{0x1fc00, 0x1fffd, 1},
+	},
+}
+
+const (
+	zeroWidthJoiner   = 0x200d
+	emojiPresentation = 0xfe0f
+)
+
+// runeWidth returns the number of columns r takes on the screen.
+func runeWidth(r rune) int {
+	switch {
+	case r < 32 || r >= keyDelete && r < 0xa0:
+		return 0
+	case r >= 0x1160 && r <= 0x11ff || r >= 0xd7b0 && r <= 0xd7ff:
+		// Hangul vowels and final consonants join the syllable
+		// before them
+		return 0
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		return 0
+	case unicode.Is(eastAsianWide, r):
+		return 2
+	}
+	return 1
+}
+
+// graphemeWidth returns the number of columns the grapheme cluster g
+// takes on the screen.
+func graphemeWidth(g []rune) int {
+	if len(g) == 0 {
+		return 0
+	}
+	w := runeWidth(g[0])
+	switch {
+	case w == 0:
+		return 0
+	case graphemeClassOf(g[0]) == graphemeRegional && len(g) > 1:
+		// a pair of regional indicators is a flag
+		return 2
+	case slices.Contains(g, emojiPresentation):
+		return 2
+	}
+	return w
+}
+
+// textWidth returns the number of columns s takes on the screen when it
+// fits on a row.
+func textWidth(s []rune) int {
+	w := 0
+	for len(s) > 0 {
+		n := graphemeLen(s)
+		w += graphemeWidth(s[:n])
+		s = s[n:]
+	}
+	return w
+}
+
+// truncateWidth returns the grapheme clusters at the start of s that fit
+// in width columns.
+func truncateWidth(s []rune, width int) []rune {
+	w, i := 0, 0
+	for i < len(s) {
+		n := graphemeLen(s[i:])
+		w += graphemeWidth(s[i : i+n])
+		if w > width {
+			break
+		}
+		i += n
+	}
+	return s[:i]
+}
+
+// nextGrapheme returns where the grapheme cluster after the one at i in
+// s starts.
+func nextGrapheme(s []rune, i int) int {
+	if i >= len(s) {
+		return len(s)
+	}
+	return i + graphemeLen(s[i:])
+}
+
+// prevGrapheme returns where the grapheme cluster before i in s starts.
+func prevGrapheme(s []rune, i int) int {
+	start := 0
+	for j := 0; j < i && j < len(s); j = nextGrapheme(s, j) {
+		start = j
+	}
+	return start
+}
+
+type graphemeClass int
+
+// Grapheme_Cluster_Break properties, from Unicode Standard Annex #29
+const (
+	graphemeOther graphemeClass = iota
+	graphemeCR
+	graphemeLF
+	graphemeControl
+	graphemeExtend
+	graphemeZWJ
+	graphemeSpacingMark
+	graphemeRegional
+	graphemeL
+	graphemeV
+	graphemeT
+	graphemeLV
+	graphemeLVT
+	graphemePictographic
+)
+
+func graphemeClassOf(r rune) graphemeClass {
+	switch {
+	case r == '\r':
+		return graphemeCR
+	case r == '\n':
+		return graphemeLF
+	case r == zeroWidthJoiner:
+		return graphemeZWJ
+	case r == 0x200c || r >= 0x1f3fb && r <= 0x1f3ff || r >= 0xe0020 && r <= 0xe007f:
+		// zero width non-joiner, emoji skin tones and tags
+		return graphemeExtend
+	case r >= 0x1f1e6 && r <= 0x1f1ff:
+		return graphemeRegional
+	case r >= 0x1100 && r <= 0x115f || r >= 0xa960 && r <= 0xa97c:
+		return graphemeL
+	case r >= 0x1160 && r <= 0x11a7 || r >= 0xd7b0 && r <= 0xd7c6:
+		return graphemeV
+	case r >= 0x11a8 && r <= 0x11ff || r >= 0xd7cb && r <= 0xd7fb:
+		return graphemeT
+	case r >= 0xac00 && r <= 0xd7a3:
+		if (r-0xac00)%28 == 0 {
+			return graphemeLV
+		}
+		return graphemeLVT
+	case unicode.In(r, unicode.Cc, unicode.Zl, unicode.Zp, unicode.Cf):
+		return graphemeControl
+	case unicode.In(r, unicode.Mn, unicode.Me):
+		return graphemeExtend
+	case unicode.Is(unicode.Mc, r):
+		return graphemeSpacingMark
+	case unicode.Is(pictographic, r):
+		return graphemePictographic
+	}
+	return graphemeOther
+}
+
+// graphemeLen returns the number of runes in the grapheme cluster s
+// starts with, following the extended grapheme cluster rules of Unicode
+// Standard Annex #29 without those for Prepend and Indic conjuncts.
+func graphemeLen(s []rune) int {
+	if len(s) == 0 {
+		return 0
+	}
+
+	prev := graphemeClassOf(s[0])
+	// emoji is set while the cluster is a pictograph followed by
+	// extending runes, which a zero width joiner can join to another
+	// pictograph. regionals counts the regional indicators in a row.
+	emoji := prev == graphemePictographic
+	regionals := 0
+	if prev == graphemeRegional {
+		regionals = 1
+	}
+
+	for i := 1; i < len(s); i++ {
+		next := graphemeClassOf(s[i])
+		if graphemeBreak(prev, next, emoji, regionals) {
+			return i
+		}
+
+		switch next {
+		case graphemePictographic:
+			emoji = true
+		case graphemeExtend, graphemeZWJ:
+		default:
+			emoji = false
+		}
+		if next == graphemeRegional {
+			regionals++
+		}
+		prev = next
+	}
+	return len(s)
+}
+
+// graphemeBreak reports whether a grapheme cluster ends between runes of
+// the classes prev and next.
+func graphemeBreak(prev, next graphemeClass, emoji bool, regionals int) bool {
+	switch {
+	case prev == graphemeCR && next == graphemeLF:
+		return false
+	case prev == graphemeCR || prev == graphemeLF || prev == graphemeControl:
+		return true
+	case next == graphemeCR || next == graphemeLF || next == graphemeControl:
+		return true
+	case prev == graphemeL && (next == graphemeL || next == graphemeV || next == graphemeLV || next == graphemeLVT):
+		return false
+	case (prev == graphemeLV || prev == graphemeV) && (next == graphemeV || next == graphemeT):
+		return false
+	case (prev == graphemeLVT || prev == graphemeT) && next == graphemeT:
+		return false
+	case next == graphemeExtend || next == graphemeZWJ || next == graphemeSpacingMark:
+		return false
+	case prev == graphemeZWJ && next == graphemePictographic && emoji:
+		return false
+	case prev == graphemeRegional && next == graphemeRegional:
+		return regionals%2 == 0
+	}
+	return true
+}
+
+// stripEscapes removes the escape sequences from s, e.g. the colors of
+// a prompt, which take no room on the screen.
+func stripEscapes(s string) string {
+	if !strings.ContainsRune(s, rune(keyEscape)) {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != keyEscape {
+			b.WriteByte(s[i])
+			continue
+		}
+		if i+1 >= len(s) {
+			break
+		}
+
+		i++
+		switch s[i] {
+		case csi:
+			// parameters and intermediates up to the final byte
+			for i+1 < len(s) && (s[i+1] < 0x40 || s[i+1] > 0x7e) {
+				i++
+			}
+			i++
+		case ']':
+			// an operating system command ends with a bell or
+			// ESC \
+			for i+1 < len(s) && s[i+1] != bell && s[i+1] != keyEscape {
+				i++
+			}
+			i++
+			if i < len(s) && s[i] == keyEscape {
+				i++
+			}
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,118 @@
+package terminal
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGraphemeLen(t *testing.T) {
+	tt := []struct {
+		name string
+		text string
+		n    int
+	}{
+		{"ascii", "ab", 1},
+		{"combining accent", "e\u0301x", 2},
+		{"crlf", "\r\nx", 2},
+		{"control", "\ta", 1},
+		{"hangul jamo", "\u1100\u1161\u11a8x", 3},
+		{"hangul syllable and final", "가\u11a8x", 2},
+		{"skin tone", "\U0001f44d\U0001f3fdx", 2},
+		{"zwj sequence", "\U0001f468\u200d\U0001f469\u200d\U0001f467x", 5},
+		{"zwj without pictograph", "a\u200d\U0001f469", 2},
+		{"flag", "\U0001f1eb\U0001f1f7\U0001f1e9\U0001f1ea", 2},
+		{"variation selector", "❤\ufe0fx", 2},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.n, graphemeLen([]rune(test.text)))
+		})
+	}
+}
+
+func TestTextWidth(t *testing.T) {
+	tt := []struct {
+		text  string
+		width int
+	}{
+		{"abc", 3},
+		{"日本語", 6},
+		{"ｆｕｌｌ", 8},
+		{"e\u0301", 1},
+		{"\U0001f44d\U0001f3fd", 2},
+		{"\U0001f468\u200d\U0001f469\u200d\U0001f467", 2},
+		{"\U0001f1eb\U0001f1f7", 2},
+		{"❤\ufe0f", 2},
+		{"❤", 1},
+		{"가\u11a8", 2},
+	}
+
+	for _, test := range tt {
+		t.Run(test.text, func(t *testing.T) {
+			assert.Equal(t, test.width, textWidth([]rune(test.text)))
+		})
+	}
+}
+
+func TestStripEscapes(t *testing.T) {
+	assert.Equal(t, "$ ", stripEscapes("\x1b[1;31m$\x1b[0m "))
+	assert.Equal(t, "~ > ", stripEscapes("\x1b]0;title\x07~ \x1b]8;;file:///\x1b\\> "))
+	assert.Equal(t, "plain", stripEscapes("plain"))
+}
+
+func TestWideLineEditing(t *testing.T) {
+	left := imp(keyEscape, "[D")
+
+	tt := []struct {
+		name  string
+		input []byte
+		line  string
+	}{
+		{"backspace removes the accent with its letter", imp("cafe\u0301", byte(keyBackspace), keyCarriageReturn), "caf"},
+		{"arrows skip over emoji sequences", imp("a\U0001f468\u200d\U0001f469b", left, left, "X", keyCarriageReturn), "aX\U0001f468\u200d\U0001f469b"},
+		{"delete removes a flag", imp("\U0001f1eb\U0001f1f7\U0001f1e9\U0001f1ea", byte(keyCtrlA), keyEscape, "[3~", keyCarriageReturn), "\U0001f1e9\U0001f1ea"},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			tr := NewTermReader(bytes.NewReader(test.input), NewTermWriter(io.Discard))
+			item := tr.NextItem()
+			assert.Equal(t, test.line, item.Literal)
+		})
+	}
+}
+
+func TestWideRender(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	tr := NewTermReader(bytes.NewReader(imp("日本語", byte(keyCtrlB), "abc")), NewTermWriter(out))
+	tr.PromptStringFunc = func() string { return "\x1b[31m$\x1b[0m " }
+	tr.SizeFunc = func() (int, int, error) { return 10, 24, nil }
+	_ = tr.Ready()
+	_, _ = tr.Writer().Commit()
+	out.Reset()
+
+	tr.NextItem()
+	// ctrl-b moves over a character two columns wide, and the prompt
+	// takes two columns without its colors
+	assert.True(t, strings.HasPrefix(out.String(), "日本語\x1b[2D"), out.String())
+	// the wide character that does not fit wraps to the next row
+	assert.True(t, strings.HasSuffix(out.String(), "$\x1b[0m 日本abc語\x1b[1A\x1b[7C"), out.String())
+}
+
+func TestShowHint(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	tr := NewTermReader(bytes.NewReader(imp("日本語")), NewTermWriter(out))
+	tr.SizeFunc = func() (int, int, error) { return 10, 24, nil }
+	_ = tr.Ready()
+	tr.NextItem()
+	out.Reset()
+
+	// the hint is cut to the room left on the row
+	tr.ShowHint("abcdef")
+	assert.Equal(t, "\x1b[K\x1b[90ma\x1b[1D\x1b[38;5;141m", out.String())
+}
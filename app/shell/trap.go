@@ -0,0 +1,105 @@
+package shell
+
+import (
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/codecrafters-io/shell-starter-go/app/cmd"
+	"github.com/codecrafters-io/shell-starter-go/app/shell/interpreter"
+	"github.com/codecrafters-io/shell-starter-go/assert"
+)
+
+func NewTrapCommandFunc(s *Shell) cmd.CommandFunc {
+	assert.NotNil(s, "shell")
+	return func() *cmd.Command {
+		return &cmd.Command{
+			Name: "trap",
+			Spec: &cmd.Spec{
+				Synopsis: "trap [-lp] [[action] signal_spec ...]",
+				Short:    "Trap signals and other events.",
+				Flags: []cmd.Flag{
+					{Name: 'l', Usage: "list the signal names and numbers"},
+					{Name: 'p', Usage: "display the trap commands"},
+				},
+			},
+			Run: func(cmd *cmd.Command, args []string) error {
+				assert.Assert(len(args) > 0)
+
+				flags, rest, err := cmd.Spec.Parse(args[1:])
+				if err != nil {
+					return fmt.Errorf("trap: %w", err)
+				}
+
+				if _, ok := flags['l']; ok {
+					printSignalList(cmd.Stdout)
+					return nil
+				}
+
+				_, printTraps := flags['p']
+				if printTraps || len(rest) == 0 {
+					return s.printTraps(cmd.Stdout, rest)
+				}
+
+				// a single signal spec resets it like `trap - SIG`
+				action, specs := rest[0], rest[1:]
+				if _, isSignal := parseSignalSpec(action); isSignal && len(specs) == 0 {
+					action, specs = "-", rest
+				}
+
+				for _, spec := range specs {
+					name, ok := parseSignalSpec(spec)
+					if !ok {
+						return fmt.Errorf("trap: %s: invalid signal specification", spec)
+					}
+
+					if action == "-" {
+						s.traps.reset(name)
+						s.releaseSignal(name)
+						continue
+					}
+
+					s.traps.set(name, action)
+					s.catchSignal(name)
+				}
+				return nil
+			},
+		}
+	}
+}
+
+func (s *Shell) printTraps(w io.Writer, specs []string) error {
+	names := s.traps.names()
+	if len(specs) > 0 {
+		names = make([]string, 0, len(specs))
+		for _, spec := range specs {
+			name, ok := parseSignalSpec(spec)
+			if !ok {
+				return fmt.Errorf("trap: %s: invalid signal specification", spec)
+			}
+			names = append(names, name)
+		}
+	}
+
+	for _, name := range names {
+		action, ok := s.traps.get(name)
+		if !ok {
+			continue
+		}
+		if !slices.Contains(pseudoSignals, name) {
+			name = "SIG" + name
+		}
+		_, _ = fmt.Fprintf(w, "trap -- %s %s\n", interpreter.Quote(action), name)
+	}
+	return nil
+}
+
+func printSignalList(w io.Writer) {
+	sigs := slices.Clone(signalTable)
+	slices.SortFunc(sigs, func(a, b signalInfo) int {
+		return int(a.sig) - int(b.sig)
+	})
+	for _, si := range sigs {
+		_, _ = fmt.Fprintf(w, "%2d) SIG%s\n", int(si.sig), si.name)
+	}
+}
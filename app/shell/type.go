@@ -2,8 +2,10 @@ package shell
 
 import (
 	"fmt"
+	"io"
 
 	"github.com/codecrafters-io/shell-starter-go/app/cmd"
+	"github.com/codecrafters-io/shell-starter-go/app/shell/interpreter"
 	"github.com/codecrafters-io/shell-starter-go/assert"
 )
 
@@ -12,29 +14,91 @@ func NewTypeCommandFunc(r *cmd.Registry) cmd.CommandFunc {
 	return func() *cmd.Command {
 		return &cmd.Command{
 			Name: "type",
+			Spec: &cmd.Spec{
+				Synopsis: "type [-afptP] name [name ...]",
+				Short:    "Display information about command type.",
+				Flags: []cmd.Flag{
+					{Name: 'a', Usage: "display all locations containing NAME"},
+					{Name: 'f', Usage: "suppress shell function lookup"},
+					{Name: 'P', Usage: "force a PATH search for each NAME"},
+					{Name: 'p', Usage: "print the file that would be executed"},
+					{Name: 't', Usage: "print a single word describing NAME"},
+				},
+				Args: cmd.ArgCommand,
+			},
 			Run: func(cmd *cmd.Command, args []string) error {
 				assert.Assert(len(args) > 0)
 
-				if len(args) != 2 {
-					return fmt.Errorf("expected exactly one argument to the 'type' command")
+				// -f skips functions which do not exist yet, so it is
+				// accepted but changes nothing
+				flags, names, err := cmd.Spec.Parse(args[1:])
+				if err != nil {
+					return fmt.Errorf("type: %w", err)
 				}
 
-				cmdName := args[1]
-				if _, found := r.LookupBuiltinCommand(cmdName); found {
-					_, _ = fmt.Fprintf(cmd.Stdout, "%s is a shell builtin\n", cmdName)
-					return nil
-				}
+				_, all := flags['a']
+				_, kindOnly := flags['t']
+				_, pathOnly := flags['p']
+				_, forcePath := flags['P']
 
-				path, _, found := r.LookupPathCommand(cmdName)
-				if found {
-					assert.Assert(len(path) > 0)
-					_, _ = fmt.Fprintf(cmd.Stdout, "%s is %s\n", cmdName, path)
-					return nil
-				}
+				var status error
+				for _, name := range names {
+					candidates := r.LookupAll(name)
+					if forcePath {
+						candidates = filesOnly(candidates)
+					}
+					if !all && len(candidates) > 1 {
+						candidates = candidates[:1]
+					}
+
+					if len(candidates) == 0 {
+						if !kindOnly && !pathOnly && !forcePath {
+							_, _ = fmt.Fprintf(cmd.Stdout, "%s: not found\n", name)
+						}
+						status = interpreter.ExitStatus(1)
+						continue
+					}
 
-				fmt.Fprintf(cmd.Stdout, "%s: not found\n", cmdName)
-				return nil
+					for _, c := range candidates {
+						switch {
+						case kindOnly:
+							_, _ = fmt.Fprintln(cmd.Stdout, c.Kind)
+						case pathOnly || forcePath:
+							printCandidatePath(cmd.Stdout, c)
+						default:
+							describeCandidate(cmd.Stdout, c)
+						}
+					}
+				}
+				return status
 			},
 		}
 	}
 }
+
+func describeCandidate(w io.Writer, c cmd.Candidate) {
+	switch c.Kind {
+	case cmd.KindBuiltin:
+		_, _ = fmt.Fprintf(w, "%s is a shell builtin\n", c.Name)
+	case cmd.KindFile:
+		assert.Assert(len(c.Path) > 0)
+		_, _ = fmt.Fprintf(w, "%s is %s\n", c.Name, c.Path)
+	}
+}
+
+// printCandidatePath prints the path of c if it is an executable.
+func printCandidatePath(w io.Writer, c cmd.Candidate) {
+	if c.Kind == cmd.KindFile {
+		_, _ = fmt.Fprintln(w, c.Path)
+	}
+}
+
+func filesOnly(candidates []cmd.Candidate) []cmd.Candidate {
+	files := make([]cmd.Candidate, 0, len(candidates))
+	for _, c := range candidates {
+		if c.Kind == cmd.KindFile {
+			files = append(files, c)
+		}
+	}
+	return files
+}
@@ -0,0 +1,161 @@
+package shell
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/codecrafters-io/shell-starter-go/app/cmd"
+	"github.com/codecrafters-io/shell-starter-go/app/shell/interpreter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newLookupTestRegistry returns a registry with echo both as a builtin
+// and in PATH, and ls in two PATH directories. Executables print the
+// path they ran from along with their arguments.
+func newLookupTestRegistry() *cmd.Registry {
+	fsys := fstest.MapFS{
+		"bin/echo":   {Mode: 0o755},
+		"bin/ls":     {Mode: 0o755},
+		"usr/bin/ls": {Mode: 0o755},
+		"bin/README": {Mode: 0o644},
+	}
+	r := cmd.NewResitry(func(name, execPath string) cmd.CommandFunc {
+		return func() *cmd.Command {
+			return &cmd.Command{
+				Name: name,
+				Run: func(c *cmd.Command, args []string) error {
+					_, err := fmt.Fprintln(c.Stdout, "ran", execPath, strings.Join(args[1:], " "))
+					return err
+				},
+			}
+		}
+	})
+	r.IndexPath("bin:usr/bin", fsys, func(dir string) (string, error) { return dir, nil })
+
+	r.AddBuiltinCommand("echo", NewEchoCommandFunc())
+	r.AddBuiltinCommand("type", NewTypeCommandFunc(r))
+	r.AddBuiltinCommand("command", NewCommandCommandFunc(r))
+	r.AddBuiltinCommand("builtin", NewBuiltinCommandFunc(r))
+	r.AddBuiltinCommand("which", NewWhichCommandFunc(r))
+	r.AddBuiltinCommand("hash", NewHashCommandFunc(r))
+	return r
+}
+
+func TestLookupCommands(t *testing.T) {
+	tt := []struct {
+		args   string
+		stdout string
+		stderr string
+		status int
+	}{
+		{
+			args:   "type echo ls missing",
+			stdout: "echo is a shell builtin\nls is bin/ls\nmissing: not found\n",
+			status: 1,
+		},
+		{
+			args: "type -a echo ls",
+			stdout: "echo is a shell builtin\necho is bin/echo\n" +
+				"ls is bin/ls\nls is usr/bin/ls\n",
+		},
+		{
+			args:   "type -t echo ls missing",
+			stdout: "builtin\nfile\n",
+			status: 1,
+		},
+		{
+			args:   "type -p echo ls missing",
+			stdout: "bin/ls\n",
+			status: 1,
+		},
+		{
+			args:   "type -P echo",
+			stdout: "bin/echo\n",
+		},
+		{
+			args:   "type -ap ls",
+			stdout: "bin/ls\nusr/bin/ls\n",
+		},
+		{
+			args:   "type README",
+			stdout: "README: not found\n",
+			status: 1,
+		},
+		{
+			args:   "command -v echo ls missing",
+			stdout: "echo\nbin/ls\n",
+			status: 1,
+		},
+		{
+			args:   "command -V echo ls missing",
+			stdout: "echo is a shell builtin\nls is bin/ls\n",
+			stderr: "command: missing: not found\n",
+			status: 1,
+		},
+		{
+			args:   "command echo hello",
+			stdout: "hello\n",
+		},
+		{
+			args:   "command ls -l",
+			stdout: "ran bin/ls -l\n",
+		},
+		{
+			args:   "command missing",
+			status: 127,
+		},
+		{
+			args: "command",
+		},
+		{
+			args:   "builtin echo hello",
+			stdout: "hello\n",
+		},
+		{
+			args:   "builtin ls",
+			status: 1,
+		},
+		{
+			args:   "which ls echo missing",
+			stdout: "bin/ls\necho: shell built-in command\nmissing not found\n",
+			status: 1,
+		},
+		{
+			args:   "which -a ls echo",
+			stdout: "bin/ls\nusr/bin/ls\necho: shell built-in command\nbin/echo\n",
+		},
+	}
+
+	r := newLookupTestRegistry()
+	for _, test := range tt {
+		t.Run(test.args, func(t *testing.T) {
+			stdout, stderr, err := runBuiltin(t, r, test.args)
+			assert.Equal(t, test.stdout, stdout)
+			assert.Equal(t, test.stderr, stderr)
+			assert.Equal(t, test.status, interpreter.StatusOf(err))
+		})
+	}
+}
+
+// runBuiltin runs the builtin named by the first of the space separated
+// args and returns what it wrote along with its error.
+func runBuiltin(t *testing.T, r *cmd.Registry, args string) (string, string, error) {
+	t.Helper()
+	fields := strings.Fields(args)
+	c, ok := r.LookupBuiltinCommand(fields[0])
+	require.True(t, ok)
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	c.Stdout, c.Stderr = stdout, stderr
+	err := c.Run(c, fields)
+	return stdout.String(), stderr.String(), err
+}
+
+func TestBuiltinNotBuiltin(t *testing.T) {
+	_, _, err := runBuiltin(t, newLookupTestRegistry(), "builtin ls")
+	assert.EqualError(t, err, "builtin: ls: not a shell builtin")
+}
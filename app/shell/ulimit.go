@@ -0,0 +1,197 @@
+package shell
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+
+	"github.com/codecrafters-io/shell-starter-go/app/cmd"
+	"github.com/codecrafters-io/shell-starter-go/assert"
+)
+
+const rlimInfinity = math.MaxUint64
+
+// rlimitInfo describes a resource limit that can be changed with
+// `ulimit`. Values are shown and given in multiples of scale.
+type rlimitInfo struct {
+	flag     byte
+	desc     string
+	unit     string
+	scale    uint64
+	resource int
+}
+
+func NewUlimitCommandFunc() cmd.CommandFunc {
+	return func() *cmd.Command {
+		return &cmd.Command{
+			Name: "ulimit",
+			Spec: ulimitSpec(),
+			Run: func(cmd *cmd.Command, args []string) error {
+				assert.Assert(len(args) > 0)
+
+				flags, rest, err := cmd.Spec.Parse(args[1:])
+				if err != nil {
+					return fmt.Errorf("ulimit: %w", err)
+				}
+				if len(rlimitTable) == 0 {
+					return fmt.Errorf("ulimit: not supported on this platform")
+				}
+				if len(rest) > 1 {
+					return fmt.Errorf("ulimit: too many arguments")
+				}
+
+				_, hard := flags['H']
+				_, soft := flags['S']
+				_, all := flags['a']
+
+				selected := make([]rlimitInfo, 0, len(rlimitTable))
+				for _, ri := range rlimitTable {
+					if _, ok := flags[ri.flag]; ok || all {
+						selected = append(selected, ri)
+					}
+				}
+				if len(selected) == 0 {
+					ri, _ := lookupRlimit('f')
+					selected = append(selected, ri)
+				}
+
+				if len(rest) == 0 {
+					for _, ri := range selected {
+						if err := printRlimit(cmd.Stdout, ri, hard && !soft, len(selected) > 1); err != nil {
+							return fmt.Errorf("ulimit: %s: %w", ri.desc, err)
+						}
+					}
+					return nil
+				}
+
+				if all {
+					return fmt.Errorf("ulimit: cannot set all limits at once")
+				}
+
+				// without -H or -S both limits are set
+				if !hard && !soft {
+					hard, soft = true, true
+				}
+				for _, ri := range selected {
+					if err := setRlimitValue(ri, rest[0], hard, soft); err != nil {
+						return fmt.Errorf("ulimit: %s: %w", ri.desc, err)
+					}
+				}
+				return nil
+			},
+		}
+	}
+}
+
+// ulimitSpec lists the limits of the platform as flags.
+func ulimitSpec() *cmd.Spec {
+	flags := []cmd.Flag{
+		{Name: 'S', Usage: "use the soft resource limit"},
+		{Name: 'H', Usage: "use the hard resource limit"},
+		{Name: 'a', Usage: "report all current limits"},
+	}
+	synopsis := "ulimit [-SHa]"
+	if len(rlimitTable) > 0 {
+		synopsis += " [-"
+		for _, ri := range rlimitTable {
+			flags = append(flags, cmd.Flag{Name: ri.flag, Usage: ri.desc})
+			synopsis += string(ri.flag)
+		}
+		synopsis += "]"
+	}
+
+	return &cmd.Spec{
+		Synopsis: synopsis + " [limit]",
+		Short:    "Modify shell resource limits.",
+		Long:     "LIMIT is a number, or one of unlimited, hard and soft. Without a flag -f is assumed.",
+		Flags:    flags,
+	}
+}
+
+func lookupRlimit(flag byte) (rlimitInfo, bool) {
+	for _, ri := range rlimitTable {
+		if ri.flag == flag {
+			return ri, true
+		}
+	}
+	return rlimitInfo{}, false
+}
+
+func printRlimit(w io.Writer, ri rlimitInfo, hard bool, long bool) error {
+	cur, max, err := getrlimit(ri.resource)
+	if err != nil {
+		return err
+	}
+
+	limit := cur
+	if hard {
+		limit = max
+	}
+	_, err = fmt.Fprintln(w, formatRlimit(ri, limit, long))
+	return err
+}
+
+// formatRlimit formats limit in multiples of the scale of ri, preceded
+// by its description when long is set as in `ulimit -a`.
+func formatRlimit(ri rlimitInfo, limit uint64, long bool) string {
+	value := "unlimited"
+	if limit != rlimInfinity {
+		value = strconv.FormatUint(limit/ri.scale, 10)
+	}
+
+	if !long {
+		return value
+	}
+
+	unit := fmt.Sprintf("(-%c) ", ri.flag)
+	if len(ri.unit) > 0 {
+		unit = fmt.Sprintf("(%s, -%c) ", ri.unit, ri.flag)
+	}
+	return fmt.Sprintf("%-20s %20s%s", ri.desc, unit, value)
+}
+
+func setRlimitValue(ri rlimitInfo, value string, hard, soft bool) error {
+	cur, max, err := getrlimit(ri.resource)
+	if err != nil {
+		return err
+	}
+
+	limit, err := parseRlimit(ri, value, cur, max)
+	if err != nil {
+		return err
+	}
+
+	if hard {
+		max = limit
+	}
+	if soft {
+		cur = limit
+	}
+	if err := setrlimit(ri.resource, cur, max); err != nil {
+		return fmt.Errorf("cannot modify limit: %w", err)
+	}
+	return nil
+}
+
+// parseRlimit parses a limit given to ulimit in multiples of the scale
+// of ri. cur and max are the current soft and hard limits.
+func parseRlimit(ri rlimitInfo, value string, cur, max uint64) (uint64, error) {
+	switch value {
+	case "unlimited":
+		return rlimInfinity, nil
+	case "hard":
+		return max, nil
+	case "soft":
+		return cur, nil
+	}
+
+	n, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s: invalid number", value)
+	}
+	if n > rlimInfinity/ri.scale {
+		return 0, fmt.Errorf("%s: limit out of range", value)
+	}
+	return n * ri.scale, nil
+}
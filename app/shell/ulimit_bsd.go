@@ -0,0 +1,11 @@
+//go:build darwin || dragonfly || freebsd || netbsd || openbsd
+
+package shell
+
+import "golang.org/x/sys/unix"
+
+var platformRlimits = []rlimitInfo{
+	{'l', "max locked memory", "kbytes", 1024, unix.RLIMIT_MEMLOCK},
+	{'m', "max memory size", "kbytes", 1024, unix.RLIMIT_RSS},
+	{'u', "max user processes", "", 1, unix.RLIMIT_NPROC},
+}
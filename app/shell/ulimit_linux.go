@@ -0,0 +1,16 @@
+package shell
+
+import "golang.org/x/sys/unix"
+
+var platformRlimits = []rlimitInfo{
+	{'e', "scheduling priority", "", 1, unix.RLIMIT_NICE},
+	{'i', "pending signals", "", 1, unix.RLIMIT_SIGPENDING},
+	{'l', "max locked memory", "kbytes", 1024, unix.RLIMIT_MEMLOCK},
+	{'m', "max memory size", "kbytes", 1024, unix.RLIMIT_RSS},
+	{'q', "POSIX message queues", "bytes", 1, unix.RLIMIT_MSGQUEUE},
+	{'r', "real-time priority", "", 1, unix.RLIMIT_RTPRIO},
+	{'R', "real-time non-blocking time", "microseconds", 1, unix.RLIMIT_RTTIME},
+	{'u', "max user processes", "", 1, unix.RLIMIT_NPROC},
+	{'v', "virtual memory", "kbytes", 1024, unix.RLIMIT_AS},
+	{'x', "file locks", "", 1, unix.RLIMIT_LOCKS},
+}
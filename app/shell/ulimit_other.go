@@ -0,0 +1,17 @@
+//go:build !unix
+
+package shell
+
+import "errors"
+
+var rlimitTable = []rlimitInfo{}
+
+var errRlimitUnsupported = errors.New("resource limits are not supported on this platform")
+
+func getrlimit(resource int) (cur uint64, max uint64, err error) {
+	return 0, 0, errRlimitUnsupported
+}
+
+func setrlimit(resource int, cur uint64, max uint64) error {
+	return errRlimitUnsupported
+}
@@ -0,0 +1,63 @@
+package shell
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	testFileSize  = rlimitInfo{'f', "file size", "blocks", 1024, 0}
+	testOpenFiles = rlimitInfo{'n', "open files", "", 1, 0}
+)
+
+func TestFormatRlimit(t *testing.T) {
+	tt := []struct {
+		ri    rlimitInfo
+		limit uint64
+		long  bool
+		want  string
+	}{
+		{testFileSize, 4096, false, "4"},
+		{testFileSize, 4000, false, "3"},
+		{testFileSize, rlimInfinity, false, "unlimited"},
+		{testOpenFiles, 1024, false, "1024"},
+		{testFileSize, 4096, true, "file size                   (blocks, -f) 4"},
+		{testOpenFiles, rlimInfinity, true, "open files                          (-n) unlimited"},
+	}
+
+	for _, test := range tt {
+		assert.Equal(t, test.want, formatRlimit(test.ri, test.limit, test.long))
+	}
+}
+
+func TestParseRlimit(t *testing.T) {
+	const cur, max = 1024, 4096
+	tt := []struct {
+		ri    rlimitInfo
+		value string
+		want  uint64
+		err   string
+	}{
+		{testOpenFiles, "256", 256, ""},
+		{testFileSize, "4", 4096, ""},
+		{testFileSize, "0", 0, ""},
+		{testOpenFiles, "unlimited", rlimInfinity, ""},
+		{testOpenFiles, "hard", max, ""},
+		{testOpenFiles, "soft", cur, ""},
+		{testOpenFiles, "-1", 0, "-1: invalid number"},
+		{testOpenFiles, "many", 0, "many: invalid number"},
+		{testOpenFiles, "18446744073709551616", 0, "18446744073709551616: invalid number"},
+		{testFileSize, "18446744073709551615", 0, "18446744073709551615: limit out of range"},
+	}
+
+	for _, test := range tt {
+		limit, err := parseRlimit(test.ri, test.value, cur, max)
+		if len(test.err) > 0 {
+			assert.EqualError(t, err, test.err, test.value)
+			continue
+		}
+		assert.NoError(t, err, test.value)
+		assert.Equal(t, test.want, limit, test.value)
+	}
+}
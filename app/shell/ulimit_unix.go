@@ -0,0 +1,69 @@
+//go:build unix
+
+package shell
+
+import (
+	"cmp"
+	"slices"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// posixRlimits are the limits every unix has, platformRlimits the ones
+// only some of them know
+var posixRlimits = []rlimitInfo{
+	{'c', "core file size", "blocks", 1024, unix.RLIMIT_CORE},
+	{'d', "data seg size", "kbytes", 1024, unix.RLIMIT_DATA},
+	{'f', "file size", "blocks", 1024, unix.RLIMIT_FSIZE},
+	{'n', "open files", "", 1, unix.RLIMIT_NOFILE},
+	{'s', "stack size", "kbytes", 1024, unix.RLIMIT_STACK},
+	{'t', "cpu time", "seconds", 1, unix.RLIMIT_CPU},
+}
+
+// rlimitTable lists the limits by flag the way `ulimit -a` shows them,
+// with a lower case flag before the same one in upper case
+var rlimitTable = func() []rlimitInfo {
+	table := slices.Concat(posixRlimits, platformRlimits)
+	slices.SortFunc(table, func(a, b rlimitInfo) int {
+		return cmp.Or(
+			cmp.Compare(a.flag|0x20, b.flag|0x20),
+			cmp.Compare(b.flag, a.flag),
+		)
+	})
+	return table
+}()
+
+// the limits go through the syscall package rather than unix so that
+// the runtime knows not to restore its own open files limit for the
+// processes we start
+func getrlimit(resource int) (cur uint64, max uint64, err error) {
+	var rlim syscall.Rlimit
+	err = syscall.Getrlimit(resource, &rlim)
+	return fromRlim(uint64(rlim.Cur)), fromRlim(uint64(rlim.Max)), err
+}
+
+func setrlimit(resource int, cur uint64, max uint64) error {
+	var rlim syscall.Rlimit
+	setRlim(&rlim.Cur, cur)
+	setRlim(&rlim.Max, max)
+	return syscall.Setrlimit(resource, &rlim)
+}
+
+// setRlim sets a field of syscall.Rlimit, which is signed on some
+// platforms.
+func setRlim[T int64 | uint64](field *T, value uint64) {
+	if value == rlimInfinity {
+		value = uint64(unix.RLIM_INFINITY)
+	}
+	*field = T(value)
+}
+
+// fromRlim maps the platform's RLIM_INFINITY, which is not the largest
+// value everywhere, to rlimInfinity.
+func fromRlim(value uint64) uint64 {
+	if value == uint64(unix.RLIM_INFINITY) {
+		return rlimInfinity
+	}
+	return value
+}
@@ -0,0 +1,79 @@
+//go:build unix
+
+package shell
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+func runUlimit(args ...string) (string, error) {
+	c := NewUlimitCommandFunc()()
+	out := &bytes.Buffer{}
+	c.Stdout = out
+	err := c.Run(c, append([]string{"ulimit"}, args...))
+	return out.String(), err
+}
+
+func TestUlimit(t *testing.T) {
+	cur, max, err := getrlimit(unix.RLIMIT_NOFILE)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, setrlimit(unix.RLIMIT_NOFILE, cur, max))
+	}()
+
+	out, err := runUlimit("-a")
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	require.Len(t, lines, len(rlimitTable))
+	assert.Contains(t, lines, formatRlimit(testOpenFiles, cur, true))
+
+	out, err = runUlimit("-n")
+	require.NoError(t, err)
+	assert.Equal(t, formatRlimit(testOpenFiles, cur, false)+"\n", out)
+
+	out, err = runUlimit("-Hn")
+	require.NoError(t, err)
+	assert.Equal(t, formatRlimit(testOpenFiles, max, false)+"\n", out)
+
+	out, err = runUlimit("-n", "-f")
+	require.NoError(t, err)
+	assert.Len(t, strings.Split(strings.TrimSuffix(out, "\n"), "\n"), 2)
+
+	// only the soft limit is lowered with -S
+	lower := min(cur, 256) - 1
+	_, err = runUlimit("-Sn", strconv.FormatUint(lower, 10))
+	require.NoError(t, err)
+	newCur, newMax, err := getrlimit(unix.RLIMIT_NOFILE)
+	require.NoError(t, err)
+	assert.Equal(t, lower, newCur)
+	assert.Equal(t, max, newMax)
+
+	_, err = runUlimit("-Sn", "hard")
+	require.NoError(t, err)
+	out, err = runUlimit("-n")
+	require.NoError(t, err)
+	assert.Equal(t, formatRlimit(testOpenFiles, max, false)+"\n", out)
+}
+
+func TestUlimitErrors(t *testing.T) {
+	tt := []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"-n", "many"}, "ulimit: open files: many: invalid number"},
+		{[]string{"-a", "10"}, "ulimit: cannot set all limits at once"},
+		{[]string{"1", "2"}, "ulimit: too many arguments"},
+	}
+
+	for _, test := range tt {
+		_, err := runUlimit(test.args...)
+		assert.EqualError(t, err, test.err, strings.Join(test.args, " "))
+	}
+}
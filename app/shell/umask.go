@@ -0,0 +1,150 @@
+package shell
+
+import (
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-io/shell-starter-go/app/cmd"
+	"github.com/codecrafters-io/shell-starter-go/assert"
+)
+
+func NewUmaskCommandFunc() cmd.CommandFunc {
+	return func() *cmd.Command {
+		return &cmd.Command{
+			Name: "umask",
+			Spec: &cmd.Spec{
+				Synopsis: "umask [-p] [-S] [mode]",
+				Short:    "Display or set file mode mask.",
+				Flags: []cmd.Flag{
+					{Name: 'p', Usage: "output the mask in a form that may be reused as input"},
+					{Name: 'S', Usage: "output the mask in symbolic form"},
+				},
+			},
+			Run: func(cmd *cmd.Command, args []string) error {
+				assert.Assert(len(args) > 0)
+
+				flags, rest, err := cmd.Spec.Parse(args[1:])
+				if err != nil {
+					return fmt.Errorf("umask: %w", err)
+				}
+				if len(rest) > 1 {
+					return fmt.Errorf("umask: too many arguments")
+				}
+
+				mask := getUmask()
+				if len(rest) == 1 {
+					mask, err = parseUmask(rest[0], mask)
+					if err != nil {
+						return fmt.Errorf("umask: %w", err)
+					}
+					setUmask(mask)
+					return nil
+				}
+
+				_, symbolic := flags['S']
+				value := fmt.Sprintf("%04o", uint32(mask))
+				if symbolic {
+					value = symbolicUmask(mask)
+				}
+
+				if _, reusable := flags['p']; reusable {
+					if symbolic {
+						_, _ = fmt.Fprintf(cmd.Stdout, "umask -S %s\n", value)
+					} else {
+						_, _ = fmt.Fprintf(cmd.Stdout, "umask %s\n", value)
+					}
+					return nil
+				}
+				_, _ = fmt.Fprintln(cmd.Stdout, value)
+				return nil
+			},
+		}
+	}
+}
+
+// parseUmask parses an octal mask or a symbolic mode like `u=rwx,g-w`.
+// Symbolic modes name the permissions to allow and are applied to the
+// current mask.
+func parseUmask(value string, mask fs.FileMode) (fs.FileMode, error) {
+	if len(value) > 0 && value[0] >= '0' && value[0] <= '9' {
+		n, err := strconv.ParseUint(value, 8, 32)
+		if err != nil || n > 0o777 {
+			return 0, fmt.Errorf("%s: octal number out of range", value)
+		}
+		return fs.FileMode(n), nil
+	}
+
+	perm := ^mask & fs.ModePerm
+	for _, clause := range strings.Split(value, ",") {
+		i := 0
+		var who fs.FileMode
+		for ; i < len(clause) && strings.IndexByte("ugoa", clause[i]) >= 0; i++ {
+			switch clause[i] {
+			case 'u':
+				who |= 0o700
+			case 'g':
+				who |= 0o070
+			case 'o':
+				who |= 0o007
+			case 'a':
+				who |= 0o777
+			}
+		}
+		if who == 0 {
+			who = 0o777
+		}
+
+		if i >= len(clause) || strings.IndexByte("+-=", clause[i]) < 0 {
+			return 0, fmt.Errorf("%s: invalid symbolic mode operator", value)
+		}
+		op := clause[i]
+
+		var bits fs.FileMode
+		for _, c := range clause[i+1:] {
+			switch c {
+			case 'r':
+				bits |= 0o444
+			case 'w':
+				bits |= 0o222
+			case 'x':
+				bits |= 0o111
+			default:
+				return 0, fmt.Errorf("%s: invalid symbolic mode character", value)
+			}
+		}
+
+		switch op {
+		case '+':
+			perm |= bits & who
+		case '-':
+			perm &^= bits & who
+		case '=':
+			perm = perm&^who | bits&who
+		}
+	}
+	return ^perm & fs.ModePerm, nil
+}
+
+func symbolicUmask(mask fs.FileMode) string {
+	perm := ^mask & fs.ModePerm
+	parts := make([]string, 0, 3)
+	for i, who := range []string{"u", "g", "o"} {
+		shift := 6 - 3*i
+		bits := perm >> shift & 0o7
+
+		part := who + "="
+		if bits&0o4 != 0 {
+			part += "r"
+		}
+		if bits&0o2 != 0 {
+			part += "w"
+		}
+		if bits&0o1 != 0 {
+			part += "x"
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, ",")
+}
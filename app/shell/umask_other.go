@@ -0,0 +1,16 @@
+//go:build !unix
+
+package shell
+
+import "io/fs"
+
+// umask is only remembered on platforms without one
+var umask fs.FileMode = 0o022
+
+func getUmask() fs.FileMode {
+	return umask
+}
+
+func setUmask(mask fs.FileMode) {
+	umask = mask & fs.ModePerm
+}
@@ -0,0 +1,68 @@
+package shell
+
+import (
+	"io/fs"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseUmask(t *testing.T) {
+	tt := []struct {
+		value string
+		mask  fs.FileMode
+		want  fs.FileMode
+		err   string
+	}{
+		{"022", 0, 0o022, ""},
+		{"7", 0o022, 0o007, ""},
+		{"0777", 0, 0o777, ""},
+		{"1000", 0, 0, "1000: octal number out of range"},
+		{"089", 0, 0, "089: octal number out of range"},
+		{"u=rwx,g=rx,o=", 0o000, 0o027, ""},
+		{"g-w", 0o002, 0o022, ""},
+		{"o+r", 0o027, 0o023, ""},
+		{"a=r", 0o022, 0o333, ""},
+		{"=rx", 0o022, 0o222, ""},
+		{"ug+w,o-rwx", 0o022, 0o007, ""},
+		{"u=", 0o022, 0o722, ""},
+		{"u", 0o022, 0, "u: invalid symbolic mode operator"},
+		{"u=rq", 0o022, 0, "u=rq: invalid symbolic mode character"},
+		{"", 0o022, 0, ": invalid symbolic mode operator"},
+	}
+
+	for _, test := range tt {
+		t.Run(test.value, func(t *testing.T) {
+			mask, err := parseUmask(test.value, test.mask)
+			if len(test.err) > 0 {
+				assert.EqualError(t, err, test.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.want, mask)
+		})
+	}
+}
+
+func TestSymbolicUmask(t *testing.T) {
+	tt := []struct {
+		mask fs.FileMode
+		want string
+	}{
+		{0o022, "u=rwx,g=rx,o=rx"},
+		{0o077, "u=rwx,g=,o="},
+		{0o000, "u=rwx,g=rwx,o=rwx"},
+		{0o777, "u=,g=,o="},
+		{0o246, "u=rx,g=wx,o=x"},
+	}
+
+	for _, test := range tt {
+		t.Run(test.want, func(t *testing.T) {
+			assert.Equal(t, test.want, symbolicUmask(test.mask))
+			// the symbolic form parses back to the same mask
+			mask, err := parseUmask(test.want, 0o777)
+			assert.NoError(t, err)
+			assert.Equal(t, test.mask, mask)
+		})
+	}
+}
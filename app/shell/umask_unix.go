@@ -0,0 +1,22 @@
+//go:build unix
+
+package shell
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// getUmask reads the umask of the process. The mask can only be read by
+// setting it, so it is briefly cleared.
+func getUmask() fs.FileMode {
+	mask := syscall.Umask(0)
+	syscall.Umask(mask)
+	return fs.FileMode(mask)
+}
+
+// setUmask changes the umask of the process which is applied to every
+// file it creates and inherited by its children.
+func setUmask(mask fs.FileMode) {
+	syscall.Umask(int(mask & fs.ModePerm))
+}
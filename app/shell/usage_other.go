@@ -0,0 +1,16 @@
+//go:build !unix
+
+package shell
+
+import (
+	"os"
+
+	"github.com/codecrafters-io/shell-starter-go/app/shell/interpreter"
+)
+
+func processUsage(ps *os.ProcessState) interpreter.Usage {
+	return interpreter.Usage{
+		User: ps.UserTime(),
+		Sys:  ps.SystemTime(),
+	}
+}
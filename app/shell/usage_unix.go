@@ -0,0 +1,26 @@
+//go:build unix
+
+package shell
+
+import (
+	"os"
+	"runtime"
+	"syscall"
+
+	"github.com/codecrafters-io/shell-starter-go/app/shell/interpreter"
+)
+
+func processUsage(ps *os.ProcessState) interpreter.Usage {
+	u := interpreter.Usage{
+		User: ps.UserTime(),
+		Sys:  ps.SystemTime(),
+	}
+	if ru, ok := ps.SysUsage().(*syscall.Rusage); ok {
+		u.MaxRSS = int64(ru.Maxrss)
+		// darwin reports bytes instead of kilobytes
+		if runtime.GOOS == "darwin" {
+			u.MaxRSS /= 1024
+		}
+	}
+	return u
+}
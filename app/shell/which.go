@@ -0,0 +1,63 @@
+package shell
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/codecrafters-io/shell-starter-go/app/cmd"
+	"github.com/codecrafters-io/shell-starter-go/app/shell/interpreter"
+	"github.com/codecrafters-io/shell-starter-go/assert"
+)
+
+func NewWhichCommandFunc(r *cmd.Registry) cmd.CommandFunc {
+	assert.NotNil(r, "registry")
+	return func() *cmd.Command {
+		return &cmd.Command{
+			Name: "which",
+			Spec: &cmd.Spec{
+				Synopsis: "which [-a] name [name ...]",
+				Short:    "Locate a command in PATH.",
+				Flags: []cmd.Flag{
+					{Name: 'a', Usage: "print all matching executables"},
+				},
+				Args: cmd.ArgCommand,
+			},
+			Run: func(cmd *cmd.Command, args []string) error {
+				assert.Assert(len(args) > 0)
+
+				flags, names, err := cmd.Spec.Parse(args[1:])
+				if err != nil {
+					return fmt.Errorf("which: %w", err)
+				}
+				_, all := flags['a']
+
+				var status error
+				for _, name := range names {
+					candidates := r.LookupAll(name)
+					if len(candidates) == 0 {
+						_, _ = fmt.Fprintf(cmd.Stdout, "%s not found\n", name)
+						status = interpreter.ExitStatus(1)
+						continue
+					}
+					if !all {
+						candidates = candidates[:1]
+					}
+
+					for _, c := range candidates {
+						printWhich(cmd.Stdout, c)
+					}
+				}
+				return status
+			},
+		}
+	}
+}
+
+func printWhich(w io.Writer, c cmd.Candidate) {
+	switch c.Kind {
+	case cmd.KindBuiltin:
+		_, _ = fmt.Fprintf(w, "%s: shell built-in command\n", c.Name)
+	case cmd.KindFile:
+		_, _ = fmt.Fprintln(w, c.Path)
+	}
+}
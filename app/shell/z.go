@@ -0,0 +1,211 @@
+package shell
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/codecrafters-io/shell-starter-go/app/cmd"
+	"github.com/codecrafters-io/shell-starter-go/app/shell/interpreter"
+	"github.com/codecrafters-io/shell-starter-go/app/shell/jump"
+	"github.com/codecrafters-io/shell-starter-go/app/shell/terminal"
+	"github.com/codecrafters-io/shell-starter-go/assert"
+)
+
+const (
+	// maxFrecentDirs bounds the directories offered by completion and zi
+	maxFrecentDirs = 20
+	dirDBFileName  = "shell-go-dirs"
+)
+
+func NewZCommandFunc(s *Shell) cmd.CommandFunc {
+	assert.NotNil(s, "shell")
+	return func() *cmd.Command {
+		return &cmd.Command{
+			Name: "z",
+			Spec: &cmd.Spec{
+				Synopsis: "z [-clx] [term ...]",
+				Short:    "Jump to a frequently and recently visited directory.",
+				Long: "Changes to the highest ranked directory whose path contains the TERMs in\n" +
+					"order, the last one in its last component. Terms without upper case\n" +
+					"letters ignore case.",
+				Flags: []cmd.Flag{
+					{Name: 'c', Usage: "only match subdirectories of the current directory"},
+					{Name: 'l', Usage: "list the matches with their scores instead"},
+					{Name: 'x', Usage: "remove the current directory from the database"},
+				},
+			},
+			Run: func(cmd *cmd.Command, args []string) error {
+				assert.Assert(len(args) > 0)
+
+				flags, terms, err := cmd.Spec.Parse(args[1:])
+				if err != nil {
+					return fmt.Errorf("z: %w", err)
+				}
+
+				if _, ok := flags['x']; ok {
+					s.dirDB.Remove(s.WorkingDir)
+					s.dirDBChanged = true
+					return nil
+				}
+
+				_, below := flags['c']
+				_, list := flags['l']
+				list = list || len(terms) == 0
+				limit := 1
+				if list {
+					limit = 0
+				}
+				entries := s.queryDirs(terms, below, limit)
+
+				if list {
+					now := time.Now()
+					// the best match is printed last, closest to the prompt
+					for _, e := range slices.Backward(entries) {
+						_, _ = fmt.Fprintf(cmd.Stdout, "%-10.2f %s\n", e.Score(now), e.Dir)
+					}
+					return nil
+				}
+
+				if len(entries) == 0 {
+					_, _ = fmt.Fprintln(cmd.Stderr, "z: no match found")
+					return interpreter.ExitStatus(1)
+				}
+				if err := s.chdir(entries[0].Dir); err != nil {
+					_, _ = fmt.Fprintf(cmd.Stderr, "z: %s: %s\n", entries[0].Dir, err)
+					return interpreter.ExitStatus(1)
+				}
+				return nil
+			},
+		}
+	}
+}
+
+func NewZICommandFunc(s *Shell) cmd.CommandFunc {
+	assert.NotNil(s, "shell")
+	return func() *cmd.Command {
+		return &cmd.Command{
+			Name: "zi",
+			Spec: &cmd.Spec{
+				Synopsis: "zi [-c] [term ...]",
+				Short:    "Pick a directory to jump to among the matches of z.",
+				Flags: []cmd.Flag{
+					{Name: 'c', Usage: "only match subdirectories of the current directory"},
+				},
+			},
+			Run: func(cmd *cmd.Command, args []string) error {
+				assert.Assert(len(args) > 0)
+
+				flags, terms, err := cmd.Spec.Parse(args[1:])
+				if err != nil {
+					return fmt.Errorf("zi: %w", err)
+				}
+				if cmd.Stdin != s.Stdin || s.tr == nil {
+					return fmt.Errorf("zi: input is not a terminal")
+				}
+
+				_, below := flags['c']
+				entries := s.queryDirs(terms, below, maxFrecentDirs)
+				if len(entries) == 0 {
+					_, _ = fmt.Fprintln(cmd.Stderr, "zi: no match found")
+					return interpreter.ExitStatus(1)
+				}
+
+				dirs := make([]string, len(entries))
+				for i, e := range entries {
+					dirs[i] = s.abbreviateHome(e.Dir)
+				}
+				picked, err := s.tr.Pick(dirs, terminal.PickOptions{Prompt: "zi> "})
+				// the pick leaves the line with the command drawn again
+				s.tr.Erase()
+				if err != nil || len(picked) == 0 {
+					return interpreter.ExitStatus(1)
+				}
+				dir := entries[slices.Index(dirs, picked[0])].Dir
+
+				if err := s.chdir(dir); err != nil {
+					_, _ = fmt.Fprintf(cmd.Stderr, "zi: %s: %s\n", dir, err)
+					return interpreter.ExitStatus(1)
+				}
+				return nil
+			},
+		}
+	}
+}
+
+// FrecentDirs returns the visited directories matching the terms in
+// query, best first.
+func (s *Shell) FrecentDirs(query string) []string {
+	entries := s.queryDirs(strings.Fields(query), false, maxFrecentDirs)
+	dirs := make([]string, 0, len(entries))
+	for _, e := range entries {
+		dirs = append(dirs, e.Dir)
+	}
+	return dirs
+}
+
+// queryDirs returns up to limit visited directories matching terms, all
+// of them when limit is 0. Only the directories returned or passed over
+// are checked, and the ones that no longer exist are dropped from the
+// database.
+func (s *Shell) queryDirs(terms []string, below bool, limit int) []jump.Entry {
+	entries := make([]jump.Entry, 0)
+	for _, e := range s.dirDB.Query(terms, time.Now()) {
+		if below && !strings.HasPrefix(e.Dir, s.WorkingDir+string(filepath.Separator)) {
+			continue
+		}
+
+		fi, err := fs.Stat(s.FS, e.Dir)
+		switch {
+		case err == nil && fi.IsDir():
+			entries = append(entries, e)
+		case err == nil || errors.Is(err, fs.ErrNotExist):
+			s.dirDB.Remove(e.Dir)
+			s.dirDBChanged = true
+		}
+		// other errors, e.g. from a mount that is gone for now, leave
+		// the directory in the database
+
+		if limit > 0 && len(entries) == limit {
+			break
+		}
+	}
+	return entries
+}
+
+// recordDir adds a visit of dir to the directory database. The home
+// directory is not recorded as it is always one cd away.
+func (s *Shell) recordDir(dir string) {
+	if home, ok := s.homeDir(); ok && dir == home {
+		return
+	}
+	s.dirDB.Visit(dir, time.Now())
+	s.dirDBChanged = true
+}
+
+func (s *Shell) loadDirDB() {
+	s.dirDB = jump.NewDB()
+	if filename := s.dataFile(dirDBFileName); len(filename) > 0 {
+		_ = jump.ReadFromFile(s.dirDB, s.FS, filename)
+	}
+}
+
+// saveDirDB writes the directory database if it changed, which is done
+// once on exit rather than on every cd.
+func (s *Shell) saveDirDB() {
+	if !s.dirDBChanged {
+		return
+	}
+	err := s.writeDataFile(dirDBFileName, func(filename string) error {
+		return jump.WriteToFile(s.dirDB, s.FS, filename)
+	})
+	if err != nil {
+		fmt.Fprintf(s.Stderr, "failed to save directory history: %s\n", err)
+		return
+	}
+	s.dirDBChanged = false
+}
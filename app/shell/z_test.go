@@ -0,0 +1,79 @@
+package shell
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/codecrafters-io/shell-starter-go/app/shell/jump"
+	"github.com/codecrafters-io/shell-starter-go/app/shell/shelltest"
+	"github.com/stretchr/testify/assert"
+)
+
+// statFS counts the files statted and fails to stat the ones in denied.
+type statFS struct {
+	shelltest.OSFS
+	stats  []string
+	denied map[string]bool
+}
+
+func (f *statFS) Stat(name string) (fs.FileInfo, error) {
+	f.stats = append(f.stats, name)
+	if f.denied[name] {
+		return nil, fs.ErrPermission
+	}
+	return os.Stat(name)
+}
+
+func TestQueryDirs(t *testing.T) {
+	root := t.TempDir()
+	dir := func(name string) string { return filepath.Join(root, name) }
+	for _, name := range []string{"a1", "a2", "a3"} {
+		assert.NoError(t, os.Mkdir(dir(name), 0o755))
+	}
+	assert.NoError(t, os.WriteFile(dir("afile"), nil, 0o644))
+
+	fsys := &statFS{denied: map[string]bool{dir("adenied"): true}}
+	s := &Shell{FS: fsys, WorkingDir: root, dirDB: jump.NewDB()}
+	now := time.Now()
+	// later visits rank higher
+	for i, name := range []string{"a3", "a2", "afile", "agone", "adenied", "a1"} {
+		for range i + 1 {
+			s.dirDB.Visit(dir(name), now)
+		}
+	}
+
+	entries := s.queryDirs([]string{"a"}, false, 1)
+	assert.Equal(t, []jump.Entry{{Dir: dir("a1")}}, dirsOf(entries))
+	assert.Equal(t, []string{dir("a1")}, fsys.stats)
+	assert.False(t, s.dirDBChanged)
+
+	// directories that are gone or became files are dropped, the ones
+	// that cannot be checked are kept
+	fsys.stats = nil
+	entries = s.queryDirs([]string{"a"}, false, 0)
+	assert.Equal(t, []jump.Entry{{Dir: dir("a1")}, {Dir: dir("a2")}, {Dir: dir("a3")}}, dirsOf(entries))
+	assert.Len(t, fsys.stats, 6)
+	assert.True(t, s.dirDBChanged)
+	assert.Equal(t, 4, s.dirDB.Len())
+}
+
+func dirsOf(entries []jump.Entry) []jump.Entry {
+	dirs := make([]jump.Entry, len(entries))
+	for i, e := range entries {
+		dirs[i] = jump.Entry{Dir: e.Dir}
+	}
+	return dirs
+}
+
+func TestDirDBSavedOnExit(t *testing.T) {
+	home := t.TempDir()
+	visited := t.TempDir()
+	runShell(t, "cd "+visited+"\n", shelltest.MapEnv{"HOME": home})
+
+	data, err := os.ReadFile(filepath.Join(home, ".local", "share", dirDBFileName))
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), visited)
+}
@@ -2,13 +2,13 @@ module github.com/codecrafters-io/shell-starter-go
 
 go 1.25.0
 
-require github.com/stretchr/testify v1.11.1
-
 require (
-	github.com/yuin/gopher-lua v1.1.1 // indirect
-	golang.org/x/sys v0.39.0 // indirect
+	github.com/stretchr/testify v1.11.1
+	github.com/yuin/gopher-lua v1.1.1
 )
 
+require golang.org/x/sys v0.39.0
+
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
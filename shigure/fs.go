@@ -17,6 +17,10 @@ func (_ filesystem) ReadDir(name string) ([]fs.DirEntry, error) {
 	return nil, nil
 }
 
+func (filesystem) MkdirAll(name string, perm fs.FileMode) error {
+	return errors.New("not implemented")
+}
+
 func (filesystem) OpenFile(name string, flags int) (io.ReadWriteCloser, error) {
 	return nil, os.ErrNotExist
 }
@@ -76,3 +76,15 @@ type env struct{}
 func (e env) Get(key string) string {
 	return os.Getenv(key)
 }
+
+func (e env) Lookup(key string) (string, bool) {
+	return os.LookupEnv(key)
+}
+
+func (e env) Set(key, value string) error {
+	return os.Setenv(key, value)
+}
+
+func (e env) Unset(key string) error {
+	return os.Unsetenv(key)
+}